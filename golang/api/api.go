@@ -2,47 +2,142 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"time"
 
 	"bitcoin-app-golang/config"
 )
 
-type API struct{}
+const DefaultHTTPTimeout = 10 * time.Second
+
+type API struct {
+	// nilの場合はhttp.DefaultClientを使う
+	Client      *http.Client
+	RetryPolicy RetryPolicy
+}
 
 func NewAPI() *API {
-	return &API{}
+	return NewAPIWithRetryPolicy(DefaultRetryPolicy)
+}
+
+func NewAPIWithRetryPolicy(p RetryPolicy) *API {
+	return NewAPIWithClient(NewHTTPClient(config.HTTPClient{}), p)
+}
+
+func NewAPIWithClient(client *http.Client, p RetryPolicy) *API {
+	return &API{
+		Client:      client,
+		RetryPolicy: p,
+	}
+}
+
+func NewAPIFromConfig(cfg config.Config) *API {
+	return NewAPIWithClient(NewHTTPClient(cfg.HTTPClient), NewRetryPolicy(cfg.Retry))
+}
+
+func NewHTTPClient(cfg config.HTTPClient) *http.Client {
+	timeout := DefaultHTTPTimeout
+	if cfg.TimeoutSec > 0 {
+		timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	}
+	return &http.Client{
+		Timeout: timeout,
+	}
+}
+
+func (api *API) Do(ctx context.Context, method string, reqModel, resModel any, url string, headerMap map[string]any) error {
+	_, err := api.DoWithHeader(ctx, method, reqModel, resModel, url, headerMap)
+	return err
+}
+
+// AttemptHook はリトライを含む試行ごとに、送る前と応答を受けた後に呼ばれる。
+// Beforeがエラーを返した場合はその試行を送らずに終える
+type AttemptHook struct {
+	Before func(ctx context.Context) error
+	After  func(header http.Header, err error)
 }
 
-func (api *API) Do(method string, reqModel, resModel any, url string, headerMap map[string]any) error {
+// レスポンスヘッダーも返す。ステータスコードが400以上の場合もヘッダーは返す。
+// GETはRetryPolicyに従ってリトライし、2回以上試行して失敗した場合はRetryErrorを返す
+func (api *API) DoWithHeader(ctx context.Context, method string, reqModel, resModel any, url string, headerMap map[string]any) (http.Header, error) {
+	return api.DoWithAttemptHook(ctx, method, reqModel, resModel, url, headerMap, AttemptHook{})
+}
+
+func (api *API) DoWithAttemptHook(ctx context.Context, method string, reqModel, resModel any, url string, headerMap map[string]any, hook AttemptHook) (http.Header, error) {
 	reqJson, err := marshalJson(reqModel)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	res, err := request(method, url, reqJson, convertToStringMap(headerMap))
+	attempts := api.RetryPolicy.attempts(method)
+	for attempt := 1; ; attempt++ {
+		if hook.Before != nil {
+			if err := hook.Before(ctx); err != nil {
+				if attempt > 1 {
+					return nil, &RetryError{Attempts: attempt - 1, Err: err}
+				}
+				return nil, err
+			}
+		}
+		header, err := api.do(ctx, method, reqJson, resModel, url, convertToStringMap(headerMap))
+		if hook.After != nil {
+			hook.After(header, err)
+		}
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Request succeeded after %d attempts: %s %s", attempt, method, url)
+			}
+			return header, nil
+		}
+
+		// 呼び出し元のctxがキャンセルされた場合はリトライしない
+		if attempt >= attempts || ctx.Err() != nil || !isRetryableError(err) {
+			if attempt > 1 {
+				return header, &RetryError{Attempts: attempt, Err: err}
+			}
+			return header, err
+		}
+
+		wait := api.RetryPolicy.delay(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusTooManyRequests {
+			var ok bool
+			if wait, ok = api.RetryPolicy.retryAfter(attempt, header, time.Now()); !ok {
+				return header, err
+			}
+		}
+		log.Printf("Retrying %s %s in %s (attempt %d/%d): %v", method, url, wait.Round(time.Millisecond), attempt+1, attempts, err)
+		if err := retrySleep(ctx, wait); err != nil {
+			return header, &RetryError{Attempts: attempt, Err: err}
+		}
+	}
+}
+
+func (api *API) do(ctx context.Context, method string, reqJson []byte, resModel any, url string, headers map[string]string) (http.Header, error) {
+	res, err := api.request(ctx, method, url, reqJson, headers)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	resJson, err := readResponse(res)
 	if err != nil {
-		return err
+		return res.Header, err
 	}
 
 	if resModel == nil {
-		return nil
+		return res.Header, nil
 	}
 
-	return json.Unmarshal(resJson, resModel)
+	return res.Header, json.Unmarshal(resJson, resModel)
 }
 
-func request(method, url string, body []byte, headers map[string]string) (*http.Response, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+func (api *API) request(ctx context.Context, method, url string, body []byte, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -55,7 +150,11 @@ func request(method, url string, body []byte, headers map[string]string) (*http.
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	return http.DefaultClient.Do(req)
+	client := api.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
 }
 
 func readResponse(resp *http.Response) ([]byte, error) {
@@ -71,8 +170,7 @@ func readResponse(resp *http.Response) ([]byte, error) {
 	}
 
 	if resp.StatusCode >= 400 {
-		fmt.Printf("status code: %d, body: %s", resp.StatusCode, string(body))
-		return nil, errors.New(string(body))
+		return nil, newAPIError(resp.StatusCode, body)
 	}
 
 	return body, nil
@@ -1,8 +1,14 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"bitcoin-app-golang/config"
 )
@@ -75,3 +81,41 @@ func Test_marshalJson(t *testing.T) {
 		})
 	}
 }
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.HTTPClient
+		want time.Duration
+	}{
+		{name: "default", cfg: config.HTTPClient{}, want: DefaultHTTPTimeout},
+		{name: "from config", cfg: config.HTTPClient{TimeoutSec: 3}, want: 3 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewHTTPClient(tt.cfg).Timeout; got != tt.want {
+				t.Errorf("NewHTTPClient().Timeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI_Do_canceled(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := NewAPIWithClient(server.Client(), DefaultRetryPolicy).Do(ctx, http.MethodGet, nil, &struct{}{}, server.URL, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("API.Do() error = %v, want context.DeadlineExceeded", err)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("API.Do() attempts = %d, want 1", n)
+	}
+}
@@ -1,10 +1,12 @@
 package api
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"net/url"
@@ -12,13 +14,48 @@ import (
 	"time"
 
 	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
 )
 
+// config.ServerURL.BitFlyerが空の場合に使う
 const BitFlyerBaseURL = "https://api.bitflyer.com"
 
+const (
+	// child_order_dateはタイムゾーンなしのUTC
+	childOrderDateLayout = "2006-01-02T15:04:05"
+	// 発注の照合でさかのぼる件数と、手元と取引所の時刻のずれの許容
+	reconcileLookupCount = 20
+	reconcileClockSkew   = 5 * time.Second
+	reconcileTimeout     = 30 * time.Second
+)
+
 type IBitFlyerAPI interface {
-	GetTicker(string) (TickerFromBitFlyer, error)
-	SendChildOrder(SendChildOrderRequest, bool) (SendChildOrderResponse, error)
+	GetTicker(context.Context, string) (TickerFromBitFlyer, error)
+	GetMarkets(context.Context) ([]MarketFromBitFlyer, error)
+	GetBoard(context.Context, string) (BoardFromBitFlyer, error)
+	GetExecutions(context.Context, string, Pagination) ([]ExecutionFromBitFlyer, error)
+	GetHealth(context.Context, string) (HealthFromBitFlyer, error)
+	GetBoardState(context.Context, string) (BoardStateFromBitFlyer, error)
+	GetFundingRate(context.Context, string) (FundingRateFromBitFlyer, error)
+	SendChildOrder(context.Context, SendChildOrderRequest, bool) (SendChildOrderResponse, error)
+	CancelChildOrder(context.Context, CancelChildOrderRequest, bool) error
+	CancelAllChildOrders(context.Context, CancelAllChildOrdersRequest, bool) error
+	GetChildOrders(context.Context, GetChildOrdersRequest) ([]ChildOrderFromBitFlyer, error)
+	SendParentOrder(context.Context, SendParentOrderRequest, bool) (SendParentOrderResponse, error)
+	GetParentOrders(context.Context, GetParentOrdersRequest) ([]ParentOrderFromBitFlyer, error)
+	GetParentOrder(context.Context, GetParentOrderRequest) (ParentOrderDetailFromBitFlyer, error)
+	CancelParentOrder(context.Context, CancelParentOrderRequest, bool) error
+	GetBalance(context.Context) ([]BalanceFromBitFlyer, error)
+	GetCollateral(context.Context) (CollateralFromBitFlyer, error)
+	GetCollateralHistory(context.Context, Pagination) ([]CollateralHistoryFromBitFlyer, error)
+	GetPositions(context.Context, string) ([]PositionFromBitFlyer, error)
+	GetMyExecutions(context.Context, GetMyExecutionsRequest) ([]MyExecutionFromBitFlyer, error)
+	GetTradingCommission(context.Context, string) (TradingCommissionFromBitFlyer, error)
+	GetCoinIns(context.Context, Pagination) ([]CoinInFromBitFlyer, error)
+	GetCoinOuts(context.Context, Pagination) ([]CoinOutFromBitFlyer, error)
+	GetDeposits(context.Context, Pagination) ([]DepositFromBitFlyer, error)
+	GetWithdrawals(context.Context, Pagination) ([]WithdrawalFromBitFlyer, error)
+	GetRateLimitBudget() []RateLimitBudget
 }
 
 type BitFlyerAPI struct {
@@ -29,55 +66,471 @@ type BitFlyerAPI struct {
 func NewBitFlyerAPI(cfg config.Config) IBitFlyerAPI {
 	return &BitFlyerAPI{
 		Config: cfg,
-		API:    NewAPI(),
+		API:    NewAPIFromConfig(cfg),
+	}
+}
+
+func (b *BitFlyerAPI) baseURL() string {
+	if b.Config.ServerURL.BitFlyer != "" {
+		return b.Config.ServerURL.BitFlyer
 	}
+	return BitFlyerBaseURL
 }
 
-func (b *BitFlyerAPI) GetTicker(productCode string) (TickerFromBitFlyer, error) {
-	url, err := BitFlyerURL(BitFlyerBaseURL).GetTicker(productCode)
+func (b *BitFlyerAPI) GetTicker(ctx context.Context, productCode string) (TickerFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetTicker(productCode)
 	if err != nil {
 		return TickerFromBitFlyer{}, err
 	}
 
 	resModel := TickerFromBitFlyer{}
-	if err := b.API.Do(http.MethodGet, nil, &resModel, url, nil); err != nil {
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
 		return TickerFromBitFlyer{}, err
 	}
 	return resModel, nil
 }
 
-func (b *BitFlyerAPI) SendChildOrder(args SendChildOrderRequest, isDry bool) (SendChildOrderResponse, error) {
-	url, err := BitFlyerURL(BitFlyerBaseURL).SendChildOrder()
+func (b *BitFlyerAPI) GetMarkets(ctx context.Context) ([]MarketFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetMarkets()
 	if err != nil {
-		return SendChildOrderResponse{}, err
+		return nil, err
 	}
 
-	body, err := json.Marshal(args)
+	var resModel []MarketFromBitFlyer
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetBoard(ctx context.Context, productCode string) (BoardFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetBoard(productCode)
 	if err != nil {
-		return SendChildOrderResponse{}, err
+		return BoardFromBitFlyer{}, err
+	}
+
+	resModel := BoardFromBitFlyer{}
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return BoardFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetExecutions(ctx context.Context, productCode string, p Pagination) ([]ExecutionFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetExecutions(productCode, p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []ExecutionFromBitFlyer
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetHealth(ctx context.Context, productCode string) (HealthFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetHealth(productCode)
+	if err != nil {
+		return HealthFromBitFlyer{}, err
+	}
+
+	resModel := HealthFromBitFlyer{}
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return HealthFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetBoardState(ctx context.Context, productCode string) (BoardStateFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetBoardState(productCode)
+	if err != nil {
+		return BoardStateFromBitFlyer{}, err
+	}
+
+	resModel := BoardStateFromBitFlyer{}
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return BoardStateFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetFundingRate(ctx context.Context, productCode string) (FundingRateFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetFundingRate(productCode)
+	if err != nil {
+		return FundingRateFromBitFlyer{}, err
+	}
+
+	resModel := FundingRateFromBitFlyer{}
+	if err := b.doPublic(ctx, http.MethodGet, &resModel, url); err != nil {
+		return FundingRateFromBitFlyer{}, err
 	}
+	return resModel, nil
+}
 
-	authHeaders, err := b.privateRequestHeader(nowUnixTimestamp(), http.MethodPost, url, body)
+func (b *BitFlyerAPI) SendChildOrder(ctx context.Context, args SendChildOrderRequest, isDry bool) (SendChildOrderResponse, error) {
+	url, err := BitFlyerURL(b.baseURL()).SendChildOrder()
 	if err != nil {
 		return SendChildOrderResponse{}, err
 	}
 
+	if isDry {
+		return b.simulateChildOrder(ctx, args)
+	}
+
 	resModel := SendChildOrderResponse{}
 
-	if isDry {
-		log.Default().Println("Dry run: SendChildOrder is not executed")
-	} else {
-		if err := b.API.Do(http.MethodPost, args, &resModel, url, authHeaders); err != nil {
+	sentAt := time.Now()
+	if err := b.doPrivate(ctx, http.MethodPost, args, &resModel, url); err != nil {
+		if !isAmbiguousError(err) {
+			return SendChildOrderResponse{}, err
+		}
+		// 二重発注を避けるため発注はリトライせず、取引所に届いていたかを注文一覧で確認する
+		log.Printf("SendChildOrder failed with an ambiguous error, reconciling through child orders: %v", err)
+		// 呼び出し元がキャンセルしていても、発注が通ったかどうかは確認しておく
+		reconcileCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), reconcileTimeout)
+		defer cancel()
+		reconciled, ok := b.reconcileChildOrder(reconcileCtx, args, sentAt)
+		if !ok {
 			return SendChildOrderResponse{}, err
 		}
+		log.Printf("SendChildOrder reconciled: child_order_acceptance_id=%s", reconciled.ChildOrderAcceptanceID)
+		return reconciled, nil
+	}
+	return resModel, nil
+}
+
+// 発注時刻以降に同じ内容で受け付けられた子注文を探す。見つからない場合はfalseを返す
+func (b *BitFlyerAPI) reconcileChildOrder(ctx context.Context, args SendChildOrderRequest, sentAt time.Time) (SendChildOrderResponse, bool) {
+	attempts := max(b.API.RetryPolicy.MaxAttempts, 1)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		// 取引所側で注文一覧に反映されるまで少し時間がかかる
+		if err := retrySleep(ctx, b.API.RetryPolicy.delay(attempt)); err != nil {
+			break
+		}
+
+		orders, err := b.GetChildOrders(ctx, GetChildOrdersRequest{
+			ProductCode: args.ProductCode,
+			Pagination:  Pagination{Count: reconcileLookupCount},
+		})
+		if err != nil {
+			log.Printf("Error looking up child orders for reconciliation (attempt %d/%d): %v", attempt, attempts, err)
+			continue
+		}
+		if o, ok := FindSentChildOrder(orders, args, sentAt); ok {
+			return SendChildOrderResponse{
+				ChildOrderAcceptanceID: o.ChildOrderAcceptanceID,
+				Reconciled:             true,
+			}, true
+		}
+	}
+	return SendChildOrderResponse{}, false
+}
+
+// 注文一覧は新しい順なので、最初に一致したものが今回の発注。usecaseでも結果が分からない発注の照合に使う
+func FindSentChildOrder(orders []ChildOrderFromBitFlyer, args SendChildOrderRequest, sentAt time.Time) (ChildOrderFromBitFlyer, bool) {
+	since := sentAt.Add(-reconcileClockSkew)
+	for _, o := range orders {
+		if o.Side != args.Side || o.ChildOrderType != args.ChildOrderType || o.Size != args.Size {
+			continue
+		}
+		if args.ChildOrderType == consts.ChildOrderTypeLimit && o.Price != args.Price {
+			continue
+		}
+		orderedAt, err := time.Parse(childOrderDateLayout, o.ChildOrderDate)
+		if err != nil || orderedAt.Before(since) {
+			continue
+		}
+		return o, true
+	}
+	return ChildOrderFromBitFlyer{}, false
+}
+
+func (b *BitFlyerAPI) CancelChildOrder(ctx context.Context, args CancelChildOrderRequest, isDry bool) error {
+	url, err := BitFlyerURL(b.baseURL()).CancelChildOrder()
+	if err != nil {
+		return err
+	}
+
+	if isDry {
+		log.Default().Println("Dry run: CancelChildOrder is not executed")
+		return nil
+	}
+
+	// 成功時のレスポンスボディは空
+	return b.doPrivate(ctx, http.MethodPost, args, nil, url)
+}
+
+func (b *BitFlyerAPI) CancelAllChildOrders(ctx context.Context, args CancelAllChildOrdersRequest, isDry bool) error {
+	url, err := BitFlyerURL(b.baseURL()).CancelAllChildOrders()
+	if err != nil {
+		return err
+	}
+
+	if isDry {
+		log.Default().Println("Dry run: CancelAllChildOrders is not executed")
+		return nil
+	}
+
+	return b.doPrivate(ctx, http.MethodPost, args, nil, url)
+}
+
+func (b *BitFlyerAPI) GetChildOrders(ctx context.Context, req GetChildOrdersRequest) ([]ChildOrderFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetChildOrders(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []ChildOrderFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) SendParentOrder(ctx context.Context, args SendParentOrderRequest, isDry bool) (SendParentOrderResponse, error) {
+	url, err := BitFlyerURL(b.baseURL()).SendParentOrder()
+	if err != nil {
+		return SendParentOrderResponse{}, err
+	}
+
+	resModel := SendParentOrderResponse{}
+
+	if isDry {
+		log.Default().Println("Dry run: SendParentOrder is not executed")
+		return resModel, nil
+	}
+
+	if err := b.doPrivate(ctx, http.MethodPost, args, &resModel, url); err != nil {
+		return SendParentOrderResponse{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetParentOrders(ctx context.Context, req GetParentOrdersRequest) ([]ParentOrderFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetParentOrders(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []ParentOrderFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetParentOrder(ctx context.Context, req GetParentOrderRequest) (ParentOrderDetailFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetParentOrder(req)
+	if err != nil {
+		return ParentOrderDetailFromBitFlyer{}, err
+	}
+
+	resModel := ParentOrderDetailFromBitFlyer{}
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return ParentOrderDetailFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) CancelParentOrder(ctx context.Context, args CancelParentOrderRequest, isDry bool) error {
+	url, err := BitFlyerURL(b.baseURL()).CancelParentOrder()
+	if err != nil {
+		return err
+	}
+
+	if isDry {
+		log.Default().Println("Dry run: CancelParentOrder is not executed")
+		return nil
+	}
+
+	return b.doPrivate(ctx, http.MethodPost, args, nil, url)
+}
+
+func (b *BitFlyerAPI) GetBalance(ctx context.Context) ([]BalanceFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetBalance()
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []BalanceFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetCollateral(ctx context.Context) (CollateralFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetCollateral()
+	if err != nil {
+		return CollateralFromBitFlyer{}, err
+	}
+
+	resModel := CollateralFromBitFlyer{}
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return CollateralFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetCollateralHistory(ctx context.Context, p Pagination) ([]CollateralHistoryFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetCollateralHistory(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []CollateralHistoryFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetPositions(ctx context.Context, productCode string) ([]PositionFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetPositions(productCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []PositionFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetMyExecutions(ctx context.Context, req GetMyExecutionsRequest) ([]MyExecutionFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetMyExecutions(req)
+	if err != nil {
+		return nil, err
 	}
 
+	var resModel []MyExecutionFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
 	return resModel, nil
 }
 
+func (b *BitFlyerAPI) GetTradingCommission(ctx context.Context, productCode string) (TradingCommissionFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetTradingCommission(productCode)
+	if err != nil {
+		return TradingCommissionFromBitFlyer{}, err
+	}
+
+	resModel := TradingCommissionFromBitFlyer{}
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return TradingCommissionFromBitFlyer{}, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetCoinIns(ctx context.Context, p Pagination) ([]CoinInFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetCoinIns(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []CoinInFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetCoinOuts(ctx context.Context, p Pagination) ([]CoinOutFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetCoinOuts(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []CoinOutFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetDeposits(ctx context.Context, p Pagination) ([]DepositFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetDeposits(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []DepositFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+func (b *BitFlyerAPI) GetWithdrawals(ctx context.Context, p Pagination) ([]WithdrawalFromBitFlyer, error) {
+	url, err := BitFlyerURL(b.baseURL()).GetWithdrawals(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var resModel []WithdrawalFromBitFlyer
+	if err := b.doPrivate(ctx, http.MethodGet, nil, &resModel, url); err != nil {
+		return nil, err
+	}
+	return resModel, nil
+}
+
+// Private APIを認証ヘッダー付きで実行する
+func (b *BitFlyerAPI) doPrivate(ctx context.Context, method string, reqModel, resModel any, url string) error {
+	body, err := marshalJson(reqModel)
+	if err != nil {
+		return err
+	}
+
+	authHeaders, err := b.privateRequestHeader(nowUnixTimestamp(), method, url, body)
+	if err != nil {
+		return err
+	}
+
+	class, err := rateLimitClassOf(url)
+	if err != nil {
+		return err
+	}
+
+	return b.do(ctx, class, method, reqModel, resModel, url, authHeaders)
+}
+
+func (b *BitFlyerAPI) doPublic(ctx context.Context, method string, resModel any, url string) error {
+	return b.do(ctx, RateLimitClassPublic, method, nil, resModel, url, nil)
+}
+
+// リトライを含む試行ごとにレート制限の枠を取ってから実行し、レスポンスのX-RateLimit-*ヘッダーを反映する。
+// 429を受けた後のリトライもResetまで止める制限に従う
+func (b *BitFlyerAPI) do(ctx context.Context, class RateLimitClass, method string, reqModel, resModel any, url string, headerMap map[string]any) error {
+	_, err := b.API.DoWithAttemptHook(ctx, method, reqModel, resModel, url, headerMap, rateLimitHook(defaultRateLimiter, class))
+	return err
+}
+
+func rateLimitHook(r *RateLimiter, class RateLimitClass) AttemptHook {
+	return AttemptHook{
+		Before: func(ctx context.Context) error {
+			return r.Acquire(ctx, class)
+		},
+		After: func(header http.Header, err error) {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusTooManyRequests {
+				r.ObserveRateLimited(class, header)
+			} else {
+				r.Observe(class, header)
+			}
+		},
+	}
+}
+
+func (b *BitFlyerAPI) GetRateLimitBudget() []RateLimitBudget {
+	return defaultRateLimiter.Budget()
+}
+
 // https://lightning.bitflyer.com/docs#%E8%AA%8D%E8%A8%BC:~:text=%E4%BA%86%E6%89%BF%E3%81%8F%E3%81%A0%E3%81%95%E3%81%84%E3%80%82-,%E8%AA%8D%E8%A8%BC,-Private%20API%20%E3%81%AE
 func (api *BitFlyerAPI) privateRequestHeader(timeStamp, method, url string, body []byte) (map[string]any, error) {
-	path, err := extractPath(url)
+	path, err := extractPathWithQuery(url)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +558,18 @@ func extractPath(u string) (string, error) {
 	return uObj.Path, nil
 }
 
+// GETのクエリパラメータも署名の対象に含める
+func extractPathWithQuery(u string) (string, error) {
+	uObj, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	if uObj.RawQuery == "" {
+		return uObj.Path, nil
+	}
+	return uObj.Path + "?" + uObj.RawQuery, nil
+}
+
 func nowUnixTimestamp() string {
 	return strconv.FormatInt(time.Now().Unix(), 10)
 }
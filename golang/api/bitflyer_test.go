@@ -1,8 +1,16 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"bitcoin-app-golang/config"
 	"bitcoin-app-golang/consts"
@@ -24,7 +32,7 @@ func TestNewBitFlyerAPI(t *testing.T) {
 			},
 			want: &BitFlyerAPI{
 				Config: testConfig,
-				API:    NewAPI(),
+				API:    NewAPIWithRetryPolicy(NewRetryPolicy(testConfig.Retry)),
 			},
 		},
 	}
@@ -87,7 +95,7 @@ func TestBitFlyerAPI_GetTicker(t *testing.T) {
 				Config: tt.fields.Config,
 				API:    tt.fields.API,
 			}
-			got, err := b.GetTicker(tt.args.productCode)
+			got, err := b.GetTicker(context.Background(), tt.args.productCode)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerAPI.GetTicker() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -99,27 +107,36 @@ func TestBitFlyerAPI_GetTicker(t *testing.T) {
 	}
 }
 
+// ドライランは取引所に発注せず、getboardとgettradingcommissionだけを呼ぶ
+func newDryRunStubServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/getboard/":
+			w.Write([]byte(`{"mid_price":10000000,"bids":[{"price":9990000,"size":0.005},{"price":9980000,"size":0.01}],"asks":[{"price":10010000,"size":0.005},{"price":10020000,"size":0.01}]}`))
+		case "/v1/me/gettradingcommission/":
+			w.Write([]byte(`{"commission_rate":0.0015}`))
+		default:
+			t.Errorf("unexpected request in dry run: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
 func TestBitFlyerAPI_SendChildOrder(t *testing.T) {
-	type fields struct {
-		Config config.Config
-		API    *API
-	}
 	type args struct {
 		args SendChildOrderRequest
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		want    SendChildOrderResponse
-		wantErr bool
+		name          string
+		args          args
+		wantErr       bool
+		wantCheckFunc func(DryRunReport) bool
 	}{
 		{
 			name: "success with valid request",
-			fields: fields{
-				Config: testConfig,
-				API:    NewAPI(),
-			},
 			args: args{
 				args: SendChildOrderRequest{
 					ProductCode:    consts.ProductCodeBTCJPY,
@@ -131,15 +148,13 @@ func TestBitFlyerAPI_SendChildOrder(t *testing.T) {
 					TimeInForce:    consts.TimeInForceGTC,
 				},
 			},
-			want:    SendChildOrderResponse{},
 			wantErr: false,
+			wantCheckFunc: func(r DryRunReport) bool {
+				return !r.WouldCross && r.ImmediateSize == 0 && r.Fill == nil && r.BestAsk == 10010000 && len(r.Warnings) == 0
+			},
 		},
 		{
 			name: "success with market order",
-			fields: fields{
-				Config: testConfig,
-				API:    NewAPI(),
-			},
 			args: args{
 				args: SendChildOrderRequest{
 					ProductCode:    consts.ProductCodeBTCJPY,
@@ -150,51 +165,123 @@ func TestBitFlyerAPI_SendChildOrder(t *testing.T) {
 					TimeInForce:    consts.TimeInForceIOC,
 				},
 			},
-			want:    SendChildOrderResponse{},
 			wantErr: false,
+			wantCheckFunc: func(r DryRunReport) bool {
+				// (0.005×9,990,000 + 0.005×9,980,000) / 0.01 = 9,985,000
+				return r.WouldCross && r.Fill != nil && math.Abs(r.Fill.AveragePrice-9985000) < 1e-6 &&
+					math.Abs(r.SlippagePercent-5000.0/9990000*100) < 1e-9 &&
+					math.Abs(r.EstimatedCommission-0.000015) < 1e-12
+			},
 		},
 		{
-			name: "success with different product code",
-			fields: fields{
-				Config: testConfig,
-				API:    NewAPI(),
-			},
+			name: "success with crossing limit order",
 			args: args{
 				args: SendChildOrderRequest{
 					ProductCode:    consts.ProductCodeETHJPY,
 					ChildOrderType: consts.ChildOrderTypeLimit,
 					Side:           consts.SideBuy,
-					Price:          500000,
-					Size:           0.1,
+					Price:          10015000,
+					Size:           0.01,
 					MinuteToExpire: 43200,
 					TimeInForce:    consts.TimeInForceFOK,
 				},
 			},
-			want:    SendChildOrderResponse{},
 			wantErr: false,
+			wantCheckFunc: func(r DryRunReport) bool {
+				return r.WouldCross && r.ImmediateSize == 0.005 && r.Fill != nil && r.Fill.WorstPrice == 10010000 && len(r.Warnings) == 1
+			},
+		},
+		{
+			name: "market order deeper than the board",
+			args: args{
+				args: SendChildOrderRequest{
+					ProductCode:    consts.ProductCodeBTCJPY,
+					ChildOrderType: consts.ChildOrderTypeMarket,
+					Side:           consts.SideBuy,
+					Size:           1,
+					MinuteToExpire: 43200,
+					TimeInForce:    consts.TimeInForceGTC,
+				},
+			},
+			wantErr: false,
+			wantCheckFunc: func(r DryRunReport) bool {
+				return math.Abs(r.ImmediateSize-0.015) < 1e-12 && len(r.Warnings) == 1
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			b := &BitFlyerAPI{
-				Config: tt.fields.Config,
-				API:    tt.fields.API,
-			}
+			b := newStubBitFlyerAPI(newDryRunStubServer(t))
 
 			isDry := true // falseにすると本当に注文APIが実行されるので注意
 
-			got, err := b.SendChildOrder(tt.args.args, isDry)
+			got, err := b.SendChildOrder(context.Background(), tt.args.args, isDry)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerAPI.SendChildOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("BitFlyerAPI.SendChildOrder() = %v, want %v", got, tt.want)
+			if !strings.HasPrefix(got.ChildOrderAcceptanceID, DryRunAcceptanceIDPrefix) || got.DryRun == nil {
+				t.Fatalf("BitFlyerAPI.SendChildOrder() = %+v, want a simulated acceptance id and report", got)
+			}
+			if !tt.wantCheckFunc(*got.DryRun) {
+				t.Errorf("BitFlyerAPI.SendChildOrder() report = %+v", *got.DryRun)
+			}
+		})
+	}
+}
+
+func TestBitFlyerAPI_CancelChildOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    CancelChildOrderRequest
+		wantErr bool
+	}{
+		{
+			name: "success with child order id",
+			args: CancelChildOrderRequest{
+				ProductCode:  consts.ProductCodeBTCJPY,
+				ChildOrderID: "JOR20150707-055555-022222",
+			},
+			wantErr: false,
+		},
+		{
+			name: "success with child order acceptance id",
+			args: CancelChildOrderRequest{
+				ProductCode:            consts.ProductCodeBTCJPY,
+				ChildOrderAcceptanceID: "JRF20150707-033333-099999",
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerAPI{
+				Config: testConfig,
+				API:    NewAPI(),
+			}
+
+			isDry := true // falseにすると本当にキャンセルAPIが実行されるので注意
+
+			if err := b.CancelChildOrder(context.Background(), tt.args, isDry); (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerAPI.CancelChildOrder() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestBitFlyerAPI_CancelAllChildOrders(t *testing.T) {
+	b := &BitFlyerAPI{
+		Config: testConfig,
+		API:    NewAPI(),
+	}
+
+	isDry := true // falseにすると本当にキャンセルAPIが実行されるので注意
+
+	if err := b.CancelAllChildOrders(context.Background(), CancelAllChildOrdersRequest{ProductCode: consts.ProductCodeBTCJPY}, isDry); err != nil {
+		t.Errorf("BitFlyerAPI.CancelAllChildOrders() error = %v", err)
+	}
+}
+
 func TestBitFlyerAPI_privateRequestHeader(t *testing.T) {
 	type fields struct {
 		Config config.Config
@@ -405,3 +492,153 @@ func Test_extractPath(t *testing.T) {
 		})
 	}
 }
+
+func Test_extractPathWithQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		u       string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success without query",
+			u:       "https://api.bitflyer.com/v1/me/getbalance/",
+			want:    "/v1/me/getbalance/",
+			wantErr: false,
+		},
+		{
+			name:    "success with query parameters",
+			u:       "https://api.bitflyer.com/v1/me/getcollateralhistory/?before=100&count=10",
+			want:    "/v1/me/getcollateralhistory/?before=100&count=10",
+			wantErr: false,
+		},
+		{
+			name:    "error with invalid URL",
+			u:       "://invalid-url",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractPathWithQuery(tt.u)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("extractPathWithQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("extractPathWithQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// bitFlyerのスタブに向けたBitFlyerAPIを返す
+func newStubBitFlyerAPI(server *httptest.Server) *BitFlyerAPI {
+	cfg := testConfig
+	cfg.ServerURL.BitFlyer = server.URL
+	return &BitFlyerAPI{
+		Config: cfg,
+		API:    NewAPIWithClient(server.Client(), DefaultRetryPolicy),
+	}
+}
+
+func TestBitFlyerAPI_GetBoardState_stub(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/getboardstate/" || r.URL.Query().Get("product_code") != consts.ProductCodeBTCJPY {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"health":"NORMAL","state":"RUNNING"}`))
+	}))
+	defer server.Close()
+
+	got, err := newStubBitFlyerAPI(server).GetBoardState(context.Background(), consts.ProductCodeBTCJPY)
+	if err != nil {
+		t.Fatalf("BitFlyerAPI.GetBoardState() error = %v", err)
+	}
+	if got.Health != "NORMAL" || got.State != "RUNNING" {
+		t.Errorf("BitFlyerAPI.GetBoardState() = %v", got)
+	}
+}
+
+func TestBitFlyerAPI_SendChildOrder_reconcile(t *testing.T) {
+	noRetrySleep(t)
+
+	args := SendChildOrderRequest{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Side:           consts.SideBuy,
+		Price:          5000000,
+		Size:           0.01,
+		MinuteToExpire: 43200,
+		TimeInForce:    consts.TimeInForceGTC,
+	}
+
+	tests := []struct {
+		name       string
+		sendStatus int
+		orders     []ChildOrderFromBitFlyer
+		want       SendChildOrderResponse
+		wantSends  int32
+		wantErr    bool
+	}{
+		{
+			name:       "reconciled after server error",
+			sendStatus: http.StatusInternalServerError,
+			orders: []ChildOrderFromBitFlyer{
+				{ChildOrderAcceptanceID: "JRF20240101-000000-000001", ProductCode: args.ProductCode, Side: args.Side, ChildOrderType: args.ChildOrderType, Price: args.Price, Size: args.Size, ChildOrderDate: time.Now().UTC().Format(childOrderDateLayout)},
+			},
+			want:      SendChildOrderResponse{ChildOrderAcceptanceID: "JRF20240101-000000-000001", Reconciled: true},
+			wantSends: 1,
+			wantErr:   false,
+		},
+		{
+			name:       "not found after server error",
+			sendStatus: http.StatusInternalServerError,
+			orders:     []ChildOrderFromBitFlyer{},
+			want:       SendChildOrderResponse{},
+			wantSends:  1,
+			wantErr:    true,
+		},
+		{
+			name:       "rejected order is not reconciled",
+			sendStatus: http.StatusBadRequest,
+			orders: []ChildOrderFromBitFlyer{
+				{ChildOrderAcceptanceID: "JRF20240101-000000-000001", ProductCode: args.ProductCode, Side: args.Side, ChildOrderType: args.ChildOrderType, Price: args.Price, Size: args.Size, ChildOrderDate: time.Now().UTC().Format(childOrderDateLayout)},
+			},
+			want:      SendChildOrderResponse{},
+			wantSends: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sends atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/me/sendchildorder/":
+					sends.Add(1)
+					w.WriteHeader(tt.sendStatus)
+					w.Write([]byte(`{"status":-1,"error_message":"error","data":null}`))
+				case "/v1/me/getchildorders/":
+					json.NewEncoder(w).Encode(tt.orders)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			got, err := newStubBitFlyerAPI(server).SendChildOrder(context.Background(), args, false)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BitFlyerAPI.SendChildOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerAPI.SendChildOrder() = %v, want %v", got, tt.want)
+			}
+			if n := sends.Load(); n != tt.wantSends {
+				t.Errorf("sendchildorder called %d times, want %d", n, tt.wantSends)
+			}
+		})
+	}
+}
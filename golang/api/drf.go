@@ -1,15 +1,17 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"bitcoin-app-golang/config"
 )
 
 type IDRFAPI interface {
-	GetBitFlyerTickers() ([]GetTickerFromDRFResponse, error)
-	PostBitFlyerTicker(ticker PostTickerDRFRequest) error
-	DeleteBitFlyerTicker(id int) error
+	GetBitFlyerTickers(ctx context.Context) ([]GetTickerFromDRFResponse, error)
+	PostBitFlyerTicker(ctx context.Context, ticker PostTickerDRFRequest) error
+	DeleteBitFlyerTicker(ctx context.Context, id int) error
+	PostBitFlyerFXStatus(ctx context.Context, status PostFXStatusDRFRequest) error
 }
 
 type DRFAPI struct {
@@ -20,36 +22,45 @@ type DRFAPI struct {
 func NewDRFAPI(cfg config.Config) IDRFAPI {
 	return &DRFAPI{
 		Config: cfg,
-		API:    NewAPI(),
+		API:    NewAPIFromConfig(cfg),
 	}
 }
 
-func (d *DRFAPI) GetBitFlyerTickers() ([]GetTickerFromDRFResponse, error) {
+func (d *DRFAPI) GetBitFlyerTickers(ctx context.Context) ([]GetTickerFromDRFResponse, error) {
 	url, err := DRFServerURL(d.Config.ServerURL.DRFServer).GetTickers()
 	if err != nil {
 		return nil, err
 	}
 	var tickers []GetTickerFromDRFResponse
-	if err := d.API.Do(http.MethodGet, nil, &tickers, url, nil); err != nil {
+	if err := d.API.Do(ctx, http.MethodGet, nil, &tickers, url, nil); err != nil {
 		return nil, err
 	}
 	return tickers, nil
 }
 
-func (d *DRFAPI) PostBitFlyerTicker(ticker PostTickerDRFRequest) error {
+func (d *DRFAPI) PostBitFlyerTicker(ctx context.Context, ticker PostTickerDRFRequest) error {
 	url, err := DRFServerURL(d.Config.ServerURL.DRFServer).PostTicker()
 	if err != nil {
 		return err
 	}
 
-	return d.API.Do(http.MethodPost, ticker, nil, url, nil)
+	return d.API.Do(ctx, http.MethodPost, ticker, nil, url, nil)
 }
 
-func (d *DRFAPI) DeleteBitFlyerTicker(id int) error {
+func (d *DRFAPI) DeleteBitFlyerTicker(ctx context.Context, id int) error {
 	url, err := DRFServerURL(d.Config.ServerURL.DRFServer).DeleteTicker(id)
 	if err != nil {
 		return err
 	}
 
-	return d.API.Do(http.MethodDelete, nil, nil, url, nil)
+	return d.API.Do(ctx, http.MethodDelete, nil, nil, url, nil)
+}
+
+func (d *DRFAPI) PostBitFlyerFXStatus(ctx context.Context, status PostFXStatusDRFRequest) error {
+	url, err := DRFServerURL(d.Config.ServerURL.DRFServer).PostFXStatus()
+	if err != nil {
+		return err
+	}
+
+	return d.API.Do(ctx, http.MethodPost, status, nil, url, nil)
 }
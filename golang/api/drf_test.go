@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -201,7 +202,7 @@ func TestDRFAPI_PostBitFlyerTicker(t *testing.T) {
 				Config: cfg,
 				API:    tt.fields.API,
 			}
-			if err := d.PostBitFlyerTicker(tt.args.ticker); (err != nil) != tt.wantErr {
+			if err := d.PostBitFlyerTicker(context.Background(), tt.args.ticker); (err != nil) != tt.wantErr {
 				t.Errorf("DRFAPI.PostBitFlyerTicker() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -397,7 +398,7 @@ func TestDRFAPI_GetBitFlyerTickers(t *testing.T) {
 				Config: cfg,
 				API:    tt.fields.API,
 			}
-			got, err := d.GetBitFlyerTickers()
+			got, err := d.GetBitFlyerTickers(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("DRFAPI.GetBitFlyerTickers() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -471,9 +472,59 @@ func TestDRFAPI_DeleteBitFlyerTicker(t *testing.T) {
 				Config: cfg,
 				API:    tt.fields.API,
 			}
-			if err := d.DeleteBitFlyerTicker(tt.args.id); (err != nil) != tt.wantErr {
+			if err := d.DeleteBitFlyerTicker(context.Background(), tt.args.id); (err != nil) != tt.wantErr {
 				t.Errorf("DRFAPI.DeleteBitFlyerTicker() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestDRFAPI_PostBitFlyerFXStatus(t *testing.T) {
+	status := PostFXStatusDRFRequest{
+		ProductCode:               consts.ProductCodeFXBTCJPY,
+		FundingRate:               0.0001,
+		NextFundingRateSettledate: "2025-05-18T20:00:00",
+		FXLtp:                     5050000.0,
+		SpotLtp:                   5000000.0,
+		SFDRatio:                  1.0,
+		SFDFeeRate:                0.25,
+		SFDFeeSide:                consts.SideBuy,
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "正常系", status: http.StatusCreated, wantErr: false},
+		{name: "異常系 - サーバーエラー", status: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/fx-statuses/" || r.Method != http.MethodPost {
+					t.Errorf("Expected POST /api/fx-statuses/, got %s %s", r.Method, r.URL.Path)
+				}
+				var received PostFXStatusDRFRequest
+				if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+					t.Errorf("Failed to decode request body: %v", err)
+				}
+				if received != status {
+					t.Errorf("Received fx status = %+v, want %+v", received, status)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			var cfg config.Config
+			cfg.ServerURL.DRFServer = server.URL
+			d := &DRFAPI{
+				Config: cfg,
+				API:    NewAPI(),
+			}
+			if err := d.PostBitFlyerFXStatus(context.Background(), status); (err != nil) != tt.wantErr {
+				t.Errorf("DRFAPI.PostBitFlyerFXStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
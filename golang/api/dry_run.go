@@ -0,0 +1,160 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"bitcoin-app-golang/consts"
+)
+
+// ドライランの受付IDの接頭辞。取引所の受付ID(JRF...)と区別できるようにする
+const DryRunAcceptanceIDPrefix = "DRY"
+
+var dryRunSequence atomic.Int64
+
+// DryRunReport はドライランの発注を現在の板に当てた場合の見積もり。
+// 手数料は取引所のtotal_commissionと同じく数量×手数料率で計算する
+type DryRunReport struct {
+	ProductCode    string  `json:"product_code"`
+	Side           string  `json:"side"`
+	ChildOrderType string  `json:"child_order_type"`
+	Price          float64 `json:"price"`
+	Size           float64 `json:"size"`
+	BestBid        float64 `json:"best_bid"`
+	BestAsk        float64 `json:"best_ask"`
+	MidPrice       float64 `json:"mid_price"`
+
+	// 指値が発注と同時に反対側の板と約定するか。成行は常にtrue
+	WouldCross bool `json:"would_cross"`
+	// 発注と同時に約定する数量。指値は価格までの板、成行は板全体で約定できる分
+	ImmediateSize float64       `json:"immediate_size"`
+	Fill          *FillEstimate `json:"fill,omitempty"`
+	// 約定平均価格が最良気配からどれだけ不利か(%)
+	SlippagePercent float64 `json:"slippage_percent"`
+
+	CommissionRate      float64 `json:"commission_rate"`
+	EstimatedCommission float64 `json:"estimated_commission"`
+
+	// 板が足りない場合やFOKで全量約定しない場合など、実際の発注と結果が変わりうる点
+	Warnings    []string  `json:"warnings,omitempty"`
+	SimulatedAt time.Time `json:"simulated_at"`
+}
+
+func newDryRunAcceptanceID(now time.Time) string {
+	return fmt.Sprintf("%s%s-%06d", DryRunAcceptanceIDPrefix, now.Format("20060102-150405"), dryRunSequence.Add(1)%1000000)
+}
+
+// 取引所に発注せず、現在の板と手数料率から約定を見積もる。板を取得できない場合はエラーを返す
+func (b *BitFlyerAPI) simulateChildOrder(ctx context.Context, args SendChildOrderRequest) (SendChildOrderResponse, error) {
+	board, err := b.GetBoard(ctx, args.ProductCode)
+	if err != nil {
+		return SendChildOrderResponse{}, fmt.Errorf("failed to get board for dry run: %w", err)
+	}
+
+	now := time.Now()
+	report, err := simulateOnBoard(NewOrderBookFromBoard(board), args)
+	if err != nil {
+		return SendChildOrderResponse{}, err
+	}
+	report.MidPrice = board.MidPrice
+	report.SimulatedAt = now
+
+	// 手数料率は見積もりの補足なので、取得できなくても見積もりは返す
+	commission, err := b.GetTradingCommission(ctx, args.ProductCode)
+	if err != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("failed to get commission rate: %v", err))
+	} else {
+		report.CommissionRate = commission.CommissionRate
+		report.EstimatedCommission = report.ImmediateSize * commission.CommissionRate
+	}
+
+	res := SendChildOrderResponse{
+		ChildOrderAcceptanceID: newDryRunAcceptanceID(now),
+		DryRun:                 &report,
+	}
+	log.Printf("Dry run: SendChildOrder is not executed: acceptance_id=%s side=%s type=%s size=%v immediate=%v slippage=%.4f%%",
+		res.ChildOrderAcceptanceID, args.Side, args.ChildOrderType, args.Size, report.ImmediateSize, report.SlippagePercent)
+	return res, nil
+}
+
+func simulateOnBoard(book *OrderBook, args SendChildOrderRequest) (DryRunReport, error) {
+	report := DryRunReport{
+		ProductCode:    args.ProductCode,
+		Side:           args.Side,
+		ChildOrderType: args.ChildOrderType,
+		Price:          args.Price,
+		Size:           args.Size,
+	}
+
+	best, err := book.Best(1)
+	if err != nil {
+		return DryRunReport{}, err
+	}
+	if len(best.Bids) > 0 {
+		report.BestBid = best.Bids[0].Price
+	}
+	if len(best.Asks) > 0 {
+		report.BestAsk = best.Asks[0].Price
+	}
+
+	switch args.ChildOrderType {
+	case consts.ChildOrderTypeMarket:
+		report.WouldCross = true
+		report.ImmediateSize = args.Size
+	case consts.ChildOrderTypeLimit:
+		depth, err := book.DepthTo(args.Side, args.Price)
+		if err != nil {
+			return DryRunReport{}, err
+		}
+		report.ImmediateSize = math.Min(depth, args.Size)
+		report.WouldCross = report.ImmediateSize > 0
+	default:
+		return DryRunReport{}, fmt.Errorf("invalid child order type: %s", args.ChildOrderType)
+	}
+
+	if report.ImmediateSize > 0 {
+		fill, err := book.CostToFill(args.Side, report.ImmediateSize)
+		if err != nil && !errors.Is(err, ErrInsufficientDepth) {
+			return DryRunReport{}, err
+		}
+		report.Fill = &fill
+		report.ImmediateSize = fill.FilledSize
+		report.SlippagePercent = slippagePercent(args.Side, fill.AveragePrice, report.BestBid, report.BestAsk)
+	}
+
+	report.Warnings = dryRunWarnings(args, report)
+	return report, nil
+}
+
+func slippagePercent(side string, averagePrice, bestBid, bestAsk float64) float64 {
+	switch {
+	case side == consts.SideBuy && bestAsk > 0:
+		return (averagePrice - bestAsk) / bestAsk * 100
+	case side == consts.SideSell && bestBid > 0:
+		return (bestBid - averagePrice) / bestBid * 100
+	default:
+		return 0
+	}
+}
+
+func dryRunWarnings(args SendChildOrderRequest, report DryRunReport) []string {
+	var warnings []string
+	unfilled := args.Size - report.ImmediateSize
+	if args.ChildOrderType == consts.ChildOrderTypeMarket && unfilled > 0 {
+		warnings = append(warnings, fmt.Sprintf("insufficient order book depth: only %v of %v would fill", report.ImmediateSize, args.Size))
+	}
+	if args.ChildOrderType == consts.ChildOrderTypeLimit && unfilled > 0 {
+		switch args.TimeInForce {
+		case consts.TimeInForceFOK:
+			warnings = append(warnings, "FOK order would be canceled because it cannot fill completely")
+		case consts.TimeInForceIOC:
+			warnings = append(warnings, fmt.Sprintf("IOC order would cancel the remaining %v", unfilled))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// errors.Isで判定するためのエラー。APIErrorのIsで取引所のステータスコードやHTTPステータスと対応付ける
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrInvalidTimestamp  = errors.New("request timestamp is out of range")
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrRateLimited       = errors.New("rate limited")
+)
+
+// bitFlyerのエラーレスポンスのstatus
+var exchangeStatusErrors = map[int]error{
+	-208: ErrInsufficientFunds,
+	-500: ErrInvalidTimestamp,
+}
+
+var httpStatusErrors = map[int]error{
+	http.StatusUnauthorized:    ErrUnauthorized,
+	http.StatusTooManyRequests: ErrRateLimited,
+}
+
+// APIError はステータスコードが400以上のレスポンスを表す。
+// bitFlyerの場合は{status, error_message, data}の形式のボディをデコードする
+type APIError struct {
+	HTTPStatus   int
+	Status       int
+	ErrorMessage string
+	Data         json.RawMessage
+	Body         string
+}
+
+type errorEnvelope struct {
+	Status       int             `json:"status"`
+	ErrorMessage string          `json:"error_message"`
+	Data         json.RawMessage `json:"data"`
+}
+
+func newAPIError(httpStatus int, body []byte) *APIError {
+	apiErr := &APIError{
+		HTTPStatus: httpStatus,
+		Body:       string(body),
+	}
+
+	var envelope errorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		apiErr.Status = envelope.Status
+		apiErr.ErrorMessage = envelope.ErrorMessage
+		apiErr.Data = envelope.Data
+	}
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	if e.ErrorMessage != "" {
+		return fmt.Sprintf("http status %d, status %d: %s", e.HTTPStatus, e.Status, e.ErrorMessage)
+	}
+	return fmt.Sprintf("http status %d: %s", e.HTTPStatus, e.Body)
+}
+
+func (e *APIError) Is(target error) bool {
+	if err, ok := exchangeStatusErrors[e.Status]; ok && err == target {
+		return true
+	}
+	if err, ok := httpStatusErrors[e.HTTPStatus]; ok && err == target {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPI_Do_error(t *testing.T) {
+	tests := []struct {
+		name           string
+		status         int
+		body           string
+		wantHTTPStatus int
+		wantStatus     int
+		wantIs         error
+		wantErrString  string
+	}{
+		{
+			name:           "insufficient funds",
+			status:         http.StatusBadRequest,
+			body:           `{"status":-208,"error_message":"Insufficient funds","data":null}`,
+			wantHTTPStatus: http.StatusBadRequest,
+			wantStatus:     -208,
+			wantIs:         ErrInsufficientFunds,
+			wantErrString:  "http status 400, status -208: Insufficient funds",
+		},
+		{
+			name:           "invalid timestamp",
+			status:         http.StatusBadRequest,
+			body:           `{"status":-500,"error_message":"Timestamp is out of range","data":null}`,
+			wantHTTPStatus: http.StatusBadRequest,
+			wantStatus:     -500,
+			wantIs:         ErrInvalidTimestamp,
+			wantErrString:  "http status 400, status -500: Timestamp is out of range",
+		},
+		{
+			name:           "rate limited",
+			status:         http.StatusTooManyRequests,
+			body:           `{"status":-1,"error_message":"Too many requests","data":null}`,
+			wantHTTPStatus: http.StatusTooManyRequests,
+			wantStatus:     -1,
+			wantIs:         ErrRateLimited,
+			wantErrString:  "http status 429, status -1: Too many requests",
+		},
+		{
+			name:           "unauthorized",
+			status:         http.StatusUnauthorized,
+			body:           `{"status":-500,"error_message":"Invalid signature","data":null}`,
+			wantHTTPStatus: http.StatusUnauthorized,
+			wantStatus:     -500,
+			wantIs:         ErrUnauthorized,
+			wantErrString:  "http status 401, status -500: Invalid signature",
+		},
+		{
+			name:           "not an envelope",
+			status:         http.StatusInternalServerError,
+			body:           "Internal Server Error",
+			wantHTTPStatus: http.StatusInternalServerError,
+			wantStatus:     0,
+			wantIs:         nil,
+			wantErrString:  "http status 500: Internal Server Error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			err := NewAPIWithRetryPolicy(RetryPolicy{MaxAttempts: 1}).Do(context.Background(), http.MethodGet, nil, &struct{}{}, server.URL, nil)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("API.Do() error = %v, want *APIError", err)
+			}
+			if apiErr.HTTPStatus != tt.wantHTTPStatus || apiErr.Status != tt.wantStatus {
+				t.Errorf("API.Do() HTTPStatus = %v, Status = %v, want %v, %v", apiErr.HTTPStatus, apiErr.Status, tt.wantHTTPStatus, tt.wantStatus)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.wantIs)
+			}
+			if err.Error() != tt.wantErrString {
+				t.Errorf("API.Do() error = %v, want %v", err.Error(), tt.wantErrString)
+			}
+		})
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+	err := &APIError{HTTPStatus: http.StatusBadRequest, Status: -208}
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrInvalidTimestamp) {
+		t.Errorf("APIError.Is() matched an unrelated sentinel")
+	}
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("APIError.Is(ErrInsufficientFunds) = false, want true")
+	}
+}
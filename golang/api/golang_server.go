@@ -1,13 +1,17 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"bitcoin-app-golang/config"
 )
 
 type IGolangServerAPI interface {
-	GetBitFlyerTicker(productCode string) (TickerFromGolangServer, error)
+	GetBitFlyerTicker(ctx context.Context, productCode string) (TickerFromGolangServer, error)
+	GetBitFlyerFXStatus(ctx context.Context) (FXStatusFromGolangServer, error)
+	GetKillSwitch(ctx context.Context) (KillSwitchStateFromGolangServer, error)
+	SetKillSwitch(ctx context.Context, req KillSwitchRequest) (KillSwitchResultFromGolangServer, error)
 }
 
 type GolangServerAPI struct {
@@ -18,20 +22,68 @@ type GolangServerAPI struct {
 func NewGolangServerAPI(cfg config.Config) IGolangServerAPI {
 	return &GolangServerAPI{
 		Config: cfg,
-		API:    NewAPI(),
+		API:    NewAPIFromConfig(cfg),
 	}
 }
 
-func (g *GolangServerAPI) GetBitFlyerTicker(productCode string) (TickerFromGolangServer, error) {
+func (g *GolangServerAPI) GetBitFlyerTicker(ctx context.Context, productCode string) (TickerFromGolangServer, error) {
 	url, err := GolangServerURL(g.Config.ServerURL.GolangServer).GetTicker(productCode)
 	if err != nil {
 		return TickerFromGolangServer{}, err
 	}
 
 	var resModel TickerFromGolangServer
-	if err := g.API.Do(http.MethodGet, nil, &resModel, url, nil); err != nil {
+	if err := g.API.Do(ctx, http.MethodGet, nil, &resModel, url, nil); err != nil {
 		return TickerFromGolangServer{}, err
 	}
 
 	return resModel, nil
 }
+
+func (g *GolangServerAPI) GetBitFlyerFXStatus(ctx context.Context) (FXStatusFromGolangServer, error) {
+	url, err := GolangServerURL(g.Config.ServerURL.GolangServer).GetFXStatus()
+	if err != nil {
+		return FXStatusFromGolangServer{}, err
+	}
+
+	var resModel FXStatusFromGolangServer
+	if err := g.API.Do(ctx, http.MethodGet, nil, &resModel, url, nil); err != nil {
+		return FXStatusFromGolangServer{}, err
+	}
+
+	return resModel, nil
+}
+
+func (g *GolangServerAPI) GetKillSwitch(ctx context.Context) (KillSwitchStateFromGolangServer, error) {
+	url, err := GolangServerURL(g.Config.ServerURL.GolangServer).KillSwitch()
+	if err != nil {
+		return KillSwitchStateFromGolangServer{}, err
+	}
+
+	var resModel KillSwitchStateFromGolangServer
+	if err := g.API.Do(ctx, http.MethodGet, nil, &resModel, url, g.adminHeader()); err != nil {
+		return KillSwitchStateFromGolangServer{}, err
+	}
+
+	return resModel, nil
+}
+
+func (g *GolangServerAPI) SetKillSwitch(ctx context.Context, req KillSwitchRequest) (KillSwitchResultFromGolangServer, error) {
+	url, err := GolangServerURL(g.Config.ServerURL.GolangServer).KillSwitch()
+	if err != nil {
+		return KillSwitchResultFromGolangServer{}, err
+	}
+
+	var resModel KillSwitchResultFromGolangServer
+	if err := g.API.Do(ctx, http.MethodPut, req, &resModel, url, g.adminHeader()); err != nil {
+		return KillSwitchResultFromGolangServer{}, err
+	}
+
+	return resModel, nil
+}
+
+func (g *GolangServerAPI) adminHeader() map[string]any {
+	return map[string]any{
+		"Authorization": "Bearer " + string(g.Config.Admin.Token),
+	}
+}
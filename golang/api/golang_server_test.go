@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -157,7 +158,7 @@ func TestGolangServerAPI_GetBitFlyerTicker(t *testing.T) {
 				Config: cfg,
 				API:    tt.fields.API,
 			}
-			got, err := g.GetBitFlyerTicker(tt.args.productCode)
+			got, err := g.GetBitFlyerTicker(context.Background(), tt.args.productCode)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GolangServerAPI.GetBitFlyerTicker() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -168,3 +169,105 @@ func TestGolangServerAPI_GetBitFlyerTicker(t *testing.T) {
 		})
 	}
 }
+
+func TestGolangServerAPI_GetBitFlyerFXStatus(t *testing.T) {
+	mockStatus := FXStatusFromGolangServer{
+		ProductCode:               consts.ProductCodeFXBTCJPY,
+		FundingRate:               0.0001,
+		NextFundingRateSettledate: "2025-05-18T20:00:00",
+		FXLtp:                     5300000,
+		SpotLtp:                   5000000,
+		SFDRatio:                  6,
+		SFDFeeRate:                0.25,
+		SFDFeeSide:                consts.SideBuy,
+	}
+
+	tests := []struct {
+		name       string
+		serverFunc func() *httptest.Server
+		want       FXStatusFromGolangServer
+		wantErr    bool
+	}{
+		{
+			name: "正常系",
+			serverFunc: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/bitflyer/fx/status/" {
+						t.Errorf("Expected path '/bitflyer/fx/status/', got %s", r.URL.Path)
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(mockStatus)
+				}))
+			},
+			want:    mockStatus,
+			wantErr: false,
+		},
+		{
+			name: "異常系 - サーバーエラー",
+			serverFunc: func() *httptest.Server {
+				return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte("Internal Server Error"))
+				}))
+			},
+			want:    FXStatusFromGolangServer{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.serverFunc()
+			defer server.Close()
+
+			cfg := config.Config{}
+			cfg.ServerURL.GolangServer = server.URL
+
+			g := &GolangServerAPI{
+				Config: cfg,
+				API:    NewAPI(),
+			}
+			got, err := g.GetBitFlyerFXStatus(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GolangServerAPI.GetBitFlyerFXStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GolangServerAPI.GetBitFlyerFXStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGolangServerAPI_SetKillSwitch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/admin/killswitch/" {
+			t.Errorf("request = %s %s, want PUT /admin/killswitch/", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer "+string(testConfig.Admin.Token) {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		var req KillSwitchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(KillSwitchResultFromGolangServer{
+			State: KillSwitchStateFromGolangServer{Engaged: req.Engaged, Reason: req.Reason, Source: req.Source},
+		})
+	}))
+	defer server.Close()
+
+	cfg := testConfig
+	cfg.ServerURL.GolangServer = server.URL
+	g := &GolangServerAPI{Config: cfg, API: NewAPI()}
+
+	got, err := g.SetKillSwitch(context.Background(), KillSwitchRequest{Engaged: true, Reason: "test", Source: "cli"})
+	if err != nil {
+		t.Fatalf("GolangServerAPI.SetKillSwitch() error = %v", err)
+	}
+	want := KillSwitchStateFromGolangServer{Engaged: true, Reason: "test", Source: "cli"}
+	if got.State != want {
+		t.Errorf("GolangServerAPI.SetKillSwitch() = %+v, want %+v", got.State, want)
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"log"
 
@@ -14,11 +15,11 @@ func NewLinebot(cfg config.Config) (*linebot.Client, error) {
 		return nil, errors.New("line channel token or secret is empty")
 	}
 
-	return linebot.New(string(cfg.Line.ChannelSecret), string(cfg.Line.ChannelToken))
+	return linebot.New(string(cfg.Line.ChannelSecret), string(cfg.Line.ChannelToken), linebot.WithHTTPClient(NewHTTPClient(cfg.HTTPClient)))
 }
 
 type ILineAPI interface {
-	PostMessage(message string) error
+	PostMessage(ctx context.Context, message string) error
 }
 
 type LineAPI struct {
@@ -38,7 +39,7 @@ func NewLineAPI(cfg config.Config) (ILineAPI, error) {
 	}, nil
 }
 
-func (l *LineAPI) PostMessage(message string) error {
+func (l *LineAPI) PostMessage(ctx context.Context, message string) error {
 	if l.Config.Line.GroupID == "" {
 		return errors.New("line group ID is empty")
 	}
@@ -51,7 +52,7 @@ func (l *LineAPI) PostMessage(message string) error {
 		return errors.New("line bot client is not initialized")
 	}
 
-	res, err := l.Bot.PushMessage(string(l.Config.Line.GroupID), linebot.NewTextMessage(message)).Do()
+	res, err := l.Bot.PushMessage(string(l.Config.Line.GroupID), linebot.NewTextMessage(message)).WithContext(ctx).Do()
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
 		return err
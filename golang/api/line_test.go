@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"testing"
@@ -257,7 +258,7 @@ func TestLineAPI_PostMessage(t *testing.T) {
 				Config: tt.fields.Config,
 				Bot:    tt.fields.Bot,
 			}
-			if err := l.PostMessage(tt.args.message); (err != nil) != tt.wantErr {
+			if err := l.PostMessage(context.Background(), tt.args.message); (err != nil) != tt.wantErr {
 				t.Errorf("LineAPI.PostMessage() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -89,4 +89,347 @@ type SendChildOrderRequest struct {
 
 type SendChildOrderResponse struct {
 	ChildOrderAcceptanceID string `json:"child_order_acceptance_id"`
+	// 発注のレスポンスを受け取れず、注文一覧との照合で受付IDを特定した場合にtrue
+	Reconciled bool `json:"reconciled,omitempty"`
+	// ドライランの場合だけ、現在の板に当てた見積もりを返す
+	DryRun *DryRunReport `json:"dry_run,omitempty"`
+}
+
+// ChildOrderIDとChildOrderAcceptanceIDはどちらか一方を指定する
+type CancelChildOrderRequest struct {
+	ProductCode            string `json:"product_code"`
+	ChildOrderID           string `json:"child_order_id,omitempty"`
+	ChildOrderAcceptanceID string `json:"child_order_acceptance_id,omitempty"`
+}
+
+type CancelAllChildOrdersRequest struct {
+	ProductCode string `json:"product_code"`
+}
+
+type BoardOrder struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+type BoardFromBitFlyer struct {
+	MidPrice float64      `json:"mid_price"`
+	Bids     []BoardOrder `json:"bids"`
+	Asks     []BoardOrder `json:"asks"`
+}
+
+type ExecutionFromBitFlyer struct {
+	ID                         int64   `json:"id"`
+	Side                       string  `json:"side"`
+	Price                      float64 `json:"price"`
+	Size                       float64 `json:"size"`
+	ExecDate                   string  `json:"exec_date"`
+	BuyChildOrderAcceptanceID  string  `json:"buy_child_order_acceptance_id"`
+	SellChildOrderAcceptanceID string  `json:"sell_child_order_acceptance_id"`
+}
+
+type MarketFromBitFlyer struct {
+	ProductCode string `json:"product_code"`
+	Alias       string `json:"alias,omitempty"`
+	MarketType  string `json:"market_type"`
+}
+
+type HealthFromBitFlyer struct {
+	Status string `json:"status"`
+}
+
+type BoardStateFromBitFlyer struct {
+	Health string                      `json:"health"`
+	State  string                      `json:"state"`
+	Data   *BoardStateDataFromBitFlyer `json:"data,omitempty"`
+}
+
+type BoardStateDataFromBitFlyer struct {
+	SpecialQuotation float64 `json:"special_quotation"`
+}
+
+type FundingRateFromBitFlyer struct {
+	CurrentFundingRate        float64 `json:"current_funding_rate"`
+	NextFundingRateSettledate string  `json:"next_funding_rate_settledate"`
+}
+
+type FXStatusFromGolangServer struct {
+	ProductCode               string  `json:"product_code"`
+	FundingRate               float64 `json:"funding_rate"`
+	NextFundingRateSettledate string  `json:"next_funding_rate_settledate"`
+	FXLtp                     float64 `json:"fx_ltp"`
+	SpotLtp                   float64 `json:"spot_ltp"`
+	SFDRatio                  float64 `json:"sfd_ratio"`
+	SFDFeeRate                float64 `json:"sfd_fee_rate"`
+	SFDFeeSide                string  `json:"sfd_fee_side"`
+}
+
+type PostFXStatusDRFRequest struct {
+	ProductCode               string  `json:"product_code"`
+	FundingRate               float64 `json:"funding_rate"`
+	NextFundingRateSettledate string  `json:"next_funding_rate_settledate"`
+	FXLtp                     float64 `json:"fx_ltp"`
+	SpotLtp                   float64 `json:"spot_ltp"`
+	SFDRatio                  float64 `json:"sfd_ratio"`
+	SFDFeeRate                float64 `json:"sfd_fee_rate"`
+	SFDFeeSide                string  `json:"sfd_fee_side"`
+}
+
+func ConvertFXStatusFromGolang(status FXStatusFromGolangServer) PostFXStatusDRFRequest {
+	return PostFXStatusDRFRequest(status)
+}
+
+type KillSwitchRequest struct {
+	Engaged   bool   `json:"engaged"`
+	Reason    string `json:"reason"`
+	CancelAll bool   `json:"cancel_all"`
+	Source    string `json:"source"`
+}
+
+type KillSwitchStateFromGolangServer struct {
+	Engaged   bool   `json:"engaged"`
+	Reason    string `json:"reason"`
+	Source    string `json:"source"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type KillSwitchResultFromGolangServer struct {
+	State            KillSwitchStateFromGolangServer `json:"state"`
+	CanceledProducts []string                        `json:"canceled_products"`
+	CancelErrors     map[string]string               `json:"cancel_errors"`
+}
+
+type BalanceFromBitFlyer struct {
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Available    float64 `json:"available"`
+}
+
+type CollateralFromBitFlyer struct {
+	Collateral        float64 `json:"collateral"`
+	OpenPositionPnl   float64 `json:"open_position_pnl"`
+	RequireCollateral float64 `json:"require_collateral"`
+	KeepRate          float64 `json:"keep_rate"`
+	MarginCallAmount  float64 `json:"margin_call_amount"`
+	MarginCallDueDate *string `json:"margin_call_due_date"`
+}
+
+type PositionFromBitFlyer struct {
+	ProductCode         string  `json:"product_code"`
+	Side                string  `json:"side"`
+	Price               float64 `json:"price"`
+	Size                float64 `json:"size"`
+	Commission          float64 `json:"commission"`
+	SwapPointAccumulate float64 `json:"swap_point_accumulate"`
+	RequireCollateral   float64 `json:"require_collateral"`
+	OpenDate            string  `json:"open_date"`
+	Leverage            float64 `json:"leverage"`
+	Pnl                 float64 `json:"pnl"`
+	Sfd                 float64 `json:"sfd"`
+}
+
+type GetMyExecutionsRequest struct {
+	ProductCode            string
+	ChildOrderID           string
+	ChildOrderAcceptanceID string
+	Pagination
+}
+
+// Commissionは約定した通貨(BTC_JPYならBTC)建て
+type MyExecutionFromBitFlyer struct {
+	ID                     int64   `json:"id"`
+	ChildOrderID           string  `json:"child_order_id"`
+	Side                   string  `json:"side"`
+	Price                  float64 `json:"price"`
+	Size                   float64 `json:"size"`
+	Commission             float64 `json:"commission"`
+	ExecDate               string  `json:"exec_date"`
+	ChildOrderAcceptanceID string  `json:"child_order_acceptance_id"`
+}
+
+type TradingCommissionFromBitFlyer struct {
+	CommissionRate float64 `json:"commission_rate"`
+}
+
+type CollateralHistoryFromBitFlyer struct {
+	ID           int64   `json:"id"`
+	CurrencyCode string  `json:"currency_code"`
+	Change       float64 `json:"change"`
+	Amount       float64 `json:"amount"`
+	ReasonCode   string  `json:"reason_code"`
+	Date         string  `json:"date"`
+}
+
+type CoinInFromBitFlyer struct {
+	ID           int64   `json:"id"`
+	OrderID      string  `json:"order_id"`
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Address      string  `json:"address"`
+	TxHash       string  `json:"tx_hash"`
+	Status       string  `json:"status"`
+	EventDate    string  `json:"event_date"`
+}
+
+type CoinOutFromBitFlyer struct {
+	ID            int64   `json:"id"`
+	OrderID       string  `json:"order_id"`
+	CurrencyCode  string  `json:"currency_code"`
+	Amount        float64 `json:"amount"`
+	Address       string  `json:"address"`
+	TxHash        string  `json:"tx_hash"`
+	Fee           float64 `json:"fee"`
+	AdditionalFee float64 `json:"additional_fee"`
+	Status        string  `json:"status"`
+	EventDate     string  `json:"event_date"`
+}
+
+type DepositFromBitFlyer struct {
+	ID           int64   `json:"id"`
+	OrderID      string  `json:"order_id"`
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Status       string  `json:"status"`
+	EventDate    string  `json:"event_date"`
+}
+
+type WithdrawalFromBitFlyer struct {
+	ID           int64   `json:"id"`
+	OrderID      string  `json:"order_id"`
+	CurrencyCode string  `json:"currency_code"`
+	Amount       float64 `json:"amount"`
+	Status       string  `json:"status"`
+	EventDate    string  `json:"event_date"`
+}
+
+type GetChildOrdersRequest struct {
+	ProductCode            string
+	ChildOrderState        string
+	ChildOrderID           string
+	ChildOrderAcceptanceID string
+	ParentOrderID          string
+	Pagination
+}
+
+type ChildOrderFromBitFlyer struct {
+	ID                     int64   `json:"id"`
+	ChildOrderID           string  `json:"child_order_id"`
+	ProductCode            string  `json:"product_code"`
+	Side                   string  `json:"side"`
+	ChildOrderType         string  `json:"child_order_type"`
+	Price                  float64 `json:"price"`
+	AveragePrice           float64 `json:"average_price"`
+	Size                   float64 `json:"size"`
+	ChildOrderState        string  `json:"child_order_state"`
+	ExpireDate             string  `json:"expire_date"`
+	ChildOrderDate         string  `json:"child_order_date"`
+	ChildOrderAcceptanceID string  `json:"child_order_acceptance_id"`
+	OutstandingSize        float64 `json:"outstanding_size"`
+	CancelSize             float64 `json:"cancel_size"`
+	ExecutedSize           float64 `json:"executed_size"`
+	TotalCommission        float64 `json:"total_commission"`
+	TimeInForce            string  `json:"time_in_force"`
+}
+
+type ParentOrderParameter struct {
+	ProductCode   string  `json:"product_code"`
+	ConditionType string  `json:"condition_type"`
+	Side          string  `json:"side"`
+	Size          float64 `json:"size"`
+	Price         float64 `json:"price,omitempty"`
+	TriggerPrice  float64 `json:"trigger_price,omitempty"`
+	Offset        float64 `json:"offset,omitempty"`
+}
+
+type SendParentOrderRequest struct {
+	OrderMethod    string                 `json:"order_method"`
+	MinuteToExpire int                    `json:"minute_to_expire"`
+	TimeInForce    string                 `json:"time_in_force"`
+	Parameters     []ParentOrderParameter `json:"parameters"`
+}
+
+type SendParentOrderResponse struct {
+	ParentOrderAcceptanceID string `json:"parent_order_acceptance_id"`
+}
+
+type GetParentOrdersRequest struct {
+	ProductCode      string
+	ParentOrderState string
+	Pagination
+}
+
+// ParentOrderIDとParentOrderAcceptanceIDはどちらか一方を指定する
+type GetParentOrderRequest struct {
+	ParentOrderID           string
+	ParentOrderAcceptanceID string
+}
+
+type CancelParentOrderRequest struct {
+	ProductCode             string `json:"product_code"`
+	ParentOrderID           string `json:"parent_order_id,omitempty"`
+	ParentOrderAcceptanceID string `json:"parent_order_acceptance_id,omitempty"`
+}
+
+type ParentOrderFromBitFlyer struct {
+	ID                      int64   `json:"id"`
+	ParentOrderID           string  `json:"parent_order_id"`
+	ProductCode             string  `json:"product_code"`
+	Side                    string  `json:"side"`
+	ParentOrderType         string  `json:"parent_order_type"`
+	Price                   float64 `json:"price"`
+	AveragePrice            float64 `json:"average_price"`
+	Size                    float64 `json:"size"`
+	ParentOrderState        string  `json:"parent_order_state"`
+	ExpireDate              string  `json:"expire_date"`
+	ParentOrderDate         string  `json:"parent_order_date"`
+	ParentOrderAcceptanceID string  `json:"parent_order_acceptance_id"`
+	OutstandingSize         float64 `json:"outstanding_size"`
+	CancelSize              float64 `json:"cancel_size"`
+	ExecutedSize            float64 `json:"executed_size"`
+	TotalCommission         float64 `json:"total_commission"`
+}
+
+type ParentOrderDetailFromBitFlyer struct {
+	ID                      int64                  `json:"id"`
+	ParentOrderID           string                 `json:"parent_order_id"`
+	OrderMethod             string                 `json:"order_method"`
+	ExpireDate              string                 `json:"expire_date"`
+	TimeInForce             string                 `json:"time_in_force"`
+	Parameters              []ParentOrderParameter `json:"parameters"`
+	ParentOrderAcceptanceID string                 `json:"parent_order_acceptance_id"`
+}
+
+// Realtime APIのchild_order_eventsで配信される。event_typeによって埋まる項目が異なる
+type ChildOrderEventFromBitFlyer struct {
+	ProductCode            string  `json:"product_code"`
+	ChildOrderID           string  `json:"child_order_id"`
+	ChildOrderAcceptanceID string  `json:"child_order_acceptance_id"`
+	EventDate              string  `json:"event_date"`
+	EventType              string  `json:"event_type"`
+	ChildOrderType         string  `json:"child_order_type"`
+	ExpireDate             string  `json:"expire_date"`
+	Reason                 string  `json:"reason"`
+	ExecID                 int64   `json:"exec_id"`
+	Side                   string  `json:"side"`
+	Price                  float64 `json:"price"`
+	Size                   float64 `json:"size"`
+	Commission             float64 `json:"commission"`
+	SFD                    float64 `json:"sfd"`
+}
+
+// Realtime APIのparent_order_eventsで配信される。event_typeによって埋まる項目が異なる
+type ParentOrderEventFromBitFlyer struct {
+	ProductCode             string  `json:"product_code"`
+	ParentOrderID           string  `json:"parent_order_id"`
+	ParentOrderAcceptanceID string  `json:"parent_order_acceptance_id"`
+	EventDate               string  `json:"event_date"`
+	EventType               string  `json:"event_type"`
+	ParentOrderType         string  `json:"parent_order_type"`
+	Reason                  string  `json:"reason"`
+	ChildOrderType          string  `json:"child_order_type"`
+	ParameterIndex          int     `json:"parameter_index"`
+	ChildOrderAcceptanceID  string  `json:"child_order_acceptance_id"`
+	Side                    string  `json:"side"`
+	Price                   float64 `json:"price"`
+	Size                    float64 `json:"size"`
+	ExpireDate              string  `json:"expire_date"`
 }
@@ -0,0 +1,260 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/consts"
+)
+
+const DefaultOrderBookDriftTolerance = 0.001
+
+var (
+	ErrOrderBookNotSynced = errors.New("order book is not synced")
+	ErrOrderBookDrift     = errors.New("order book drifted from the exchange")
+	ErrInsufficientDepth  = errors.New("insufficient order book depth")
+)
+
+type FillEstimate struct {
+	Side         string  `json:"side"`
+	Size         float64 `json:"size"`
+	FilledSize   float64 `json:"filled_size"`
+	Cost         float64 `json:"cost"`
+	AveragePrice float64 `json:"average_price"`
+	WorstPrice   float64 `json:"worst_price"`
+	Levels       int     `json:"levels"`
+}
+
+// OrderBook はlightning_board_snapshotとlightning_boardの差分から組み立てるローカルの板。
+// 差分適用後に板が交差したりmid_priceが大きくずれた場合は同期が外れたとみなし、次のスナップショットまで差分を無視する。
+type OrderBook struct {
+	// 差分のmid_priceと手元の板から計算したmid_priceの許容乖離率
+	DriftTolerance float64
+
+	mu        sync.RWMutex
+	bids      map[float64]float64
+	asks      map[float64]float64
+	midPrice  float64
+	synced    bool
+	updatedAt time.Time
+}
+
+func NewOrderBook() *OrderBook {
+	return &OrderBook{
+		DriftTolerance: DefaultOrderBookDriftTolerance,
+		bids:           map[float64]float64{},
+		asks:           map[float64]float64{},
+	}
+}
+
+func NewOrderBookFromBoard(board BoardFromBitFlyer) *OrderBook {
+	o := NewOrderBook()
+	o.ApplySnapshot(board)
+	return o
+}
+
+func (o *OrderBook) ApplySnapshot(board BoardFromBitFlyer) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.bids = make(map[float64]float64, len(board.Bids))
+	o.asks = make(map[float64]float64, len(board.Asks))
+	applyLevels(o.bids, board.Bids)
+	applyLevels(o.asks, board.Asks)
+	o.midPrice = board.MidPrice
+	o.synced = true
+	o.updatedAt = time.Now()
+}
+
+// 同期が外れている場合はErrOrderBookNotSynced、適用後にずれを検知した場合はErrOrderBookDriftを返す
+func (o *OrderBook) ApplyDiff(diff BoardFromBitFlyer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.synced {
+		return ErrOrderBookNotSynced
+	}
+
+	applyLevels(o.bids, diff.Bids)
+	applyLevels(o.asks, diff.Asks)
+	if diff.MidPrice > 0 {
+		o.midPrice = diff.MidPrice
+	}
+	o.updatedAt = time.Now()
+
+	if err := o.checkDrift(diff.MidPrice); err != nil {
+		o.synced = false
+		return err
+	}
+	return nil
+}
+
+func (o *OrderBook) checkDrift(midPrice float64) error {
+	bestBid, hasBid := bestPrice(o.bids, true)
+	bestAsk, hasAsk := bestPrice(o.asks, false)
+	if !hasBid || !hasAsk {
+		return nil
+	}
+
+	if bestBid >= bestAsk {
+		return fmt.Errorf("%w: crossed book bid=%v ask=%v", ErrOrderBookDrift, bestBid, bestAsk)
+	}
+
+	if midPrice > 0 {
+		localMid := (bestBid + bestAsk) / 2
+		if math.Abs(localMid-midPrice)/midPrice > o.DriftTolerance {
+			return fmt.Errorf("%w: mid price local=%v exchange=%v", ErrOrderBookDrift, localMid, midPrice)
+		}
+	}
+	return nil
+}
+
+func applyLevels(levels map[float64]float64, orders []BoardOrder) {
+	for _, order := range orders {
+		if order.Size <= 0 {
+			delete(levels, order.Price)
+			continue
+		}
+		levels[order.Price] = order.Size
+	}
+}
+
+func bestPrice(levels map[float64]float64, highest bool) (float64, bool) {
+	best, ok := 0.0, false
+	for price := range levels {
+		if !ok || (highest && price > best) || (!highest && price < best) {
+			best, ok = price, true
+		}
+	}
+	return best, ok
+}
+
+func sortedLevels(levels map[float64]float64, descending bool) []BoardOrder {
+	orders := make([]BoardOrder, 0, len(levels))
+	for price, size := range levels {
+		orders = append(orders, BoardOrder{Price: price, Size: size})
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		if descending {
+			return orders[i].Price > orders[j].Price
+		}
+		return orders[i].Price < orders[j].Price
+	})
+	return orders
+}
+
+func (o *OrderBook) Synced() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.synced
+}
+
+func (o *OrderBook) UpdatedAt() time.Time {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.updatedAt
+}
+
+// 買い板は価格の高い順、売り板は価格の安い順に上位n件を返す。nが0の場合は全件返す
+func (o *OrderBook) Best(n int) (BoardFromBitFlyer, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if !o.synced {
+		return BoardFromBitFlyer{}, ErrOrderBookNotSynced
+	}
+
+	bids := sortedLevels(o.bids, true)
+	asks := sortedLevels(o.asks, false)
+	if n > 0 && len(bids) > n {
+		bids = bids[:n]
+	}
+	if n > 0 && len(asks) > n {
+		asks = asks[:n]
+	}
+
+	return BoardFromBitFlyer{
+		MidPrice: o.midPrice,
+		Bids:     bids,
+		Asks:     asks,
+	}, nil
+}
+
+// sideの注文がpriceまでに約定できる数量の合計。BUYは売り板のprice以下、SELLは買い板のprice以上を数える
+func (o *OrderBook) DepthTo(side string, price float64) (float64, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if !o.synced {
+		return 0, ErrOrderBookNotSynced
+	}
+
+	depth := 0.0
+	switch side {
+	case consts.SideBuy:
+		for p, size := range o.asks {
+			if p <= price {
+				depth += size
+			}
+		}
+	case consts.SideSell:
+		for p, size := range o.bids {
+			if p >= price {
+				depth += size
+			}
+		}
+	default:
+		return 0, fmt.Errorf("invalid side: %s", side)
+	}
+	return depth, nil
+}
+
+// sideの成行注文でsizeを約定させた場合の費用を見積もる。板が足りない場合は約定できた分の見積もりとErrInsufficientDepthを返す
+func (o *OrderBook) CostToFill(side string, size float64) (FillEstimate, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if !o.synced {
+		return FillEstimate{}, ErrOrderBookNotSynced
+	}
+	if size <= 0 {
+		return FillEstimate{}, errors.New("size must be greater than 0")
+	}
+
+	var levels []BoardOrder
+	switch side {
+	case consts.SideBuy:
+		levels = sortedLevels(o.asks, false)
+	case consts.SideSell:
+		levels = sortedLevels(o.bids, true)
+	default:
+		return FillEstimate{}, fmt.Errorf("invalid side: %s", side)
+	}
+
+	estimate := FillEstimate{Side: side, Size: size}
+	remaining := size
+	for _, level := range levels {
+		if remaining <= 0 {
+			break
+		}
+		fill := math.Min(remaining, level.Size)
+		estimate.FilledSize += fill
+		estimate.Cost += fill * level.Price
+		estimate.WorstPrice = level.Price
+		estimate.Levels++
+		remaining -= fill
+	}
+
+	if estimate.FilledSize > 0 {
+		estimate.AveragePrice = estimate.Cost / estimate.FilledSize
+	}
+
+	if remaining > 0 {
+		return estimate, fmt.Errorf("%w: filled %v of %v", ErrInsufficientDepth, estimate.FilledSize, size)
+	}
+	return estimate, nil
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/consts"
+)
+
+func testBoard() BoardFromBitFlyer {
+	return BoardFromBitFlyer{
+		MidPrice: 100.5,
+		Bids: []BoardOrder{
+			{Price: 100, Size: 1},
+			{Price: 99, Size: 2},
+			{Price: 98, Size: 3},
+		},
+		Asks: []BoardOrder{
+			{Price: 101, Size: 1},
+			{Price: 102, Size: 2},
+			{Price: 103, Size: 3},
+		},
+	}
+}
+
+func TestOrderBook_ApplyDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot bool
+		diff     BoardFromBitFlyer
+		wantErr  error
+		wantBest BoardFromBitFlyer
+		synced   bool
+	}{
+		{
+			name:     "add, update and remove levels",
+			snapshot: true,
+			diff: BoardFromBitFlyer{
+				MidPrice: 100.5,
+				Bids:     []BoardOrder{{Price: 99, Size: 5}, {Price: 98, Size: 0}},
+				Asks:     []BoardOrder{{Price: 104, Size: 4}},
+			},
+			wantErr: nil,
+			wantBest: BoardFromBitFlyer{
+				MidPrice: 100.5,
+				Bids:     []BoardOrder{{Price: 100, Size: 1}, {Price: 99, Size: 5}},
+				Asks:     []BoardOrder{{Price: 101, Size: 1}, {Price: 102, Size: 2}, {Price: 103, Size: 3}, {Price: 104, Size: 4}},
+			},
+			synced: true,
+		},
+		{
+			name:     "diff before snapshot",
+			snapshot: false,
+			diff:     BoardFromBitFlyer{MidPrice: 100.5},
+			wantErr:  ErrOrderBookNotSynced,
+			synced:   false,
+		},
+		{
+			name:     "crossed book",
+			snapshot: true,
+			diff: BoardFromBitFlyer{
+				Bids: []BoardOrder{{Price: 102, Size: 1}},
+			},
+			wantErr: ErrOrderBookDrift,
+			synced:  false,
+		},
+		{
+			name:     "mid price drift",
+			snapshot: true,
+			diff: BoardFromBitFlyer{
+				MidPrice: 110,
+			},
+			wantErr: ErrOrderBookDrift,
+			synced:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOrderBook()
+			if tt.snapshot {
+				o.ApplySnapshot(testBoard())
+			}
+
+			if err := o.ApplyDiff(tt.diff); !errors.Is(err, tt.wantErr) {
+				t.Errorf("OrderBook.ApplyDiff() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := o.Synced(); got != tt.synced {
+				t.Errorf("OrderBook.Synced() = %v, want %v", got, tt.synced)
+			}
+			if !tt.synced {
+				return
+			}
+
+			got, err := o.Best(0)
+			if err != nil {
+				t.Fatalf("OrderBook.Best() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantBest) {
+				t.Errorf("OrderBook.Best() = %v, want %v", got, tt.wantBest)
+			}
+		})
+	}
+}
+
+func TestOrderBook_Best(t *testing.T) {
+	o := NewOrderBookFromBoard(testBoard())
+
+	got, err := o.Best(2)
+	if err != nil {
+		t.Fatalf("OrderBook.Best() error = %v", err)
+	}
+	want := BoardFromBitFlyer{
+		MidPrice: 100.5,
+		Bids:     []BoardOrder{{Price: 100, Size: 1}, {Price: 99, Size: 2}},
+		Asks:     []BoardOrder{{Price: 101, Size: 1}, {Price: 102, Size: 2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderBook.Best() = %v, want %v", got, want)
+	}
+
+	if _, err := NewOrderBook().Best(1); !errors.Is(err, ErrOrderBookNotSynced) {
+		t.Errorf("OrderBook.Best() error = %v, want %v", err, ErrOrderBookNotSynced)
+	}
+}
+
+func TestOrderBook_DepthTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		side    string
+		price   float64
+		want    float64
+		wantErr bool
+	}{
+		{name: "buy up to 102", side: consts.SideBuy, price: 102, want: 3, wantErr: false},
+		{name: "buy below best ask", side: consts.SideBuy, price: 100, want: 0, wantErr: false},
+		{name: "sell down to 98", side: consts.SideSell, price: 98, want: 6, wantErr: false},
+		{name: "invalid side", side: "INVALID", price: 100, want: 0, wantErr: true},
+	}
+	o := NewOrderBookFromBoard(testBoard())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := o.DepthTo(tt.side, tt.price)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("OrderBook.DepthTo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("OrderBook.DepthTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrderBook_CostToFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		side    string
+		size    float64
+		want    FillEstimate
+		wantErr error
+	}{
+		{
+			name: "buy within best level",
+			side: consts.SideBuy,
+			size: 0.5,
+			want: FillEstimate{Side: consts.SideBuy, Size: 0.5, FilledSize: 0.5, Cost: 50.5, AveragePrice: 101, WorstPrice: 101, Levels: 1},
+		},
+		{
+			name: "buy across levels",
+			side: consts.SideBuy,
+			size: 2,
+			want: FillEstimate{Side: consts.SideBuy, Size: 2, FilledSize: 2, Cost: 203, AveragePrice: 101.5, WorstPrice: 102, Levels: 2},
+		},
+		{
+			name: "sell across levels",
+			side: consts.SideSell,
+			size: 3,
+			want: FillEstimate{Side: consts.SideSell, Size: 3, FilledSize: 3, Cost: 298, AveragePrice: 298.0 / 3, WorstPrice: 99, Levels: 2},
+		},
+		{
+			name:    "insufficient depth",
+			side:    consts.SideSell,
+			size:    10,
+			want:    FillEstimate{Side: consts.SideSell, Size: 10, FilledSize: 6, Cost: 592, AveragePrice: 592.0 / 6, WorstPrice: 98, Levels: 3},
+			wantErr: ErrInsufficientDepth,
+		},
+	}
+	o := NewOrderBookFromBoard(testBoard())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := o.CostToFill(tt.side, tt.size)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("OrderBook.CostToFill() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OrderBook.CostToFill() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
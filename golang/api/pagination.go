@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"bitcoin-app-golang/consts"
+)
+
+// bitFlyerのcount/before/afterによるページング指定
+type Pagination struct {
+	Count  int
+	Before int64
+	After  int64
+}
+
+func (p Pagination) setQuery(qVal url.Values) {
+	if p.Count > 0 {
+		qVal.Set("count", strconv.Itoa(p.Count))
+	}
+	if p.Before > 0 {
+		qVal.Set("before", strconv.FormatInt(p.Before, 10))
+	}
+	if p.After > 0 {
+		qVal.Set("after", strconv.FormatInt(p.After, 10))
+	}
+}
+
+func (p Pagination) pageSize() int {
+	if p.Count > 0 {
+		return p.Count
+	}
+	return consts.DefaultPaginationCount
+}
+
+// PageIterator はbeforeカーソルを更新しながら新しい順に過去のページを辿る
+type PageIterator[T any] struct {
+	fetch func(Pagination) ([]T, error)
+	idOf  func(T) int64
+	p     Pagination
+	done  bool
+}
+
+func NewPageIterator[T any](p Pagination, fetch func(Pagination) ([]T, error), idOf func(T) int64) *PageIterator[T] {
+	return &PageIterator[T]{
+		fetch: fetch,
+		idOf:  idOf,
+		p:     p,
+	}
+}
+
+func (it *PageIterator[T]) HasNext() bool {
+	return !it.done
+}
+
+// 次のページを取得する。最後のページに到達した後は空のスライスを返す
+func (it *PageIterator[T]) Next() ([]T, error) {
+	if it.done {
+		return []T{}, nil
+	}
+
+	page, err := it.fetch(it.p)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page) < it.p.pageSize() {
+		it.done = true
+	}
+
+	if len(page) == 0 {
+		return page, nil
+	}
+
+	minID := it.idOf(page[0])
+	for _, v := range page[1:] {
+		if id := it.idOf(v); id < minID {
+			minID = id
+		}
+	}
+	it.p.Before = minID
+
+	if it.p.After > 0 && minID <= it.p.After+1 {
+		it.done = true
+	}
+
+	return page, nil
+}
+
+// 次のページを取得する際のbeforeの値
+func (it *PageIterator[T]) Before() int64 {
+	return it.p.Before
+}
+
+func NewExecutionIterator(ctx context.Context, b IBitFlyerAPI, productCode string, p Pagination) *PageIterator[ExecutionFromBitFlyer] {
+	return NewPageIterator(p, func(p Pagination) ([]ExecutionFromBitFlyer, error) {
+		return b.GetExecutions(ctx, productCode, p)
+	}, func(e ExecutionFromBitFlyer) int64 {
+		return e.ID
+	})
+}
+
+func NewChildOrderIterator(ctx context.Context, b IBitFlyerAPI, req GetChildOrdersRequest) *PageIterator[ChildOrderFromBitFlyer] {
+	return NewPageIterator(req.Pagination, func(p Pagination) ([]ChildOrderFromBitFlyer, error) {
+		req.Pagination = p
+		return b.GetChildOrders(ctx, req)
+	}, func(o ChildOrderFromBitFlyer) int64 {
+		return o.ID
+	})
+}
+
+func NewMyExecutionIterator(ctx context.Context, b IBitFlyerAPI, req GetMyExecutionsRequest) *PageIterator[MyExecutionFromBitFlyer] {
+	return NewPageIterator(req.Pagination, func(p Pagination) ([]MyExecutionFromBitFlyer, error) {
+		req.Pagination = p
+		return b.GetMyExecutions(ctx, req)
+	}, func(e MyExecutionFromBitFlyer) int64 {
+		return e.ID
+	})
+}
+
+func NewCoinInIterator(ctx context.Context, b IBitFlyerAPI, p Pagination) *PageIterator[CoinInFromBitFlyer] {
+	return NewPageIterator(p, func(p Pagination) ([]CoinInFromBitFlyer, error) {
+		return b.GetCoinIns(ctx, p)
+	}, func(m CoinInFromBitFlyer) int64 {
+		return m.ID
+	})
+}
+
+func NewCoinOutIterator(ctx context.Context, b IBitFlyerAPI, p Pagination) *PageIterator[CoinOutFromBitFlyer] {
+	return NewPageIterator(p, func(p Pagination) ([]CoinOutFromBitFlyer, error) {
+		return b.GetCoinOuts(ctx, p)
+	}, func(m CoinOutFromBitFlyer) int64 {
+		return m.ID
+	})
+}
+
+func NewDepositIterator(ctx context.Context, b IBitFlyerAPI, p Pagination) *PageIterator[DepositFromBitFlyer] {
+	return NewPageIterator(p, func(p Pagination) ([]DepositFromBitFlyer, error) {
+		return b.GetDeposits(ctx, p)
+	}, func(m DepositFromBitFlyer) int64 {
+		return m.ID
+	})
+}
+
+func NewWithdrawalIterator(ctx context.Context, b IBitFlyerAPI, p Pagination) *PageIterator[WithdrawalFromBitFlyer] {
+	return NewPageIterator(p, func(p Pagination) ([]WithdrawalFromBitFlyer, error) {
+		return b.GetWithdrawals(ctx, p)
+	}, func(m WithdrawalFromBitFlyer) int64 {
+		return m.ID
+	})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPagination_setQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Pagination
+		want url.Values
+	}{
+		{
+			name: "all fields",
+			p:    Pagination{Count: 10, Before: 200, After: 100},
+			want: url.Values{
+				"count":  []string{"10"},
+				"before": []string{"200"},
+				"after":  []string{"100"},
+			},
+		},
+		{
+			name: "zero values are omitted",
+			p:    Pagination{},
+			want: url.Values{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := url.Values{}
+			tt.p.setQuery(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Pagination.setQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageIterator_Next(t *testing.T) {
+	// id 1〜5 のデータを新しい順に返すモック
+	data := []ExecutionFromBitFlyer{{ID: 5}, {ID: 4}, {ID: 3}, {ID: 2}, {ID: 1}}
+	fetch := func(p Pagination) ([]ExecutionFromBitFlyer, error) {
+		page := []ExecutionFromBitFlyer{}
+		for _, e := range data {
+			if p.Before > 0 && e.ID >= p.Before {
+				continue
+			}
+			if p.After > 0 && e.ID <= p.After {
+				continue
+			}
+			if len(page) == p.pageSize() {
+				break
+			}
+			page = append(page, e)
+		}
+		return page, nil
+	}
+	idOf := func(e ExecutionFromBitFlyer) int64 { return e.ID }
+
+	tests := []struct {
+		name      string
+		p         Pagination
+		wantPages [][]ExecutionFromBitFlyer
+	}{
+		{
+			name: "walk all pages",
+			p:    Pagination{Count: 2},
+			wantPages: [][]ExecutionFromBitFlyer{
+				{{ID: 5}, {ID: 4}},
+				{{ID: 3}, {ID: 2}},
+				{{ID: 1}},
+			},
+		},
+		{
+			name: "start from before",
+			p:    Pagination{Count: 2, Before: 4},
+			wantPages: [][]ExecutionFromBitFlyer{
+				{{ID: 3}, {ID: 2}},
+				{{ID: 1}},
+			},
+		},
+		{
+			name: "stop at after",
+			p:    Pagination{Count: 2, After: 2},
+			wantPages: [][]ExecutionFromBitFlyer{
+				{{ID: 5}, {ID: 4}},
+				{{ID: 3}},
+			},
+		},
+		{
+			name: "page size is multiple of count",
+			p:    Pagination{Count: 5},
+			wantPages: [][]ExecutionFromBitFlyer{
+				{{ID: 5}, {ID: 4}, {ID: 3}, {ID: 2}, {ID: 1}},
+				{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := NewPageIterator(tt.p, fetch, idOf)
+			var got [][]ExecutionFromBitFlyer
+			for it.HasNext() {
+				page, err := it.Next()
+				if err != nil {
+					t.Fatalf("PageIterator.Next() error = %v", err)
+				}
+				got = append(got, page)
+			}
+			if !reflect.DeepEqual(got, tt.wantPages) {
+				t.Errorf("PageIterator.Next() pages = %v, want %v", got, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestPageIterator_Next_error(t *testing.T) {
+	it := NewPageIterator(Pagination{}, func(p Pagination) ([]ExecutionFromBitFlyer, error) {
+		return nil, errors.New("api error")
+	}, func(e ExecutionFromBitFlyer) int64 { return e.ID })
+
+	if _, err := it.Next(); err == nil {
+		t.Errorf("PageIterator.Next() error = nil, want error")
+	}
+	if !it.HasNext() {
+		t.Errorf("PageIterator.HasNext() = false, want true after error")
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateLimitClass string
+
+const (
+	RateLimitClassPublic  RateLimitClass = "public"
+	RateLimitClassPrivate RateLimitClass = "private"
+	RateLimitClassOrder   RateLimitClass = "order"
+)
+
+const (
+	rateLimitPeriod = 5 * time.Minute
+	// 待ってもこの時間内に枠が空かない場合は待たずにErrRateLimitedを返す
+	DefaultRateLimitMaxWait = 2 * time.Second
+	// 429でX-RateLimit-Resetがない場合に新しいリクエストを止める時間
+	rateLimitedBackoff = 10 * time.Second
+)
+
+// bitFlyerの制限はIPごとに5分500回、APIキーごとに5分500回、発注系は5分300回
+var rateLimitCapacities = map[RateLimitClass]float64{
+	RateLimitClassPublic:  500,
+	RateLimitClassPrivate: 500,
+	RateLimitClassOrder:   300,
+}
+
+// Private APIはIPの枠も、発注系はさらにAPIキーの枠も消費する
+var rateLimitClassChain = map[RateLimitClass][]RateLimitClass{
+	RateLimitClassPublic:  {RateLimitClassPublic},
+	RateLimitClassPrivate: {RateLimitClassPublic, RateLimitClassPrivate},
+	RateLimitClassOrder:   {RateLimitClassPublic, RateLimitClassPrivate, RateLimitClassOrder},
+}
+
+var rateLimitClasses = []RateLimitClass{RateLimitClassPublic, RateLimitClassPrivate, RateLimitClassOrder}
+
+var orderEndpointPaths = map[string]bool{
+	"/v1/me/sendchildorder":       true,
+	"/v1/me/sendparentorder":      true,
+	"/v1/me/cancelallchildorders": true,
+}
+
+type RateLimitBudget struct {
+	Class         RateLimitClass `json:"class"`
+	Limit         int            `json:"limit"`
+	PeriodSeconds int            `json:"period_seconds"`
+	// 手元のトークンバケットの残り。待ちに入っているリクエストがあると負になる
+	Available float64 `json:"available"`
+	// 直近のX-RateLimit-*ヘッダーの値。まだ受け取っていない場合はnil
+	ServerRemaining *int       `json:"server_remaining"`
+	ServerReset     *time.Time `json:"server_reset"`
+	BlockedUntil    *time.Time `json:"blocked_until"`
+}
+
+type tokenBucket struct {
+	capacity     float64
+	refillPerSec float64
+	tokens       float64
+	last         time.Time
+
+	serverRemaining *int
+	serverReset     time.Time
+	// 429を受けた場合やRemainingが0の場合はResetまで新しいリクエストを送らない
+	blockedUntil time.Time
+}
+
+func newTokenBucket(capacity float64, period time.Duration, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity:     capacity,
+		refillPerSec: capacity / period.Seconds(),
+		tokens:       capacity,
+		last:         now,
+	}
+}
+
+func (t *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(t.last).Seconds(); elapsed > 0 {
+		t.tokens = math.Min(t.capacity, t.tokens+elapsed*t.refillPerSec)
+		t.last = now
+	}
+}
+
+// 1トークン取れるようになるまでの時間
+func (t *tokenBucket) wait(now time.Time) time.Duration {
+	t.refill(now)
+
+	var w time.Duration
+	if t.tokens < 1 {
+		w = time.Duration((1 - t.tokens) / t.refillPerSec * float64(time.Second))
+	}
+	if d := t.blockedUntil.Sub(now); d > w {
+		w = d
+	}
+	return w
+}
+
+// RateLimiter はbitFlyerのエンドポイントの種類ごとにトークンバケットで送信を間引き、
+// レスポンスのX-RateLimit-*ヘッダーで手元の残りを補正する
+type RateLimiter struct {
+	MaxWait time.Duration
+
+	mu      sync.Mutex
+	buckets map[RateLimitClass]*tokenBucket
+	now     func() time.Time
+	sleep   func(context.Context, time.Duration) error
+}
+
+// 同じIPとAPIキーの枠を共有するため、BitFlyerAPIはすべてこのRateLimiterを使う
+var defaultRateLimiter = NewRateLimiter()
+
+func DefaultRateLimiter() *RateLimiter {
+	return defaultRateLimiter
+}
+
+func NewRateLimiter() *RateLimiter {
+	return newRateLimiter(time.Now, sleepContext)
+}
+
+func newRateLimiter(now func() time.Time, sleep func(context.Context, time.Duration) error) *RateLimiter {
+	buckets := make(map[RateLimitClass]*tokenBucket, len(rateLimitCapacities))
+	for class, capacity := range rateLimitCapacities {
+		buckets[class] = newTokenBucket(capacity, rateLimitPeriod, now())
+	}
+	return &RateLimiter{
+		MaxWait: DefaultRateLimitMaxWait,
+		buckets: buckets,
+		now:     now,
+		sleep:   sleep,
+	}
+}
+
+// 枠が空くまでMaxWaitを上限に待つ。それ以上かかる場合は送らずにErrRateLimitedを返す
+func (r *RateLimiter) Acquire(ctx context.Context, class RateLimitClass) error {
+	chain, ok := rateLimitClassChain[class]
+	if !ok {
+		return fmt.Errorf("unknown rate limit class: %s", class)
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	var wait time.Duration
+	for _, c := range chain {
+		if w := r.buckets[c].wait(now); w > wait {
+			wait = w
+		}
+	}
+	if wait > r.MaxWait {
+		r.mu.Unlock()
+		return fmt.Errorf("%w: %s endpoints are available in %s", ErrRateLimited, class, wait.Round(time.Millisecond))
+	}
+	// 待っている間に後続のリクエストが同じ枠を取らないよう、先に消費しておく
+	for _, c := range chain {
+		r.buckets[c].tokens--
+	}
+	r.mu.Unlock()
+
+	if wait > 0 {
+		log.Printf("Rate limit: waiting %s for %s endpoints", wait.Round(time.Millisecond), class)
+		// キャンセルされた場合も消費した枠は戻さない。実際に送ったかどうかに関わらず控えめに数える
+		return r.sleep(ctx, wait)
+	}
+	return nil
+}
+
+func (r *RateLimiter) Observe(class RateLimitClass, header http.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observe(class, header, r.now())
+}
+
+// 429を受けた場合は手元の残りを0にし、Resetまで新しいリクエストを止める
+func (r *RateLimiter) ObserveRateLimited(class RateLimitClass, header http.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.observe(class, header, now)
+
+	b, ok := r.buckets[class]
+	if !ok {
+		return
+	}
+	b.tokens = math.Min(b.tokens, 0)
+	until := now.Add(rateLimitedBackoff)
+	if b.serverReset.After(now) {
+		until = b.serverReset
+	}
+	if until.After(b.blockedUntil) {
+		b.blockedUntil = until
+	}
+	log.Printf("Rate limit: %s endpoints returned 429, blocked until %s", class, b.blockedUntil.Format(time.RFC3339))
+}
+
+func (r *RateLimiter) observe(class RateLimitClass, header http.Header, now time.Time) {
+	b, ok := r.buckets[class]
+	if !ok || header == nil {
+		return
+	}
+
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	b.refill(now)
+	b.serverRemaining = &remaining
+	b.tokens = math.Min(b.tokens, float64(remaining))
+
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		b.serverReset = time.Unix(reset, 0)
+		if remaining <= 0 && b.serverReset.After(b.blockedUntil) {
+			b.blockedUntil = b.serverReset
+		}
+	}
+}
+
+func (r *RateLimiter) Budget() []RateLimitBudget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	budgets := make([]RateLimitBudget, 0, len(rateLimitClasses))
+	for _, class := range rateLimitClasses {
+		b := r.buckets[class]
+		b.refill(now)
+
+		budget := RateLimitBudget{
+			Class:         class,
+			Limit:         int(b.capacity),
+			PeriodSeconds: int(rateLimitPeriod.Seconds()),
+			Available:     math.Floor(b.tokens*100) / 100,
+		}
+		if b.serverRemaining != nil {
+			remaining := *b.serverRemaining
+			budget.ServerRemaining = &remaining
+		}
+		if !b.serverReset.IsZero() {
+			reset := b.serverReset
+			budget.ServerReset = &reset
+		}
+		if b.blockedUntil.After(now) {
+			blockedUntil := b.blockedUntil
+			budget.BlockedUntil = &blockedUntil
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets
+}
+
+func rateLimitClassOf(u string) (RateLimitClass, error) {
+	path, err := extractPath(u)
+	if err != nil {
+		return "", err
+	}
+	if orderEndpointPaths[strings.TrimSuffix(path, "/")] {
+		return RateLimitClassOrder, nil
+	}
+	return RateLimitClassPrivate, nil
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	return nil
+}
+
+func newTestRateLimiter() (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	return newRateLimiter(clock.Now, clock.Sleep), clock
+}
+
+func TestRateLimiter_Acquire(t *testing.T) {
+	r, clock := newTestRateLimiter()
+
+	for i := 0; i < 300; i++ {
+		if err := r.Acquire(context.Background(), RateLimitClassOrder); err != nil {
+			t.Fatalf("RateLimiter.Acquire(context.Background(), ) error = %v at %d", err, i)
+		}
+	}
+	if len(clock.slept) != 0 {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) slept %v, want no wait within the budget", clock.slept)
+	}
+
+	// 発注系の枠は1秒に1回しか回復しないため、MaxWaitの範囲内で待ってから送る
+	if err := r.Acquire(context.Background(), RateLimitClassOrder); err != nil {
+		t.Fatalf("RateLimiter.Acquire(context.Background(), ) error = %v", err)
+	}
+	if len(clock.slept) != 1 || clock.slept[0] != time.Second {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) slept %v, want [1s]", clock.slept)
+	}
+
+	// 発注系の消費はPrivate APIの枠にも数えられる
+	budget := r.Budget()
+	if budget[1].Class != RateLimitClassPrivate || budget[1].Available != 200.66 {
+		t.Errorf("RateLimiter.Budget() private = %+v, want available 200.66", budget[1])
+	}
+
+	r.MaxWait = 0
+	err := r.Acquire(context.Background(), RateLimitClassOrder)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) error = %v, want ErrRateLimited", err)
+	}
+	if err := r.Acquire(context.Background(), RateLimitClassPublic); err != nil {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) public error = %v, want nil", err)
+	}
+}
+
+func TestRateLimiter_Observe(t *testing.T) {
+	r, clock := newTestRateLimiter()
+	reset := clock.now.Add(30 * time.Second)
+
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	r.Observe(RateLimitClassPrivate, header)
+
+	budget := r.Budget()[1]
+	if budget.ServerRemaining == nil || *budget.ServerRemaining != 0 {
+		t.Errorf("RateLimiter.Budget() ServerRemaining = %v, want 0", budget.ServerRemaining)
+	}
+	if budget.BlockedUntil == nil || !budget.BlockedUntil.Equal(reset) {
+		t.Errorf("RateLimiter.Budget() BlockedUntil = %v, want %v", budget.BlockedUntil, reset)
+	}
+
+	if err := r.Acquire(context.Background(), RateLimitClassPrivate); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) error = %v, want ErrRateLimited", err)
+	}
+	if err := r.Acquire(context.Background(), RateLimitClassPublic); err != nil {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) public error = %v, want nil", err)
+	}
+
+	clock.now = reset
+	if err := r.Acquire(context.Background(), RateLimitClassPrivate); err != nil {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) after reset error = %v, want nil", err)
+	}
+}
+
+func TestRateLimiter_ObserveRateLimited(t *testing.T) {
+	r, clock := newTestRateLimiter()
+
+	r.ObserveRateLimited(RateLimitClassOrder, nil)
+
+	budget := r.Budget()[2]
+	if budget.Available != 0 {
+		t.Errorf("RateLimiter.Budget() Available = %v, want 0", budget.Available)
+	}
+	want := clock.now.Add(rateLimitedBackoff)
+	if budget.BlockedUntil == nil || !budget.BlockedUntil.Equal(want) {
+		t.Errorf("RateLimiter.Budget() BlockedUntil = %v, want %v", budget.BlockedUntil, want)
+	}
+	if err := r.Acquire(context.Background(), RateLimitClassOrder); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("RateLimiter.Acquire(context.Background(), ) error = %v, want ErrRateLimited", err)
+	}
+}
+
+func Test_rateLimitClassOf(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want RateLimitClass
+	}{
+		{name: "send child order", url: "https://api.bitflyer.com/v1/me/sendchildorder/", want: RateLimitClassOrder},
+		{name: "send parent order", url: "https://api.bitflyer.com/v1/me/sendparentorder/", want: RateLimitClassOrder},
+		{name: "cancel all child orders", url: "https://api.bitflyer.com/v1/me/cancelallchildorders/", want: RateLimitClassOrder},
+		{name: "cancel child order", url: "https://api.bitflyer.com/v1/me/cancelchildorder/", want: RateLimitClassPrivate},
+		{name: "get balance", url: "https://api.bitflyer.com/v1/me/getbalance/", want: RateLimitClassPrivate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rateLimitClassOf(tt.url)
+			if err != nil {
+				t.Fatalf("rateLimitClassOf() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("rateLimitClassOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPI_DoWithHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	header, err := NewAPIWithRetryPolicy(RetryPolicy{MaxAttempts: 1}).DoWithHeader(context.Background(), http.MethodGet, nil, nil, server.URL, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("API.DoWithHeader() error = %v, want ErrRateLimited", err)
+	}
+	if got := header.Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("API.DoWithHeader() X-RateLimit-Remaining = %q, want %q", got, "0")
+	}
+}
+
+func Test_rateLimitHook(t *testing.T) {
+	noRetrySleep(t)
+
+	tests := []struct {
+		name          string
+		statuses      []int
+		wantAttempts  int32
+		wantErr       error
+		wantAvailable float64
+	}{
+		{
+			// リトライのたびに枠を取る
+			name:          "each retry acquires a token",
+			statuses:      []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantAttempts:  3,
+			wantAvailable: 497,
+		},
+		{
+			// 429を受けた後はResetまで止め、MaxWaitより先ならリトライを送らない
+			name:          "retry honours the block after 429",
+			statuses:      []int{http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts:  1,
+			wantErr:       ErrRateLimited,
+			wantAvailable: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[attempts.Add(1)-1])
+			}))
+			defer server.Close()
+
+			r, _ := newTestRateLimiter()
+			api := NewAPIWithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+			_, err := api.DoWithAttemptHook(context.Background(), http.MethodGet, nil, nil, server.URL, nil, rateLimitHook(r, RateLimitClassPublic))
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("API.DoWithAttemptHook() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("API.DoWithAttemptHook() attempts = %v, want %v", got, tt.wantAttempts)
+			}
+			if got := r.Budget()[0].Available; got != tt.wantAvailable {
+				t.Errorf("RateLimiter.Budget() public available = %v, want %v", got, tt.wantAvailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package realtime
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const methodAuth = "auth"
+
+var ErrAuthFailed = errors.New("realtime authentication failed")
+
+type authParams struct {
+	ApiKey    string `json:"api_key"`
+	Timestamp int64  `json:"timestamp"`
+	Nonce     string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+// https://bf-lightning-api.readme.io/docs/realtime-api-auth
+// signatureはtimestamp+nonceをAPI SecretでHMAC-SHA256したもの
+func authSignature(secret string, timestamp int64, nonce string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(strconv.FormatInt(timestamp, 10) + nonce))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authを送信し、対応するレスポンスを受け取るまで待つ。Private Channelの購読より前に呼ぶ
+func (c *Client) authenticate(conn *websocket.Conn) error {
+	nonce, err := newNonce()
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UnixMilli()
+	id, err := c.sendWithID(conn, methodAuth, authParams{
+		ApiKey:    string(c.ApiKey),
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Signature: authSignature(string(c.ApiSecret), timestamp, nonce),
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		if c.ReadTimeout > 0 {
+			if err := conn.SetReadDeadline(time.Now().Add(c.ReadTimeout)); err != nil {
+				return err
+			}
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		if msg.ID == nil || *msg.ID != id {
+			continue
+		}
+
+		if msg.Error != nil {
+			return fmt.Errorf("%w: code=%d message=%s", ErrAuthFailed, msg.Error.Code, msg.Error.Message)
+		}
+
+		var ok bool
+		if err := json.Unmarshal(msg.Result, &ok); err != nil || !ok {
+			return fmt.Errorf("%w: result=%s", ErrAuthFailed, string(msg.Result))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,147 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+const (
+	testApiKey    = "REALTIME_API_KEY"
+	testApiSecret = "REALTIME_API_SECRET"
+)
+
+// newPrivateStandInServer は認証に成功した接続にだけchild_order_eventsを配信するサーバ
+func newPrivateStandInServer(t *testing.T, events []api.ChildOrderEventFromBitFlyer) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		authenticated := false
+		for {
+			var req rpcRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			params, _ := json.Marshal(req.Params)
+
+			switch req.Method {
+			case methodAuth:
+				var p authParams
+				json.Unmarshal(params, &p)
+				authenticated = p.ApiKey == testApiKey && p.Signature == authSignature(testApiSecret, p.Timestamp, p.Nonce)
+				if authenticated {
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				} else {
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32000, "message": "invalid signature"}})
+				}
+			case methodSubscribe:
+				var p channelParams
+				json.Unmarshal(params, &p)
+				if p.Channel == ChildOrderEventsChannel && !authenticated {
+					conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32000, "message": "not authenticated"}})
+					continue
+				}
+				conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+				conn.WriteJSON(map[string]any{
+					"jsonrpc": "2.0",
+					"method":  methodChannelMessage,
+					"params":  map[string]any{"channel": p.Channel, "message": events},
+				})
+			}
+		}
+	}))
+}
+
+func Test_authSignature(t *testing.T) {
+	// timestampとnonceを連結した文字列"1700000000000nonce"に対するHMAC-SHA256
+	got := authSignature("secret", 1700000000000, "nonce")
+	if want := "860b5b12da8cb83030ad12ff4d720d6cd5928b979474fc80e86785fcaf6f0368"; got != want {
+		t.Errorf("authSignature() = %v, want %v", got, want)
+	}
+}
+
+func TestClient_authenticate(t *testing.T) {
+	server := newPrivateStandInServer(t, nil)
+	defer server.Close()
+	endpoint := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name      string
+		apiSecret config.Credential
+		wantErr   error
+	}{
+		{name: "success", apiSecret: testApiSecret, wantErr: nil},
+		{name: "invalid secret", apiSecret: "WRONG_SECRET", wantErr: ErrAuthFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, _, err := websocket.DefaultDialer.Dial(endpoint, nil)
+			if err != nil {
+				t.Fatalf("dial error: %v", err)
+			}
+			defer conn.Close()
+
+			c := newTestClient(endpoint)
+			c.ApiKey = testApiKey
+			c.ApiSecret = tt.apiSecret
+
+			if err := c.authenticate(conn); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Client.authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_SubscribeChildOrderEvents(t *testing.T) {
+	events := []api.ChildOrderEventFromBitFlyer{
+		{
+			ProductCode:            consts.ProductCodeBTCJPY,
+			ChildOrderAcceptanceID: "JRF20250101-000000-000001",
+			EventType:              consts.OrderEventTypeExecution,
+			Side:                   consts.SideBuy,
+			Price:                  5000000,
+			Size:                   0.01,
+		},
+	}
+	server := newPrivateStandInServer(t, events)
+	defer server.Close()
+
+	cfg := config.Config{BitFlyer: config.BitFlyer{ApiKey: testApiKey, ApiSecret: testApiSecret}}
+	c := NewPrivateClient("ws"+strings.TrimPrefix(server.URL, "http"), cfg)
+	c.ReconnectInterval = 10 * time.Millisecond
+
+	got := make(chan []api.ChildOrderEventFromBitFlyer, 1)
+	if err := c.SubscribeChildOrderEvents(func(e []api.ChildOrderEventFromBitFlyer) { got <- e }); err != nil {
+		t.Fatalf("Client.SubscribeChildOrderEvents() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	select {
+	case g := <-got:
+		if len(g) != 1 || g[0] != events[0] {
+			t.Errorf("Client.SubscribeChildOrderEvents() = %v, want %v", g, events)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for child order events")
+	}
+}
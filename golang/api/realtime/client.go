@@ -0,0 +1,393 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+)
+
+// https://bf-lightning-api.readme.io/docs/realtime-api
+const Endpoint = "wss://ws.lightstream.bitflyer.com/json-rpc"
+
+const (
+	DefaultReconnectInterval    = 1 * time.Second
+	DefaultMaxReconnectInterval = 30 * time.Second
+	DefaultReadTimeout          = 1 * time.Minute
+)
+
+const (
+	methodSubscribe      = "subscribe"
+	methodUnsubscribe    = "unsubscribe"
+	methodChannelMessage = "channelMessage"
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+	ID      int    `json:"id"`
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      *int            `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type channelParams struct {
+	Channel string `json:"channel"`
+}
+
+type channelMessageParams struct {
+	Channel string          `json:"channel"`
+	Message json.RawMessage `json:"message"`
+}
+
+// Client はbitFlyer LightningのRealtime API(JSON-RPC 2.0 over WebSocket)のクライアント。
+// 購読したチャンネルは再接続時に自動で購読し直す。
+// ApiKeyとApiSecretを設定すると接続のたびに認証し、Private Channelを購読できる。
+type Client struct {
+	Endpoint             string
+	Dialer               *websocket.Dialer
+	ReconnectInterval    time.Duration
+	MaxReconnectInterval time.Duration
+	ReadTimeout          time.Duration
+	ApiKey               config.Credential
+	ApiSecret            config.Credential
+
+	mu       sync.Mutex
+	writeMu  sync.Mutex
+	conn     *websocket.Conn
+	handlers map[string]func(json.RawMessage)
+	nextID   int
+}
+
+func NewClient(endpoint string) *Client {
+	return &Client{
+		Endpoint:             endpoint,
+		Dialer:               websocket.DefaultDialer,
+		ReconnectInterval:    DefaultReconnectInterval,
+		MaxReconnectInterval: DefaultMaxReconnectInterval,
+		ReadTimeout:          DefaultReadTimeout,
+		handlers:             map[string]func(json.RawMessage){},
+	}
+}
+
+// Private Channelを購読できるクライアントを作成する
+func NewPrivateClient(endpoint string, cfg config.Config) *Client {
+	c := NewClient(endpoint)
+	c.ApiKey = cfg.BitFlyer.ApiKey
+	c.ApiSecret = cfg.BitFlyer.ApiSecret
+	return c
+}
+
+const (
+	ChildOrderEventsChannel  = "child_order_events"
+	ParentOrderEventsChannel = "parent_order_events"
+)
+
+func TickerChannel(productCode string) string {
+	return "lightning_ticker_" + productCode
+}
+
+func ExecutionsChannel(productCode string) string {
+	return "lightning_executions_" + productCode
+}
+
+func BoardSnapshotChannel(productCode string) string {
+	return "lightning_board_snapshot_" + productCode
+}
+
+func BoardChannel(productCode string) string {
+	return "lightning_board_" + productCode
+}
+
+func (c *Client) SubscribeTicker(productCode string, fn func(api.TickerFromBitFlyer)) error {
+	return c.Subscribe(TickerChannel(productCode), func(raw json.RawMessage) {
+		var ticker api.TickerFromBitFlyer
+		if err := json.Unmarshal(raw, &ticker); err != nil {
+			log.Printf("Error decoding ticker message: %v", err)
+			return
+		}
+		fn(ticker)
+	})
+}
+
+func (c *Client) SubscribeExecutions(productCode string, fn func([]api.ExecutionFromBitFlyer)) error {
+	return c.Subscribe(ExecutionsChannel(productCode), func(raw json.RawMessage) {
+		var executions []api.ExecutionFromBitFlyer
+		if err := json.Unmarshal(raw, &executions); err != nil {
+			log.Printf("Error decoding executions message: %v", err)
+			return
+		}
+		fn(executions)
+	})
+}
+
+func (c *Client) SubscribeBoardSnapshot(productCode string, fn func(api.BoardFromBitFlyer)) error {
+	return c.Subscribe(BoardSnapshotChannel(productCode), boardHandler(fn))
+}
+
+func (c *Client) SubscribeBoard(productCode string, fn func(api.BoardFromBitFlyer)) error {
+	return c.Subscribe(BoardChannel(productCode), boardHandler(fn))
+}
+
+func (c *Client) SubscribeChildOrderEvents(fn func([]api.ChildOrderEventFromBitFlyer)) error {
+	return c.Subscribe(ChildOrderEventsChannel, func(raw json.RawMessage) {
+		var events []api.ChildOrderEventFromBitFlyer
+		if err := json.Unmarshal(raw, &events); err != nil {
+			log.Printf("Error decoding child order events: %v", err)
+			return
+		}
+		fn(events)
+	})
+}
+
+func (c *Client) SubscribeParentOrderEvents(fn func([]api.ParentOrderEventFromBitFlyer)) error {
+	return c.Subscribe(ParentOrderEventsChannel, func(raw json.RawMessage) {
+		var events []api.ParentOrderEventFromBitFlyer
+		if err := json.Unmarshal(raw, &events); err != nil {
+			log.Printf("Error decoding parent order events: %v", err)
+			return
+		}
+		fn(events)
+	})
+}
+
+func boardHandler(fn func(api.BoardFromBitFlyer)) func(json.RawMessage) {
+	return func(raw json.RawMessage) {
+		var board api.BoardFromBitFlyer
+		if err := json.Unmarshal(raw, &board); err != nil {
+			log.Printf("Error decoding board message: %v", err)
+			return
+		}
+		fn(board)
+	}
+}
+
+// Tickers はティッカーをGoのチャンネルで受け取るためのヘルパー。
+// 受信側が詰まっている場合は古いティッカーを捨てる
+func (c *Client) Tickers(productCode string, size int) (<-chan api.TickerFromBitFlyer, error) {
+	ch := make(chan api.TickerFromBitFlyer, size)
+	err := c.SubscribeTicker(productCode, func(ticker api.TickerFromBitFlyer) {
+		select {
+		case ch <- ticker:
+		default:
+			log.Printf("Ticker channel is full, dropping tick %d", ticker.TickID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// 接続中であれば即座に購読し、未接続であれば次の接続時に購読する
+func (c *Client) Subscribe(channel string, fn func(json.RawMessage)) error {
+	if channel == "" {
+		return errors.New("channel is empty")
+	}
+
+	c.mu.Lock()
+	c.handlers[channel] = fn
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return c.send(conn, methodSubscribe, channelParams{Channel: channel})
+}
+
+func (c *Client) Unsubscribe(channel string) error {
+	c.mu.Lock()
+	delete(c.handlers, channel)
+	conn := c.conn
+	c.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	return c.send(conn, methodUnsubscribe, channelParams{Channel: channel})
+}
+
+// 購読を解除してから購読し直す。スナップショットを取り直したい場合に使う
+func (c *Client) Resubscribe(channel string) error {
+	c.mu.Lock()
+	_, ok := c.handlers[channel]
+	conn := c.conn
+	c.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("channel is not subscribed: %s", channel)
+	}
+	if conn == nil {
+		return nil
+	}
+
+	if err := c.send(conn, methodUnsubscribe, channelParams{Channel: channel}); err != nil {
+		return err
+	}
+	return c.send(conn, methodSubscribe, channelParams{Channel: channel})
+}
+
+// ctxがキャンセルされるまで接続を維持し、切断された場合は指数バックオフで再接続する
+func (c *Client) Run(ctx context.Context) error {
+	interval := c.ReconnectInterval
+
+	for {
+		connected, err := c.runOnce(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		log.Printf("Realtime connection closed: %v", err)
+
+		if connected {
+			interval = c.ReconnectInterval
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > c.MaxReconnectInterval {
+			interval = c.MaxReconnectInterval
+		}
+	}
+}
+
+func (c *Client) runOnce(ctx context.Context) (bool, error) {
+	conn, _, err := c.Dialer.DialContext(ctx, c.Endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			if err := conn.Close(); err != nil {
+				log.Println(err)
+			}
+		case <-done:
+		}
+	}()
+
+	defer func() {
+		c.mu.Lock()
+		c.conn = nil
+		c.mu.Unlock()
+		if err := conn.Close(); err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+			log.Println(err)
+		}
+	}()
+
+	if c.ApiKey != "" {
+		// 認証に失敗した場合は接続できなかったものとして扱い、再接続の間隔を広げる
+		if err := c.authenticate(conn); err != nil {
+			return false, err
+		}
+	}
+
+	c.mu.Lock()
+	c.conn = conn
+	channels := make([]string, 0, len(c.handlers))
+	for channel := range c.handlers {
+		channels = append(channels, channel)
+	}
+	c.mu.Unlock()
+
+	for _, channel := range channels {
+		if err := c.send(conn, methodSubscribe, channelParams{Channel: channel}); err != nil {
+			return true, err
+		}
+	}
+
+	return true, c.readLoop(conn)
+}
+
+func (c *Client) readLoop(conn *websocket.Conn) error {
+	for {
+		if c.ReadTimeout > 0 {
+			if err := conn.SetReadDeadline(time.Now().Add(c.ReadTimeout)); err != nil {
+				return err
+			}
+		}
+
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("Error decoding realtime message: %v", err)
+			continue
+		}
+
+		if msg.Error != nil {
+			log.Printf("Realtime API error: code=%d message=%s", msg.Error.Code, msg.Error.Message)
+			continue
+		}
+
+		if msg.Method != methodChannelMessage {
+			continue
+		}
+
+		var params channelMessageParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			log.Printf("Error decoding channel message: %v", err)
+			continue
+		}
+
+		c.mu.Lock()
+		fn, ok := c.handlers[params.Channel]
+		c.mu.Unlock()
+		if ok {
+			fn(params.Message)
+		}
+	}
+}
+
+func (c *Client) send(conn *websocket.Conn, method string, params any) error {
+	_, err := c.sendWithID(conn, method, params)
+	return err
+}
+
+func (c *Client) sendWithID(conn *websocket.Conn, method string, params any) (int, error) {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return id, conn.WriteJSON(rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      id,
+	})
+}
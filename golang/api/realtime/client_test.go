@@ -0,0 +1,227 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+// standInServer はbitFlyerのRealtime APIの代わりに使うローカルのWebSocketサーバ
+type standInServer struct {
+	*httptest.Server
+
+	// 購読されたチャンネルに対して送信するメッセージ
+	messages map[string]any
+	// trueの場合、最初の接続はメッセージ送信後に切断する
+	dropFirstConn bool
+
+	mu          sync.Mutex
+	connCount   int
+	subscribeCh chan string
+}
+
+func newStandInServer(t *testing.T, messages map[string]any, dropFirstConn bool) *standInServer {
+	s := &standInServer{
+		messages:      messages,
+		dropFirstConn: dropFirstConn,
+		subscribeCh:   make(chan string, 100),
+	}
+
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade error: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		s.mu.Lock()
+		s.connCount++
+		connNum := s.connCount
+		s.mu.Unlock()
+
+		for {
+			var req rpcRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+
+			params, _ := json.Marshal(req.Params)
+			var p channelParams
+			json.Unmarshal(params, &p)
+
+			conn.WriteJSON(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": true})
+
+			if req.Method != methodSubscribe {
+				continue
+			}
+			s.subscribeCh <- p.Channel
+
+			if msg, ok := s.messages[p.Channel]; ok {
+				conn.WriteJSON(map[string]any{
+					"jsonrpc": "2.0",
+					"method":  methodChannelMessage,
+					"params":  map[string]any{"channel": p.Channel, "message": msg},
+				})
+			}
+
+			if s.dropFirstConn && connNum == 1 {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func (s *standInServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func newTestClient(endpoint string) *Client {
+	c := NewClient(endpoint)
+	c.ReconnectInterval = 10 * time.Millisecond
+	c.MaxReconnectInterval = 50 * time.Millisecond
+	return c
+}
+
+func TestClient_SubscribeTicker(t *testing.T) {
+	ticker := api.TickerFromBitFlyer{
+		TickID:      100,
+		ProductCode: consts.ProductCodeBTCJPY,
+		State:       consts.BoardStateRunning,
+		BestBid:     5000000,
+		BestAsk:     5001000,
+		Ltp:         5000500,
+	}
+	server := newStandInServer(t, map[string]any{TickerChannel(consts.ProductCodeBTCJPY): ticker}, false)
+	defer server.Close()
+
+	c := newTestClient(server.wsURL())
+	got := make(chan api.TickerFromBitFlyer, 1)
+	if err := c.SubscribeTicker(consts.ProductCodeBTCJPY, func(t api.TickerFromBitFlyer) { got <- t }); err != nil {
+		t.Fatalf("Client.SubscribeTicker() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	select {
+	case g := <-got:
+		if !reflect.DeepEqual(g, ticker) {
+			t.Errorf("Client.SubscribeTicker() = %v, want %v", g, ticker)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for ticker")
+	}
+}
+
+func TestClient_SubscribeExecutionsAndBoard(t *testing.T) {
+	executions := []api.ExecutionFromBitFlyer{
+		{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: "2025-01-01T00:00:00.000"},
+	}
+	board := api.BoardFromBitFlyer{
+		MidPrice: 5000000,
+		Bids:     []api.BoardOrder{{Price: 4999000, Size: 0.1}},
+		Asks:     []api.BoardOrder{{Price: 5001000, Size: 0.2}},
+	}
+	server := newStandInServer(t, map[string]any{
+		ExecutionsChannel(consts.ProductCodeBTCJPY):    executions,
+		BoardSnapshotChannel(consts.ProductCodeBTCJPY): board,
+	}, false)
+	defer server.Close()
+
+	c := newTestClient(server.wsURL())
+	gotExecutions := make(chan []api.ExecutionFromBitFlyer, 1)
+	gotBoard := make(chan api.BoardFromBitFlyer, 1)
+	c.SubscribeExecutions(consts.ProductCodeBTCJPY, func(e []api.ExecutionFromBitFlyer) { gotExecutions <- e })
+	c.SubscribeBoardSnapshot(consts.ProductCodeBTCJPY, func(b api.BoardFromBitFlyer) { gotBoard <- b })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	select {
+	case g := <-gotExecutions:
+		if !reflect.DeepEqual(g, executions) {
+			t.Errorf("Client.SubscribeExecutions() = %v, want %v", g, executions)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for executions")
+	}
+
+	select {
+	case g := <-gotBoard:
+		if !reflect.DeepEqual(g, board) {
+			t.Errorf("Client.SubscribeBoardSnapshot() = %v, want %v", g, board)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for board")
+	}
+}
+
+func TestClient_Run_reconnect(t *testing.T) {
+	channel := TickerChannel(consts.ProductCodeBTCJPY)
+	server := newStandInServer(t, map[string]any{channel: api.TickerFromBitFlyer{TickID: 1}}, true)
+	defer server.Close()
+
+	c := newTestClient(server.wsURL())
+	tickers, err := c.Tickers(consts.ProductCodeBTCJPY, 10)
+	if err != nil {
+		t.Fatalf("Client.Tickers() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+
+	// 切断後に再接続して同じチャンネルを購読し直していること
+	for i := 0; i < 2; i++ {
+		select {
+		case got := <-server.subscribeCh:
+			if got != channel {
+				t.Errorf("subscribed channel = %v, want %v", got, channel)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for subscription %d", i+1)
+		}
+		select {
+		case <-tickers:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for ticker %d", i+1)
+		}
+	}
+}
+
+func TestClient_Run_cancel(t *testing.T) {
+	server := newStandInServer(t, map[string]any{}, false)
+	defer server.Close()
+
+	c := newTestClient(server.wsURL())
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.Run(ctx) }()
+
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("Client.Run() error = %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Client.Run() did not return after cancel")
+	}
+}
@@ -0,0 +1,28 @@
+package realtime
+
+import (
+	"errors"
+	"log"
+
+	"bitcoin-app-golang/api"
+)
+
+// WatchOrderBook はスナップショットと差分のチャンネルを購読してbookを更新し続ける。
+// 差分の適用でずれを検知した場合はスナップショットのチャンネルを購読し直して再同期する。
+func WatchOrderBook(c *Client, productCode string, book *api.OrderBook) error {
+	snapshotChannel := BoardSnapshotChannel(productCode)
+
+	if err := c.SubscribeBoardSnapshot(productCode, book.ApplySnapshot); err != nil {
+		return err
+	}
+
+	return c.SubscribeBoard(productCode, func(diff api.BoardFromBitFlyer) {
+		err := book.ApplyDiff(diff)
+		if errors.Is(err, api.ErrOrderBookDrift) {
+			log.Printf("Resyncing order book %s: %v", productCode, err)
+			if err := c.Resubscribe(snapshotChannel); err != nil {
+				log.Printf("Error resubscribing %s: %v", snapshotChannel, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func waitConnected(t *testing.T, c *Client) {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		connected := c.conn != nil
+		c.mu.Unlock()
+		if connected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("timeout waiting for connection")
+}
+
+func TestWatchOrderBook(t *testing.T) {
+	snapshot := api.BoardFromBitFlyer{
+		MidPrice: 100.5,
+		Bids:     []api.BoardOrder{{Price: 100, Size: 1}},
+		Asks:     []api.BoardOrder{{Price: 101, Size: 1}},
+	}
+	// 買い板が売り板を上回る差分を送り、再同期させる
+	crossedDiff := api.BoardFromBitFlyer{
+		Bids: []api.BoardOrder{{Price: 105, Size: 1}},
+	}
+	server := newStandInServer(t, map[string]any{
+		BoardSnapshotChannel(consts.ProductCodeBTCJPY): snapshot,
+		BoardChannel(consts.ProductCodeBTCJPY):         crossedDiff,
+	}, false)
+	defer server.Close()
+
+	c := newTestClient(server.wsURL())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+	waitConnected(t, c)
+
+	book := api.NewOrderBook()
+	if err := WatchOrderBook(c, consts.ProductCodeBTCJPY, book); err != nil {
+		t.Fatalf("WatchOrderBook() error = %v", err)
+	}
+
+	want := []string{
+		BoardSnapshotChannel(consts.ProductCodeBTCJPY),
+		BoardChannel(consts.ProductCodeBTCJPY),
+		BoardSnapshotChannel(consts.ProductCodeBTCJPY),
+	}
+	for i, w := range want {
+		select {
+		case got := <-server.subscribeCh:
+			if got != w {
+				t.Errorf("subscription %d = %v, want %v", i+1, got, w)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("timeout waiting for subscription %d", i+1)
+		}
+	}
+
+	// 再同期後のスナップショットが適用されるのを待つ
+	deadline := time.Now().Add(3 * time.Second)
+	for !book.Synced() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got, err := book.Best(0)
+	if err != nil {
+		t.Fatalf("OrderBook.Best() error = %v", err)
+	}
+	if len(got.Bids) != 1 || got.Bids[0].Price != 100 {
+		t.Errorf("OrderBook.Best() bids = %v, want resynced snapshot", got.Bids)
+	}
+}
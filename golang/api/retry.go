@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"bitcoin-app-golang/config"
+)
+
+// RetryPolicy はGETリクエストのリトライ設定。発注などGET以外のリクエストはリトライしない
+type RetryPolicy struct {
+	// 1回目を含む試行回数。1以下ならリトライしない
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// テストで待ち時間を差し替えるための変数
+var retrySleep = sleepContext
+
+// ctxがキャンセルされた場合は待たずにctxのエラーを返す
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func NewRetryPolicy(cfg config.Retry) RetryPolicy {
+	p := DefaultRetryPolicy
+	if cfg.MaxAttempts > 0 {
+		p.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BaseDelayMs > 0 {
+		p.BaseDelay = time.Duration(cfg.BaseDelayMs) * time.Millisecond
+	}
+	if cfg.MaxDelayMs > 0 {
+		p.MaxDelay = time.Duration(cfg.MaxDelayMs) * time.Millisecond
+	}
+	return p
+}
+
+func (p RetryPolicy) attempts(method string) int {
+	if method != http.MethodGet || p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// attempt回目が失敗した後の待ち時間。指数的に伸ばし、上限で打ち切った上で後半の半分をランダムにずらす
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half+1)
+}
+
+// 429でサーバがリセット時刻を返している場合はそれまで待つ。MaxDelayより先ならリトライしない
+func (p RetryPolicy) retryAfter(attempt int, header http.Header, now time.Time) (time.Duration, bool) {
+	if header != nil {
+		if sec, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+			d := time.Duration(sec) * time.Second
+			return d, d <= p.MaxDelay
+		}
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			d := max(time.Unix(reset, 0).Sub(now), 0)
+			return d, d <= p.MaxDelay
+		}
+	}
+	return p.delay(attempt), true
+}
+
+// ネットワークエラーと5xx、429はリトライの対象
+func isRetryableError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus >= 500 || apiErr.HTTPStatus == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// 取引所に届いたかどうか分からないエラー。4xxは受け付けられていないことが確定している
+func isAmbiguousError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// RetryError はリトライしても成功しなかった場合に試行回数を添えて返すエラー
+type RetryError struct {
+	Attempts int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+func noRetrySleep(t *testing.T) {
+	t.Helper()
+	retrySleep = func(context.Context, time.Duration) error { return nil }
+	t.Cleanup(func() {
+		retrySleep = sleepContext
+	})
+}
+
+func TestAPI_Do_retry(t *testing.T) {
+	noRetrySleep(t)
+
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		header       http.Header
+		wantAttempts int32
+		wantErr      bool
+		wantRetryErr bool
+	}{
+		{
+			name:         "GET succeeds after server errors",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "GET gives up after max attempts",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+			wantAttempts: 3,
+			wantErr:      true,
+			wantRetryErr: true,
+		},
+		{
+			name:         "GET is not retried on client errors",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusBadRequest},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "GET retries 429 when the reset is soon",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			header:       http.Header{"Retry-After": []string{"1"}},
+			wantAttempts: 2,
+			wantErr:      false,
+		},
+		{
+			name:         "GET does not retry 429 when the reset is far",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			header:       http.Header{"X-RateLimit-Reset": []string{strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)}},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "POST is never retried",
+			method:       http.MethodPost,
+			statuses:     []int{http.StatusInternalServerError, http.StatusOK},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				i := attempts.Add(1) - 1
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(tt.statuses[i])
+				w.Write([]byte(`{}`))
+			}))
+			defer server.Close()
+
+			err := NewAPI().Do(context.Background(), tt.method, nil, &struct{}{}, server.URL, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("API.Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("API.Do() attempts = %v, want %v", got, tt.wantAttempts)
+			}
+
+			var retryErr *RetryError
+			if errors.As(err, &retryErr) != tt.wantRetryErr {
+				t.Errorf("API.Do() error = %v, wantRetryErr %v", err, tt.wantRetryErr)
+			}
+			if tt.wantRetryErr && retryErr.Attempts != int(tt.wantAttempts) {
+				t.Errorf("RetryError.Attempts = %v, want %v", retryErr.Attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Retry
+		want RetryPolicy
+	}{
+		{
+			name: "defaults",
+			cfg:  config.Retry{},
+			want: DefaultRetryPolicy,
+		},
+		{
+			name: "from config",
+			cfg:  config.Retry{MaxAttempts: 5, BaseDelayMs: 100, MaxDelayMs: 1000},
+			want: RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewRetryPolicy(tt.cfg); got != tt.want {
+				t.Errorf("NewRetryPolicy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_delay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{attempt: 3, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+		{attempt: 10, min: 150 * time.Millisecond, max: 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := p.delay(tt.attempt); got < tt.min || got > tt.max {
+				t.Errorf("RetryPolicy.delay(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestFindSentChildOrder(t *testing.T) {
+	sentAt := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	args := SendChildOrderRequest{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Side:           consts.SideBuy,
+		Price:          5000000,
+		Size:           0.01,
+	}
+	tests := []struct {
+		name   string
+		orders []ChildOrderFromBitFlyer
+		want   string
+		wantOk bool
+	}{
+		{
+			name: "found",
+			orders: []ChildOrderFromBitFlyer{
+				{ChildOrderAcceptanceID: "JRF-SELL", Side: consts.SideSell, ChildOrderType: consts.ChildOrderTypeLimit, Price: 5000000, Size: 0.01, ChildOrderDate: "2024-01-01T00:00:11.123"},
+				{ChildOrderAcceptanceID: "JRF-NEW", Side: consts.SideBuy, ChildOrderType: consts.ChildOrderTypeLimit, Price: 5000000, Size: 0.01, ChildOrderDate: "2024-01-01T00:00:10.5"},
+				{ChildOrderAcceptanceID: "JRF-OLD", Side: consts.SideBuy, ChildOrderType: consts.ChildOrderTypeLimit, Price: 5000000, Size: 0.01, ChildOrderDate: "2024-01-01T00:00:00"},
+			},
+			want:   "JRF-NEW",
+			wantOk: true,
+		},
+		{
+			name: "only older identical order",
+			orders: []ChildOrderFromBitFlyer{
+				{ChildOrderAcceptanceID: "JRF-OLD", Side: consts.SideBuy, ChildOrderType: consts.ChildOrderTypeLimit, Price: 5000000, Size: 0.01, ChildOrderDate: "2024-01-01T00:00:00"},
+			},
+			wantOk: false,
+		},
+		{
+			name: "different price",
+			orders: []ChildOrderFromBitFlyer{
+				{ChildOrderAcceptanceID: "JRF-PRICE", Side: consts.SideBuy, ChildOrderType: consts.ChildOrderTypeLimit, Price: 4900000, Size: 0.01, ChildOrderDate: "2024-01-01T00:00:11"},
+			},
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FindSentChildOrder(tt.orders, args, sentAt)
+			if ok != tt.wantOk {
+				t.Fatalf("FindSentChildOrder() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got.ChildOrderAcceptanceID != tt.want {
+				t.Errorf("FindSentChildOrder() = %v, want %v", got.ChildOrderAcceptanceID, tt.want)
+			}
+		})
+	}
+}
@@ -20,10 +20,180 @@ func (b BitFlyerURL) GetTicker(productCode string) (string, error) {
 	return createUrl(string(b), "v1/getticker", qVal)
 }
 
+func (b BitFlyerURL) GetMarkets() (string, error) {
+	return createUrl(string(b), "v1/getmarkets", nil)
+}
+
+func (b BitFlyerURL) GetBoard(productCode string) (string, error) {
+	qVal := url.Values{}
+	if productCode != "" {
+		qVal.Set("product_code", productCode)
+	}
+	return createUrl(string(b), "v1/getboard", qVal)
+}
+
+func (b BitFlyerURL) GetExecutions(productCode string, p Pagination) (string, error) {
+	qVal := url.Values{}
+	if productCode != "" {
+		qVal.Set("product_code", productCode)
+	}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/getexecutions", qVal)
+}
+
+func (b BitFlyerURL) GetHealth(productCode string) (string, error) {
+	qVal := url.Values{}
+	if productCode != "" {
+		qVal.Set("product_code", productCode)
+	}
+	return createUrl(string(b), "v1/gethealth", qVal)
+}
+
+func (b BitFlyerURL) GetBoardState(productCode string) (string, error) {
+	qVal := url.Values{}
+	if productCode != "" {
+		qVal.Set("product_code", productCode)
+	}
+	return createUrl(string(b), "v1/getboardstate", qVal)
+}
+
+func (b BitFlyerURL) GetFundingRate(productCode string) (string, error) {
+	qVal := url.Values{}
+	if productCode != "" {
+		qVal.Set("product_code", productCode)
+	}
+	return createUrl(string(b), "v1/getfundingrate", qVal)
+}
+
 func (b BitFlyerURL) SendChildOrder() (string, error) {
 	return createUrl(string(b), "v1/me/sendchildorder", nil)
 }
 
+func (b BitFlyerURL) CancelChildOrder() (string, error) {
+	return createUrl(string(b), "v1/me/cancelchildorder", nil)
+}
+
+func (b BitFlyerURL) CancelAllChildOrders() (string, error) {
+	return createUrl(string(b), "v1/me/cancelallchildorders", nil)
+}
+
+func (b BitFlyerURL) GetChildOrders(req GetChildOrdersRequest) (string, error) {
+	qVal := url.Values{}
+	if req.ProductCode != "" {
+		qVal.Set("product_code", req.ProductCode)
+	}
+	if req.ChildOrderState != "" {
+		qVal.Set("child_order_state", req.ChildOrderState)
+	}
+	if req.ChildOrderID != "" {
+		qVal.Set("child_order_id", req.ChildOrderID)
+	}
+	if req.ChildOrderAcceptanceID != "" {
+		qVal.Set("child_order_acceptance_id", req.ChildOrderAcceptanceID)
+	}
+	if req.ParentOrderID != "" {
+		qVal.Set("parent_order_id", req.ParentOrderID)
+	}
+	req.Pagination.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getchildorders", qVal)
+}
+
+func (b BitFlyerURL) SendParentOrder() (string, error) {
+	return createUrl(string(b), "v1/me/sendparentorder", nil)
+}
+
+func (b BitFlyerURL) CancelParentOrder() (string, error) {
+	return createUrl(string(b), "v1/me/cancelparentorder", nil)
+}
+
+func (b BitFlyerURL) GetParentOrders(req GetParentOrdersRequest) (string, error) {
+	qVal := url.Values{}
+	if req.ProductCode != "" {
+		qVal.Set("product_code", req.ProductCode)
+	}
+	if req.ParentOrderState != "" {
+		qVal.Set("parent_order_state", req.ParentOrderState)
+	}
+	req.Pagination.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getparentorders", qVal)
+}
+
+func (b BitFlyerURL) GetParentOrder(req GetParentOrderRequest) (string, error) {
+	qVal := url.Values{}
+	if req.ParentOrderID != "" {
+		qVal.Set("parent_order_id", req.ParentOrderID)
+	}
+	if req.ParentOrderAcceptanceID != "" {
+		qVal.Set("parent_order_acceptance_id", req.ParentOrderAcceptanceID)
+	}
+	return createUrl(string(b), "v1/me/getparentorder", qVal)
+}
+
+func (b BitFlyerURL) GetBalance() (string, error) {
+	return createUrl(string(b), "v1/me/getbalance", nil)
+}
+
+func (b BitFlyerURL) GetCollateral() (string, error) {
+	return createUrl(string(b), "v1/me/getcollateral", nil)
+}
+
+func (b BitFlyerURL) GetPositions(productCode string) (string, error) {
+	qVal := url.Values{}
+	qVal.Set("product_code", productCode)
+	return createUrl(string(b), "v1/me/getpositions", qVal)
+}
+
+func (b BitFlyerURL) GetMyExecutions(req GetMyExecutionsRequest) (string, error) {
+	qVal := url.Values{}
+	if req.ProductCode != "" {
+		qVal.Set("product_code", req.ProductCode)
+	}
+	if req.ChildOrderID != "" {
+		qVal.Set("child_order_id", req.ChildOrderID)
+	}
+	if req.ChildOrderAcceptanceID != "" {
+		qVal.Set("child_order_acceptance_id", req.ChildOrderAcceptanceID)
+	}
+	req.Pagination.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getexecutions", qVal)
+}
+
+func (b BitFlyerURL) GetTradingCommission(productCode string) (string, error) {
+	qVal := url.Values{}
+	qVal.Set("product_code", productCode)
+	return createUrl(string(b), "v1/me/gettradingcommission", qVal)
+}
+
+func (b BitFlyerURL) GetCollateralHistory(p Pagination) (string, error) {
+	qVal := url.Values{}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getcollateralhistory", qVal)
+}
+
+func (b BitFlyerURL) GetCoinIns(p Pagination) (string, error) {
+	qVal := url.Values{}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getcoinins", qVal)
+}
+
+func (b BitFlyerURL) GetCoinOuts(p Pagination) (string, error) {
+	qVal := url.Values{}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getcoinouts", qVal)
+}
+
+func (b BitFlyerURL) GetDeposits(p Pagination) (string, error) {
+	qVal := url.Values{}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getdeposits", qVal)
+}
+
+func (b BitFlyerURL) GetWithdrawals(p Pagination) (string, error) {
+	qVal := url.Values{}
+	p.setQuery(qVal)
+	return createUrl(string(b), "v1/me/getwithdrawals", qVal)
+}
+
 func (g GolangServerURL) GetTicker(productCode string) (string, error) {
 	qVal := url.Values{}
 	if productCode != "" {
@@ -32,6 +202,14 @@ func (g GolangServerURL) GetTicker(productCode string) (string, error) {
 	return createUrl(string(g), "/bitflyer/ticker", qVal)
 }
 
+func (g GolangServerURL) GetFXStatus() (string, error) {
+	return createUrl(string(g), "/bitflyer/fx/status", nil)
+}
+
+func (g GolangServerURL) KillSwitch() (string, error) {
+	return createUrl(string(g), "/admin/killswitch", nil)
+}
+
 func (g DRFServerURL) GetTickers() (string, error) {
 	return createUrl(string(g), "/api/tickers", nil)
 }
@@ -40,6 +218,10 @@ func (d DRFServerURL) PostTicker() (string, error) {
 	qVal := url.Values{}
 	return createUrl(string(d), "/api/tickers", qVal)
 }
+func (d DRFServerURL) PostFXStatus() (string, error) {
+	return createUrl(string(d), "/api/fx-statuses", nil)
+}
+
 func (d DRFServerURL) DeleteTicker(id int) (string, error) {
 	if id <= 0 {
 		return "", errors.New("invalid ticker ID")
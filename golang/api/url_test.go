@@ -46,6 +46,130 @@ func TestBitFlyerURL_GetTicker(t *testing.T) {
 	}
 }
 
+func TestBitFlyerURL_GetMarkets(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetMarkets()
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetMarkets() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/getmarkets/"; got != want {
+		t.Errorf("BitFlyerURL.GetMarkets() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetBoard(t *testing.T) {
+	type args struct {
+		productCode string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			args:    args{productCode: consts.ProductCodeBTCJPY},
+			want:    "https://api.bitflyer.com/v1/getboard/?product_code=BTC_JPY",
+			wantErr: false,
+		},
+		{
+			name: "success productCode is empty",
+			args: args{
+				productCode: "",
+			},
+			want:    "https://api.bitflyer.com/v1/getboard/",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BitFlyerURL(BitFlyerBaseURL).GetBoard(tt.args.productCode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerURL.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerURL.GetBoard() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerURL_GetExecutions(t *testing.T) {
+	type args struct {
+		productCode string
+		p           Pagination
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			args:    args{productCode: consts.ProductCodeBTCJPY},
+			want:    "https://api.bitflyer.com/v1/getexecutions/?product_code=BTC_JPY",
+			wantErr: false,
+		},
+		{
+			name: "success with pagination",
+			args: args{
+				productCode: consts.ProductCodeBTCJPY,
+				p:           Pagination{Count: 100, Before: 2000, After: 1000},
+			},
+			want:    "https://api.bitflyer.com/v1/getexecutions/?after=1000&before=2000&count=100&product_code=BTC_JPY",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BitFlyerURL(BitFlyerBaseURL).GetExecutions(tt.args.productCode, tt.args.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerURL.GetExecutions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerURL.GetExecutions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerURL_GetHealth(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetHealth(consts.ProductCodeBTCJPY)
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetHealth() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/gethealth/?product_code=BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetHealth() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetBoardState(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetBoardState(consts.ProductCodeBTCJPY)
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetBoardState() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/getboardstate/?product_code=BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetBoardState() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetFundingRate(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetFundingRate(consts.ProductCodeFXBTCJPY)
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetFundingRate() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/getfundingrate/?product_code=FX_BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetFundingRate() = %v, want %v", got, want)
+	}
+}
+
 func TestBitFlyerURL_SendChildOrder(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -72,6 +196,230 @@ func TestBitFlyerURL_SendChildOrder(t *testing.T) {
 	}
 }
 
+func TestBitFlyerURL_CancelChildOrder(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).CancelChildOrder()
+	if err != nil {
+		t.Errorf("BitFlyerURL.CancelChildOrder() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/cancelchildorder/"; got != want {
+		t.Errorf("BitFlyerURL.CancelChildOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_CancelAllChildOrders(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).CancelAllChildOrders()
+	if err != nil {
+		t.Errorf("BitFlyerURL.CancelAllChildOrders() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/cancelallchildorders/"; got != want {
+		t.Errorf("BitFlyerURL.CancelAllChildOrders() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetChildOrders(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     GetChildOrdersRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "success with product code",
+			req:     GetChildOrdersRequest{ProductCode: consts.ProductCodeBTCJPY},
+			want:    "https://api.bitflyer.com/v1/me/getchildorders/?product_code=BTC_JPY",
+			wantErr: false,
+		},
+		{
+			name: "success with filters",
+			req: GetChildOrdersRequest{
+				ProductCode:            consts.ProductCodeBTCJPY,
+				ChildOrderState:        consts.ChildOrderStateActive,
+				ChildOrderAcceptanceID: "JRF20150707-084547-396699",
+				Pagination:             Pagination{Count: 10, Before: 100},
+			},
+			want:    "https://api.bitflyer.com/v1/me/getchildorders/?before=100&child_order_acceptance_id=JRF20150707-084547-396699&child_order_state=ACTIVE&count=10&product_code=BTC_JPY",
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BitFlyerURL(BitFlyerBaseURL).GetChildOrders(tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerURL.GetChildOrders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerURL.GetChildOrders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerURL_SendParentOrder(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).SendParentOrder()
+	if err != nil {
+		t.Errorf("BitFlyerURL.SendParentOrder() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/sendparentorder/"; got != want {
+		t.Errorf("BitFlyerURL.SendParentOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_CancelParentOrder(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).CancelParentOrder()
+	if err != nil {
+		t.Errorf("BitFlyerURL.CancelParentOrder() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/cancelparentorder/"; got != want {
+		t.Errorf("BitFlyerURL.CancelParentOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetParentOrders(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetParentOrders(GetParentOrdersRequest{
+		ProductCode:      consts.ProductCodeBTCJPY,
+		ParentOrderState: consts.ChildOrderStateActive,
+		Pagination:       Pagination{Count: 10},
+	})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetParentOrders() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getparentorders/?count=10&parent_order_state=ACTIVE&product_code=BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetParentOrders() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetParentOrder(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetParentOrder(GetParentOrderRequest{
+		ParentOrderAcceptanceID: "JRF20150925-060559-396699",
+	})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetParentOrder() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getparentorder/?parent_order_acceptance_id=JRF20150925-060559-396699"; got != want {
+		t.Errorf("BitFlyerURL.GetParentOrder() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetBalance(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetBalance()
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetBalance() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getbalance/"; got != want {
+		t.Errorf("BitFlyerURL.GetBalance() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetCollateral(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetCollateral()
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetCollateral() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getcollateral/"; got != want {
+		t.Errorf("BitFlyerURL.GetCollateral() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetPositions(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetPositions(consts.ProductCodeFXBTCJPY)
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetPositions() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getpositions/?product_code=FX_BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetPositions() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetMyExecutions(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetMyExecutions(GetMyExecutionsRequest{
+		ProductCode: consts.ProductCodeBTCJPY,
+		Pagination:  Pagination{Count: 100, Before: 500},
+	})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetMyExecutions() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getexecutions/?before=500&count=100&product_code=BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetMyExecutions() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetTradingCommission(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetTradingCommission(consts.ProductCodeBTCJPY)
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetTradingCommission() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/gettradingcommission/?product_code=BTC_JPY"; got != want {
+		t.Errorf("BitFlyerURL.GetTradingCommission() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetCollateralHistory(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetCollateralHistory(Pagination{Count: 10, Before: 100})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetCollateralHistory() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getcollateralhistory/?before=100&count=10"; got != want {
+		t.Errorf("BitFlyerURL.GetCollateralHistory() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetCoinIns(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetCoinIns(Pagination{Count: 10, After: 5})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetCoinIns() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getcoinins/?after=5&count=10"; got != want {
+		t.Errorf("BitFlyerURL.GetCoinIns() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetCoinOuts(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetCoinOuts(Pagination{Count: 10, After: 5})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetCoinOuts() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getcoinouts/?after=5&count=10"; got != want {
+		t.Errorf("BitFlyerURL.GetCoinOuts() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetDeposits(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetDeposits(Pagination{Count: 10, After: 5})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetDeposits() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getdeposits/?after=5&count=10"; got != want {
+		t.Errorf("BitFlyerURL.GetDeposits() = %v, want %v", got, want)
+	}
+}
+
+func TestBitFlyerURL_GetWithdrawals(t *testing.T) {
+	got, err := BitFlyerURL(BitFlyerBaseURL).GetWithdrawals(Pagination{Count: 10, After: 5})
+	if err != nil {
+		t.Errorf("BitFlyerURL.GetWithdrawals() error = %v", err)
+		return
+	}
+	if want := "https://api.bitflyer.com/v1/me/getwithdrawals/?after=5&count=10"; got != want {
+		t.Errorf("BitFlyerURL.GetWithdrawals() = %v, want %v", got, want)
+	}
+}
+
 func TestGolangServerURL_GetTicker(t *testing.T) {
 	type args struct {
 		productCode string
@@ -144,6 +492,16 @@ func TestDRFServerURL_PostTicker(t *testing.T) {
 	}
 }
 
+func TestDRFServerURL_PostFXStatus(t *testing.T) {
+	got, err := DRFServerURL("https://localhost:8080").PostFXStatus()
+	if err != nil {
+		t.Fatalf("DRFServerURL.PostFXStatus() error = %v", err)
+	}
+	if want := "https://localhost:8080/api/fx-statuses/"; got != want {
+		t.Errorf("DRFServerURL.PostFXStatus() = %v, want %v", got, want)
+	}
+}
+
 func Test_createUrl(t *testing.T) {
 	type args struct {
 		baseUrl string
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -83,7 +84,7 @@ func deleteTickerIDs(tickers []api.GetTickerFromDRFResponse) []int {
 
 func getTickers(cfg config.Config) ([]api.GetTickerFromDRFResponse, error) {
 	drfAPI := api.NewDRFAPI(cfg)
-	tickers, err := drfAPI.GetBitFlyerTickers()
+	tickers, err := drfAPI.GetBitFlyerTickers(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tickers: %w", err)
 	}
@@ -126,7 +127,7 @@ func postTickerProcess(tickers []api.PostTickerDRFRequest) []ProcessError {
 	postTickers := func(processNum int) {
 		for i, ticker := range tickers {
 			if i%postProcessNum == processNum {
-				if err := drfAPI.PostBitFlyerTicker(ticker); err != nil {
+				if err := drfAPI.PostBitFlyerTicker(context.Background(), ticker); err != nil {
 					fmt.Printf("Process %d: Failed to post ticker: %v\n", processNum, err)
 					errChan <- ProcessError{Err: err, ID: i}
 				}
@@ -166,7 +167,7 @@ func deleteTickerProcess(tickerIDs []int) []ProcessError {
 	deleteTickers := func(processNum int) {
 		for i, tickerID := range tickerIDs {
 			if i%deleteProcessNum == processNum {
-				if err := drfAPI.DeleteBitFlyerTicker(tickerID); err != nil {
+				if err := drfAPI.DeleteBitFlyerTicker(context.Background(), tickerID); err != nil {
 					fmt.Printf("Process %d: Failed to delete ticker with ID %d: %v\n", processNum, tickerID, err)
 					errChan <- ProcessError{Err: err, ID: tickerID}
 				}
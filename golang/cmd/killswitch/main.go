@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/usecase"
+)
+
+const usage = `usage: killswitch [flags] on|off|status
+
+  on      新規の発注を止める
+  off     発注の停止を解除する
+  status  現在の状態を表示する
+
+flags:
+`
+
+// 起動中のサーバーの/admin/killswitchを呼び出して切り替える。
+// -offlineを指定した場合はサーバーを経由せず状態ファイルを直接書き換え、次回の起動から反映する
+func main() {
+	tomlFilePath := flag.String("toml", "toml/local.toml", "toml file path")
+	envFilePath := flag.String("env", "env/.env.local", "env file path")
+	reason := flag.String("reason", "", "reason for engaging the kill switch")
+	cancelAll := flag.Bool("cancel-all", false, "cancel all open orders after engaging the kill switch")
+	offline := flag.Bool("offline", false, "write the state file directly instead of calling the server")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+
+	cfg, err := config.NewConfig(*tomlFilePath, *envFilePath)
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	if *offline {
+		if *cancelAll {
+			log.Fatal("-cancel-all cannot be used with -offline")
+		}
+		runOffline(cfg, command, *reason)
+		return
+	}
+
+	golangServer := api.NewGolangServerAPI(cfg)
+	ctx := context.Background()
+
+	switch command {
+	case "status":
+		state, err := golangServer.GetKillSwitch(ctx)
+		if err != nil {
+			log.Fatalf("Failed to get kill switch: %v", err)
+		}
+		printState(state.Engaged, state.Reason, state.Source, state.UpdatedAt)
+	case "on", "off":
+		res, err := golangServer.SetKillSwitch(ctx, api.KillSwitchRequest{
+			Engaged:   command == "on",
+			Reason:    *reason,
+			CancelAll: *cancelAll,
+			Source:    usecase.KillSwitchSourceCLI,
+		})
+		if err != nil {
+			log.Fatalf("Failed to set kill switch: %v", err)
+		}
+		printState(res.State.Engaged, res.State.Reason, res.State.Source, res.State.UpdatedAt)
+		if *cancelAll {
+			fmt.Printf("canceled: %v\n", res.CanceledProducts)
+			for productCode, cancelErr := range res.CancelErrors {
+				fmt.Printf("failed to cancel %s: %s\n", productCode, cancelErr)
+			}
+			if len(res.CancelErrors) > 0 {
+				os.Exit(1)
+			}
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func runOffline(cfg config.Config, command, reason string) {
+	if cfg.KillSwitch.StateFile == "" {
+		log.Fatal("killSwitch.stateFile is not configured")
+	}
+
+	killSwitch := usecase.NewKillSwitch()
+	if err := killSwitch.Open(cfg.KillSwitch.StateFile); err != nil {
+		log.Fatalf("Failed to open kill switch state: %v", err)
+	}
+
+	var err error
+	state := killSwitch.State()
+	switch command {
+	case "status":
+	case "on", "off":
+		state, err = killSwitch.Set(command == "on", reason, usecase.KillSwitchSourceCLI)
+		if err != nil {
+			log.Fatalf("Failed to set kill switch: %v", err)
+		}
+		fmt.Println("The change takes effect when the server is restarted.")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	printState(state.Engaged, state.Reason, state.Source, state.UpdatedAt.Format(time.RFC3339))
+}
+
+func printState(engaged bool, reason, source, updatedAt string) {
+	if !engaged {
+		fmt.Printf("kill switch: released (by %s at %s)\n", source, updatedAt)
+		return
+	}
+	fmt.Printf("kill switch: ENGAGED (by %s at %s): %s\n", source, updatedAt, reason)
+}
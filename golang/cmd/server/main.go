@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
+	"log"
+	"time"
 
 	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/api/realtime"
 	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/repository"
 	"bitcoin-app-golang/router"
+	"bitcoin-app-golang/usecase"
 )
 
 func main() {
@@ -19,7 +25,17 @@ func main() {
 		panic(err)
 	}
 
-	router := router.NewRouter(cfg)
+	killSwitch := openKillSwitch(cfg)
+	idempotency := openIdempotencyStore(cfg)
+	orders := startOrderStore(cfg, idempotency)
+	startMarketRegistry(cfg)
+	startOrderEventStream(cfg, orders)
+
+	router := router.NewRouter(cfg, usecase.BitFlyerUsecaseOptions{
+		OrderRepository: orders,
+		Idempotency:     idempotency,
+		KillSwitch:      killSwitch,
+	})
 
 	port, err := api.ExtractPort(cfg.ServerURL.GolangServer)
 	if err != nil {
@@ -30,3 +46,80 @@ func main() {
 		panic(err)
 	}
 }
+
+// 前回作動させたキルスイッチは再起動後も作動したままにする
+func openKillSwitch(cfg config.Config) *usecase.KillSwitch {
+	killSwitch := usecase.NewKillSwitch()
+	if err := killSwitch.Open(cfg.KillSwitch.StateFile); err != nil {
+		panic(fmt.Errorf("failed to open kill switch: %w", err))
+	}
+	if state := killSwitch.State(); state.Engaged {
+		log.Printf("Kill switch is engaged (by %s at %s): %s", state.Source, state.UpdatedAt.Format(time.RFC3339), state.Reason)
+	}
+	return killSwitch
+}
+
+// 再起動してもIdempotency-Keyで重複した発注を防げるよう、保存されているキーを読み込む。
+// 結果が分からないキーはstartOrderStoreで起動する突き合わせで解決する
+func openIdempotencyStore(cfg config.Config) *usecase.IdempotencyStore {
+	idempotency := usecase.NewIdempotencyStore()
+	if err := idempotency.Open(cfg.Idempotency.File); err != nil {
+		panic(fmt.Errorf("failed to open idempotency store: %w", err))
+	}
+	return idempotency
+}
+
+// 発注の履歴を読み込み、終了していない注文を定期的に取引所の状態と突き合わせる
+func startOrderStore(cfg config.Config, idempotency *usecase.IdempotencyStore) repository.IOrderRepository {
+	retention := time.Duration(cfg.OrderStore.RetentionDays) * 24 * time.Hour
+	orders, err := repository.NewFileOrderRepository(cfg.OrderStore.File, retention)
+	if err != nil {
+		panic(fmt.Errorf("failed to open order store: %w", err))
+	}
+
+	if cfg.OrderStore.ReconcileIntervalSec > 0 {
+		interval := time.Duration(cfg.OrderStore.ReconcileIntervalSec) * time.Second
+		go usecase.NewOrderReconciler(cfg, orders, idempotency).Run(context.Background(), interval)
+	}
+	return orders
+}
+
+// 起動時にgetmarketsを読み込み、以降は定期的に更新する。取得に失敗した場合はconstsの商品で動作する
+func startMarketRegistry(cfg config.Config) {
+	registry := usecase.DefaultMarketRegistry()
+	bitFlyerAPI := api.NewBitFlyerAPI(cfg)
+
+	if err := registry.Refresh(context.Background(), bitFlyerAPI); err != nil {
+		log.Printf("Failed to load markets, using fallback product codes: %v", err)
+	}
+
+	interval := time.Duration(cfg.Market.RefreshIntervalMin) * time.Minute
+	go registry.Run(context.Background(), bitFlyerAPI, interval)
+}
+
+// Private Channelで注文イベントを受け取り、ログ・LINE通知・注文状態に反映する
+func startOrderEventStream(cfg config.Config, orders repository.IOrderRepository) {
+	if !cfg.Realtime.PrivateChannels {
+		return
+	}
+
+	orderEventUsecase, err := usecase.NewOrderEventUsecase(cfg, orders)
+	if err != nil {
+		panic(fmt.Errorf("failed to create order event usecase: %w", err))
+	}
+
+	client := realtime.NewPrivateClient(realtime.Endpoint, cfg)
+	if err := client.SubscribeChildOrderEvents(orderEventUsecase.HandleChildOrderEvents); err != nil {
+		panic(err)
+	}
+	if err := client.SubscribeParentOrderEvents(orderEventUsecase.HandleParentOrderEvents); err != nil {
+		panic(err)
+	}
+
+	go func() {
+		defer orderEventUsecase.Close()
+		if err := client.Run(context.Background()); err != nil {
+			log.Printf("Order event stream stopped: %v", err)
+		}
+	}()
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -39,43 +40,82 @@ func main() {
 	}()
 
 	interval := time.Duration(cfg.TickerBatch.BatchIntervalSec) * time.Second
+	fxStatusInterval := time.Duration(cfg.TickerBatch.FXStatusIntervalSec) * time.Second
 
+	go runFXStatusBatch(ctx, golangServer, drf, fxStatusInterval)
 	runTickerBatch(ctx, golangServer, drf, interval)
 }
 
+// ctxがキャンセルされると実行中のリクエストも中断され、それらの終了を待ってから返る
 func runTickerBatch(ctx context.Context, golangServer api.IGolangServerAPI, drf api.IDRFAPI, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			wg.Add(1)
 			go func() {
+				defer wg.Done()
 				defer func() {
 					if r := recover(); r != nil {
 						log.Printf("Panic recovered in ticker case: %v", r)
 					}
 				}()
-				getAndPostTicker(golangServer, drf)
+				getAndPostTicker(ctx, golangServer, drf)
 			}()
 		}
 	}
 }
 
-func getAndPostTicker(golangServer api.IGolangServerAPI, drf api.IDRFAPI) {
-	ticker, err := golangServer.GetBitFlyerTicker(DefaultProductCode)
+func getAndPostTicker(ctx context.Context, golangServer api.IGolangServerAPI, drf api.IDRFAPI) {
+	ticker, err := golangServer.GetBitFlyerTicker(ctx, DefaultProductCode)
 	if err != nil {
 		log.Printf("Error fetching ticker: %v", err)
 		return
 	}
 
 	drfTicker := api.ConvertTickerFromGolang(ticker)
-	if err := drf.PostBitFlyerTicker(drfTicker); err != nil {
+	if err := drf.PostBitFlyerTicker(ctx, drfTicker); err != nil {
 		log.Printf("Error posting ticker: %v", err)
 		return
 	}
 
 	log.Print("Ticker posted successfully")
 }
+
+func runFXStatusBatch(ctx context.Context, golangServer api.IGolangServerAPI, drf api.IDRFAPI, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			recordFXStatus(ctx, golangServer, drf)
+		}
+	}
+}
+
+// FX_BTC_JPYのファンディングレートとSFDの乖離率をDRFサーバーに保存する
+func recordFXStatus(ctx context.Context, golangServer api.IGolangServerAPI, drf api.IDRFAPI) {
+	status, err := golangServer.GetBitFlyerFXStatus(ctx)
+	if err != nil {
+		log.Printf("Error fetching fx status: %v", err)
+		return
+	}
+
+	if err := drf.PostBitFlyerFXStatus(ctx, api.ConvertFXStatusFromGolang(status)); err != nil {
+		log.Printf("Error posting fx status: %v", err)
+		return
+	}
+
+	log.Printf("FX status: funding_rate=%v next_settledate=%s fx_ltp=%v spot_ltp=%v sfd_ratio=%.3f%% sfd_fee_rate=%v%% sfd_fee_side=%s",
+		status.FundingRate, status.NextFundingRateSettledate, status.FXLtp, status.SpotLtp, status.SFDRatio, status.SFDFeeRate, status.SFDFeeSide)
+}
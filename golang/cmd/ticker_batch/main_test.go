@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+type mockGolangServerAPI struct {
+	api.IGolangServerAPI
+	fxStatus    api.FXStatusFromGolangServer
+	fxStatusErr error
+}
+
+func (m *mockGolangServerAPI) GetBitFlyerFXStatus(context.Context) (api.FXStatusFromGolangServer, error) {
+	return m.fxStatus, m.fxStatusErr
+}
+
+type mockDRFAPI struct {
+	api.IDRFAPI
+	fxStatuses []api.PostFXStatusDRFRequest
+}
+
+func (m *mockDRFAPI) PostBitFlyerFXStatus(_ context.Context, status api.PostFXStatusDRFRequest) error {
+	m.fxStatuses = append(m.fxStatuses, status)
+	return nil
+}
+
+func Test_recordFXStatus(t *testing.T) {
+	status := api.FXStatusFromGolangServer{
+		ProductCode:               consts.ProductCodeFXBTCJPY,
+		FundingRate:               0.0001,
+		NextFundingRateSettledate: "2025-05-18T20:00:00",
+		FXLtp:                     5050000.0,
+		SpotLtp:                   5000000.0,
+		SFDRatio:                  1.0,
+		SFDFeeRate:                0.25,
+		SFDFeeSide:                consts.SideBuy,
+	}
+
+	tests := []struct {
+		name         string
+		golangServer *mockGolangServerAPI
+		want         []api.PostFXStatusDRFRequest
+	}{
+		{
+			name:         "取得した値を保存する",
+			golangServer: &mockGolangServerAPI{fxStatus: status},
+			want:         []api.PostFXStatusDRFRequest{api.ConvertFXStatusFromGolang(status)},
+		},
+		{
+			name:         "取得に失敗した場合は保存しない",
+			golangServer: &mockGolangServerAPI{fxStatusErr: errors.New("connection refused")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drf := &mockDRFAPI{}
+			recordFXStatus(context.Background(), tt.golangServer, drf)
+
+			if len(drf.fxStatuses) != len(tt.want) {
+				t.Fatalf("PostBitFlyerFXStatus() called with %+v, want %+v", drf.fxStatuses, tt.want)
+			}
+			for i := range tt.want {
+				if drf.fxStatuses[i] != tt.want[i] {
+					t.Errorf("PostBitFlyerFXStatus() status = %+v, want %+v", drf.fxStatuses[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
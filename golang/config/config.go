@@ -2,10 +2,13 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/BurntSushi/toml"
 	"github.com/joho/godotenv"
+
+	"bitcoin-app-golang/consts"
 )
 
 type Credential string
@@ -21,6 +24,8 @@ func (c Credential) GoString() string {
 type ServerURL struct {
 	GolangServer string `toml:"golangServer"`
 	DRFServer    string `toml:"drfServer"`
+	// 空の場合は本番のbitFlyer APIを使う。ローカルのスタブに向けるときに指定する
+	BitFlyer string `toml:"bitFlyer"`
 }
 
 type BitFlyer struct {
@@ -29,7 +34,81 @@ type BitFlyer struct {
 }
 
 type TickerBatch struct {
-	BatchIntervalSec int `toml:"batchIntervalSec"`
+	BatchIntervalSec    int `toml:"batchIntervalSec"`
+	FXStatusIntervalSec int `toml:"fxStatusIntervalSec"`
+}
+
+type Market struct {
+	RefreshIntervalMin int `toml:"refreshIntervalMin"`
+}
+
+type OrderGate struct {
+	MaxHealth string `toml:"maxHealth"`
+}
+
+// child_order_eventsなどのPrivate Channelを購読するかどうか
+type Realtime struct {
+	PrivateChannels bool `toml:"privateChannels"`
+}
+
+// API.DoでGETリクエストをリトライする設定。0の項目はデフォルト値を使う
+type Retry struct {
+	MaxAttempts int `toml:"maxAttempts"`
+	BaseDelayMs int `toml:"baseDelayMs"`
+	MaxDelayMs  int `toml:"maxDelayMs"`
+}
+
+// API.Doで使うHTTPクライアントの設定。0の場合はデフォルト値を使う
+type HTTPClient struct {
+	TimeoutSec int `toml:"timeoutSec"`
+}
+
+// 発注前のリスクチェックの上限。0の項目はチェックしない
+type Risk struct {
+	// 1回の注文の金額(価格×数量)の上限。キーはJPYやBTCなどの決済通貨
+	MaxOrderNotional map[string]float64 `toml:"maxOrderNotional"`
+	// 商品ごとの建玉・保有数量の絶対値の上限。キーはプロダクトコード
+	MaxPosition   map[string]float64 `toml:"maxPosition"`
+	MaxOpenOrders int                `toml:"maxOpenOrders"`
+	// 当日(JST)の実現損失の上限。キーは決済通貨
+	MaxDailyLoss map[string]float64 `toml:"maxDailyLoss"`
+	// 指値が最終取引価格から何%まで離れてよいか
+	PriceCollarPercent float64 `toml:"priceCollarPercent"`
+}
+
+// 商品ごとの発注単位。指定した項目だけusecaseのデフォルト値を上書きする
+type TradingRule struct {
+	MinSize   float64 `toml:"minSize"`
+	SizeStep  float64 `toml:"sizeStep"`
+	PriceTick float64 `toml:"priceTick"`
+}
+
+// キルスイッチの状態を保存するファイル。空の場合はプロセスを再起動すると解除される
+type KillSwitch struct {
+	StateFile string `toml:"stateFile"`
+}
+
+// 発注した子注文の履歴の保存先と、取引所の状態と突き合わせる間隔。
+// Fileが空の場合はメモリ上だけに保持し、ReconcileIntervalSecが0以下の場合は突き合わせない
+type OrderStore struct {
+	File                 string `toml:"file"`
+	ReconcileIntervalSec int    `toml:"reconcileIntervalSec"`
+	// 終了した注文を残す日数。0以下の場合は削除しない
+	RetentionDays int `toml:"retentionDays"`
+	// 連続してこの回数だけ取引所で見つからなかった注文はUNKNOWNにする。0以下の場合はusecaseのデフォルト値を使う
+	MaxLookupMisses int `toml:"maxLookupMisses"`
+}
+
+// 発注のIdempotency-Keyと結果を保持する期間と保存先。
+// WindowMinが0以下の場合はusecaseのデフォルト値を使い、Fileが空の場合はメモリ上だけに保持する
+type Idempotency struct {
+	WindowMin int    `toml:"windowMin"`
+	File      string `toml:"file"`
+}
+
+// /admin以下のエンドポイントの認証に使うトークン。空の場合は/admin以下をすべて拒否する
+type Admin struct {
+	Token Credential
 }
 
 type Line struct {
@@ -42,7 +121,19 @@ type Config struct {
 	ServerURL `toml:"serverURL"`
 	BitFlyer
 	TickerBatch `toml:"tickerBatch"`
+	Market      `toml:"market"`
+	OrderGate   `toml:"orderGate"`
+	Realtime    `toml:"realtime"`
+	Retry       `toml:"retry"`
+	HTTPClient  `toml:"httpClient"`
+	Risk        `toml:"risk"`
+	KillSwitch  `toml:"killSwitch"`
+	OrderStore  `toml:"orderStore"`
+	Idempotency `toml:"idempotency"`
+	Admin
 	Line
+	// キーはプロダクトコード
+	TradingRules map[string]TradingRule `toml:"tradingRules"`
 }
 
 func NewConfig(tomlFilePath, envFilePath string) (Config, error) {
@@ -80,6 +171,8 @@ func (c *Config) setFromEnv(envFilePath string) error {
 	c.Line.ChannelSecret = Credential(os.Getenv("LINE_CHANNEL_SECRET"))
 	c.Line.GroupID = Credential(os.Getenv("LINE_GROUP_ID"))
 
+	c.Admin.Token = Credential(os.Getenv("ADMIN_TOKEN"))
+
 	return nil
 }
 
@@ -108,6 +201,14 @@ func (c *Config) mustCheck() error {
 		return errors.New("ticker batch interval must be greater than 0")
 	}
 
+	if c.TickerBatch.FXStatusIntervalSec <= 0 {
+		return errors.New("fx status interval must be greater than 0")
+	}
+
+	if c.Market.RefreshIntervalMin <= 0 {
+		return errors.New("market refresh interval must be greater than 0")
+	}
+
 	if c.Line.ChannelToken == "" {
 		return errors.New("line channel token is empty")
 	}
@@ -120,5 +221,30 @@ func (c *Config) mustCheck() error {
 		return errors.New("line group id is empty")
 	}
 
+	switch c.OrderGate.MaxHealth {
+	case consts.HealthNormal, consts.HealthBusy, consts.HealthVeryBusy, consts.HealthSuperBusy:
+	case "":
+		return errors.New("order gate max health is empty")
+	default:
+		return fmt.Errorf("invalid order gate max health: %s", c.OrderGate.MaxHealth)
+	}
+
+	for productCode, rule := range c.TradingRules {
+		if rule.MinSize < 0 || rule.SizeStep < 0 || rule.PriceTick < 0 {
+			return fmt.Errorf("trading rule for %s must not be negative", productCode)
+		}
+	}
+
+	if c.Risk.MaxOpenOrders < 0 || c.Risk.PriceCollarPercent < 0 {
+		return errors.New("risk limits must not be negative")
+	}
+	for _, limits := range []map[string]float64{c.Risk.MaxOrderNotional, c.Risk.MaxPosition, c.Risk.MaxDailyLoss} {
+		for key, limit := range limits {
+			if limit < 0 {
+				return fmt.Errorf("risk limit for %s must not be negative", key)
+			}
+		}
+	}
+
 	return nil
 }
@@ -12,6 +12,8 @@ const (
 	TestLineChannelToken  = "LINE_CHANNEL_TOKEN_HOGE_HOGE"
 	TestLineChannelSecret = "LINE_CHANNEL_SECRET_HOGE_HOGE"
 	TestLineGroupID       = "LINE_GROUP_ID_HOGE_HOGE"
+
+	TestAdminToken = "ADMIN_TOKEN_HOGE_HOGE"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -35,19 +37,70 @@ func TestNewConfig(t *testing.T) {
 				ServerURL: ServerURL{
 					GolangServer: "http://localhost:8080",
 					DRFServer:    "http://localhost:8000",
+					BitFlyer:     "https://api.bitflyer.com",
 				},
 				BitFlyer: BitFlyer{
 					ApiKey:    TestBitFlyerAPIKey,
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
+				},
+				Retry: Retry{
+					MaxAttempts: 3,
+					BaseDelayMs: 200,
+					MaxDelayMs:  2000,
+				},
+				HTTPClient: HTTPClient{
+					TimeoutSec: 10,
+				},
+				Risk: Risk{
+					MaxOrderNotional: map[string]float64{
+						"JPY": 1000000,
+						"BTC": 0.1,
+					},
+					MaxPosition: map[string]float64{
+						"BTC_JPY":    0.1,
+						"FX_BTC_JPY": 0.1,
+					},
+					MaxOpenOrders: 10,
+					MaxDailyLoss: map[string]float64{
+						"JPY": 50000,
+						"BTC": 0.005,
+					},
+					PriceCollarPercent: 5,
+				},
+				KillSwitch: KillSwitch{
+					StateFile: "data/kill_switch.json",
+				},
+				OrderStore: OrderStore{
+					File:                 "data/orders.json",
+					ReconcileIntervalSec: 30,
+					RetentionDays:        90,
+					MaxLookupMisses:      20,
+				},
+				Idempotency: Idempotency{
+					WindowMin: 1440,
+					File:      "data/idempotency.json",
+				},
+				Admin: Admin{
+					Token: TestAdminToken,
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
 					ChannelSecret: TestLineChannelSecret,
 					GroupID:       TestLineGroupID,
 				},
+				TradingRules: map[string]TradingRule{
+					"BTC_JPY": {MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+				},
 			},
 			wantErr: false,
 		},
@@ -61,19 +114,73 @@ func TestNewConfig(t *testing.T) {
 				ServerURL: ServerURL{
 					GolangServer: "http://golang-server:8080",
 					DRFServer:    "http://drf:8000",
+					BitFlyer:     "https://api.bitflyer.com",
 				},
 				BitFlyer: BitFlyer{
 					ApiKey:    TestBitFlyerAPIKey,
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 1,
+					BatchIntervalSec:    1,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
+				},
+				Realtime: Realtime{
+					PrivateChannels: true,
+				},
+				Retry: Retry{
+					MaxAttempts: 3,
+					BaseDelayMs: 200,
+					MaxDelayMs:  2000,
+				},
+				HTTPClient: HTTPClient{
+					TimeoutSec: 10,
+				},
+				Risk: Risk{
+					MaxOrderNotional: map[string]float64{
+						"JPY": 1000000,
+						"BTC": 0.1,
+					},
+					MaxPosition: map[string]float64{
+						"BTC_JPY":    0.1,
+						"FX_BTC_JPY": 0.1,
+					},
+					MaxOpenOrders: 10,
+					MaxDailyLoss: map[string]float64{
+						"JPY": 50000,
+						"BTC": 0.005,
+					},
+					PriceCollarPercent: 5,
+				},
+				KillSwitch: KillSwitch{
+					StateFile: "data/kill_switch.json",
+				},
+				OrderStore: OrderStore{
+					File:                 "data/orders.json",
+					ReconcileIntervalSec: 30,
+					RetentionDays:        90,
+					MaxLookupMisses:      20,
+				},
+				Idempotency: Idempotency{
+					WindowMin: 1440,
+					File:      "data/idempotency.json",
+				},
+				Admin: Admin{
+					Token: TestAdminToken,
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
 					ChannelSecret: TestLineChannelSecret,
 					GroupID:       TestLineGroupID,
 				},
+				TradingRules: map[string]TradingRule{
+					"BTC_JPY": {MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+				},
 			},
 			wantErr: false,
 		},
@@ -128,16 +235,64 @@ func TestConfig_setFromToml(t *testing.T) {
 				ServerURL: ServerURL{
 					GolangServer: "http://localhost:8080",
 					DRFServer:    "http://localhost:8000",
+					BitFlyer:     "https://api.bitflyer.com",
 				},
 				BitFlyer: BitFlyer{},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
+				},
+				Retry: Retry{
+					MaxAttempts: 3,
+					BaseDelayMs: 200,
+					MaxDelayMs:  2000,
+				},
+				HTTPClient: HTTPClient{
+					TimeoutSec: 10,
+				},
+				Risk: Risk{
+					MaxOrderNotional: map[string]float64{
+						"JPY": 1000000,
+						"BTC": 0.1,
+					},
+					MaxPosition: map[string]float64{
+						"BTC_JPY":    0.1,
+						"FX_BTC_JPY": 0.1,
+					},
+					MaxOpenOrders: 10,
+					MaxDailyLoss: map[string]float64{
+						"JPY": 50000,
+						"BTC": 0.005,
+					},
+					PriceCollarPercent: 5,
+				},
+				KillSwitch: KillSwitch{
+					StateFile: "data/kill_switch.json",
+				},
+				OrderStore: OrderStore{
+					File:                 "data/orders.json",
+					ReconcileIntervalSec: 30,
+					RetentionDays:        90,
+					MaxLookupMisses:      20,
+				},
+				Idempotency: Idempotency{
+					WindowMin: 1440,
+					File:      "data/idempotency.json",
 				},
 				Line: Line{
 					ChannelToken:  "",
 					ChannelSecret: "",
 					GroupID:       "",
 				},
+				TradingRules: map[string]TradingRule{
+					"BTC_JPY": {MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+				},
 			},
 			wantErr: false,
 		},
@@ -150,16 +305,67 @@ func TestConfig_setFromToml(t *testing.T) {
 				ServerURL: ServerURL{
 					GolangServer: "http://golang-server:8080",
 					DRFServer:    "http://drf:8000",
+					BitFlyer:     "https://api.bitflyer.com",
 				},
 				BitFlyer: BitFlyer{},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 1,
+					BatchIntervalSec:    1,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
+				},
+				Realtime: Realtime{
+					PrivateChannels: true,
+				},
+				Retry: Retry{
+					MaxAttempts: 3,
+					BaseDelayMs: 200,
+					MaxDelayMs:  2000,
+				},
+				HTTPClient: HTTPClient{
+					TimeoutSec: 10,
+				},
+				Risk: Risk{
+					MaxOrderNotional: map[string]float64{
+						"JPY": 1000000,
+						"BTC": 0.1,
+					},
+					MaxPosition: map[string]float64{
+						"BTC_JPY":    0.1,
+						"FX_BTC_JPY": 0.1,
+					},
+					MaxOpenOrders: 10,
+					MaxDailyLoss: map[string]float64{
+						"JPY": 50000,
+						"BTC": 0.005,
+					},
+					PriceCollarPercent: 5,
+				},
+				KillSwitch: KillSwitch{
+					StateFile: "data/kill_switch.json",
+				},
+				OrderStore: OrderStore{
+					File:                 "data/orders.json",
+					ReconcileIntervalSec: 30,
+					RetentionDays:        90,
+					MaxLookupMisses:      20,
+				},
+				Idempotency: Idempotency{
+					WindowMin: 1440,
+					File:      "data/idempotency.json",
 				},
 				Line: Line{
 					ChannelToken:  "",
 					ChannelSecret: "",
 					GroupID:       "",
 				},
+				TradingRules: map[string]TradingRule{
+					"BTC_JPY": {MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+				},
 			},
 			wantErr: false,
 		},
@@ -206,6 +412,9 @@ func TestConfig_setFromEnv(t *testing.T) {
 					ApiKey:    TestBitFlyerAPIKey,
 					ApiSecret: TestBitFlyerAPISecret,
 				},
+				Admin: Admin{
+					Token: TestAdminToken,
+				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
 					ChannelSecret: TestLineChannelSecret,
@@ -254,7 +463,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -280,7 +496,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -302,7 +525,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -324,7 +554,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -346,7 +583,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: "",
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -368,7 +612,57 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 0,
+					BatchIntervalSec:    0,
+					FXStatusIntervalSec: 60,
+				},
+				Line: Line{
+					ChannelToken:  TestLineChannelToken,
+					ChannelSecret: TestLineChannelSecret,
+					GroupID:       TestLineGroupID,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail fx status interval is less than or equal to 0",
+			config: &Config{
+				ServerURL: ServerURL{
+					GolangServer: "http://localhost:8080",
+					DRFServer:    "http://localhost:8000",
+				},
+				BitFlyer: BitFlyer{
+					ApiKey:    TestBitFlyerAPIKey,
+					ApiSecret: TestBitFlyerAPISecret,
+				},
+				TickerBatch: TickerBatch{
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 0,
+				},
+				Line: Line{
+					ChannelToken:  TestLineChannelToken,
+					ChannelSecret: TestLineChannelSecret,
+					GroupID:       TestLineGroupID,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail market refresh interval is less than or equal to 0",
+			config: &Config{
+				ServerURL: ServerURL{
+					GolangServer: "http://localhost:8080",
+					DRFServer:    "http://localhost:8000",
+				},
+				BitFlyer: BitFlyer{
+					ApiKey:    TestBitFlyerAPIKey,
+					ApiSecret: TestBitFlyerAPISecret,
+				},
+				TickerBatch: TickerBatch{
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 0,
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -390,7 +684,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  "",
@@ -412,7 +713,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
@@ -422,6 +730,64 @@ func TestConfig_mustCheck(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "fail order gate max health is empty",
+			config: &Config{
+				ServerURL: ServerURL{
+					GolangServer: "http://localhost:8080",
+					DRFServer:    "http://localhost:8000",
+				},
+				BitFlyer: BitFlyer{
+					ApiKey:    TestBitFlyerAPIKey,
+					ApiSecret: TestBitFlyerAPISecret,
+				},
+				TickerBatch: TickerBatch{
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "",
+				},
+				Line: Line{
+					ChannelToken:  TestLineChannelToken,
+					ChannelSecret: TestLineChannelSecret,
+					GroupID:       TestLineGroupID,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "fail order gate max health is invalid",
+			config: &Config{
+				ServerURL: ServerURL{
+					GolangServer: "http://localhost:8080",
+					DRFServer:    "http://localhost:8000",
+				},
+				BitFlyer: BitFlyer{
+					ApiKey:    TestBitFlyerAPIKey,
+					ApiSecret: TestBitFlyerAPISecret,
+				},
+				TickerBatch: TickerBatch{
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "STOP",
+				},
+				Line: Line{
+					ChannelToken:  TestLineChannelToken,
+					ChannelSecret: TestLineChannelSecret,
+					GroupID:       TestLineGroupID,
+				},
+			},
+			wantErr: true,
+		},
 		{
 			name: "fail line groupID is empty",
 			config: &Config{
@@ -434,7 +800,14 @@ func TestConfig_mustCheck(t *testing.T) {
 					ApiSecret: TestBitFlyerAPISecret,
 				},
 				TickerBatch: TickerBatch{
-					BatchIntervalSec: 10,
+					BatchIntervalSec:    10,
+					FXStatusIntervalSec: 60,
+				},
+				Market: Market{
+					RefreshIntervalMin: 60,
+				},
+				OrderGate: OrderGate{
+					MaxHealth: "BUSY",
 				},
 				Line: Line{
 					ChannelToken:  TestLineChannelToken,
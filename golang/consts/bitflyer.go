@@ -11,9 +11,53 @@ const (
 	ProductCodeBCHBTC   = "BCH_BTC"
 	ProductCodeFXBTCJPY = "FX_BTC_JPY"
 
+	MarketTypeSpot = "Spot"
+	MarketTypeFX   = "FX"
+
+	HealthNormal    = "NORMAL"
+	HealthBusy      = "BUSY"
+	HealthVeryBusy  = "VERY BUSY"
+	HealthSuperBusy = "SUPER BUSY"
+	HealthNoOrder   = "NO ORDER"
+	HealthStop      = "STOP"
+
+	BoardStateRunning      = "RUNNING"
+	BoardStateClosed       = "CLOSED"
+	BoardStateStarting     = "STARTING"
+	BoardStatePreopen      = "PREOPEN"
+	BoardStateCircuitBreak = "CIRCUIT BREAK"
+	BoardStateAwaitingSQ   = "AWAITING SQ"
+	BoardStateMatured      = "MATURED"
+
 	ChildOrderTypeLimit  = "LIMIT"
 	ChildOrderTypeMarket = "MARKET"
 
+	ChildOrderStateActive    = "ACTIVE"
+	ChildOrderStateCompleted = "COMPLETED"
+	ChildOrderStateCanceled  = "CANCELED"
+	ChildOrderStateExpired   = "EXPIRED"
+	ChildOrderStateRejected  = "REJECTED"
+
+	OrderMethodSimple = "SIMPLE"
+	OrderMethodIFD    = "IFD"
+	OrderMethodOCO    = "OCO"
+	OrderMethodIFDOCO = "IFDOCO"
+
+	ConditionTypeLimit     = "LIMIT"
+	ConditionTypeMarket    = "MARKET"
+	ConditionTypeStop      = "STOP"
+	ConditionTypeStopLimit = "STOP_LIMIT"
+	ConditionTypeTrail     = "TRAIL"
+
+	OrderEventTypeOrder        = "ORDER"
+	OrderEventTypeOrderFailed  = "ORDER_FAILED"
+	OrderEventTypeCancel       = "CANCEL"
+	OrderEventTypeCancelFailed = "CANCEL_FAILED"
+	OrderEventTypeExecution    = "EXECUTION"
+	OrderEventTypeExpire       = "EXPIRE"
+	OrderEventTypeTrigger      = "TRIGGER"
+	OrderEventTypeComplete     = "COMPLETE"
+
 	SideBuy  = "BUY"
 	SideSell = "SELL"
 
@@ -23,4 +67,8 @@ const (
 
 	MinMinuteToExpire = 1
 	MaxMinuteToExpire = 43200 // 30 days in minutes
+
+	DefaultPaginationCount = 100
+	MaxPaginationCount     = 500
+	MaxPaginationPages     = 10
 )
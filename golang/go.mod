@@ -9,6 +9,8 @@ require (
 
 require github.com/line/line-bot-sdk-go/v7 v7.21.0
 
+require github.com/gorilla/websocket v1.5.3
+
 require (
 	github.com/bytedance/sonic v1.13.2 // indirect
 	github.com/bytedance/sonic/loader v0.2.4 // indirect
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"bitcoin-app-golang/config"
+)
+
+// AdminAuth はAuthorization: Bearer <ADMIN_TOKEN>を検証する。トークンが未設定の場合はすべて拒否する
+func AdminAuth(cfg config.Config) gin.HandlerFunc {
+	token := string(cfg.Admin.Token)
+
+	return func(ctx *gin.Context) {
+		if token == "" {
+			ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin token is not configured"})
+			return
+		}
+
+		given, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		ctx.Next()
+	}
+}
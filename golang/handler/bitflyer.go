@@ -1,19 +1,48 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"bitcoin-app-golang/api"
 	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
 	"bitcoin-app-golang/usecase"
 )
 
 type IBitFlyerHandler interface {
+	GetMarkets(ctx *gin.Context)
 	GetTickerFromBitFlyer(ctx *gin.Context)
+	GetBoard(ctx *gin.Context)
+	GetExecutions(ctx *gin.Context)
+	GetFXStatus(ctx *gin.Context)
 	BuyOrder(ctx *gin.Context)
 	SellOrder(ctx *gin.Context)
+	CancelOrder(ctx *gin.Context)
+	CancelAllOrders(ctx *gin.Context)
+	GetChildOrders(ctx *gin.Context)
+	GetChildOrder(ctx *gin.Context)
+	GetLiveOrders(ctx *gin.Context)
+	GetOrderHistory(ctx *gin.Context)
+	SendParentOrder(ctx *gin.Context)
+	GetParentOrders(ctx *gin.Context)
+	GetParentOrder(ctx *gin.Context)
+	CancelParentOrder(ctx *gin.Context)
+	GetBalance(ctx *gin.Context)
+	GetCollateral(ctx *gin.Context)
+	GetCollateralHistory(ctx *gin.Context)
+	GetPositions(ctx *gin.Context)
+	GetTradingCommission(ctx *gin.Context)
+	GetOrderFills(ctx *gin.Context)
+	GetFundingMovements(ctx *gin.Context)
+	GetRateLimitBudget(ctx *gin.Context)
+	GetKillSwitch(ctx *gin.Context)
+	SetKillSwitch(ctx *gin.Context)
 }
 
 type BitFlyerHandler struct {
@@ -22,19 +51,28 @@ type BitFlyerHandler struct {
 	UseCase usecase.IBitFlyerUsecase
 }
 
-func NewBitFlyerHandler(cfg config.Config) IBitFlyerHandler {
-	usecase := usecase.NewBitFlyerUsecase(cfg)
-
+func NewBitFlyerHandler(cfg config.Config, bitFlyerUsecase usecase.IBitFlyerUsecase) IBitFlyerHandler {
 	return &BitFlyerHandler{
 		Config:  cfg,
-		UseCase: usecase,
+		UseCase: bitFlyerUsecase,
+	}
+}
+
+func (h *BitFlyerHandler) GetMarkets(ctx *gin.Context) {
+	markets, statusCode, err := h.UseCase.GetMarkets()
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting markets: %v", err)
+		return
 	}
+
+	ctx.JSON(statusCode, markets)
 }
 
 func (h *BitFlyerHandler) GetTickerFromBitFlyer(ctx *gin.Context) {
 	productCode := ctx.Request.URL.Query().Get("product_code")
 
-	ticker, statusCode, err := h.UseCase.GetTicker(productCode)
+	ticker, statusCode, err := h.UseCase.GetTicker(ctx.Request.Context(), productCode)
 	if err != nil {
 		ctx.JSON(statusCode, gin.H{"error": err.Error()})
 		log.Printf("Error getting ticker: %v", err)
@@ -44,6 +82,61 @@ func (h *BitFlyerHandler) GetTickerFromBitFlyer(ctx *gin.Context) {
 	ctx.JSON(statusCode, ticker)
 }
 
+func (h *BitFlyerHandler) GetBoard(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+
+	depth, err := queryInt(ctx, "depth", 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	board, statusCode, err := h.UseCase.GetBoard(ctx.Request.Context(), productCode, depth)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting board: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, board)
+}
+
+func (h *BitFlyerHandler) GetExecutions(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pages, err := queryInt(ctx, "pages", 1)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetExecutions(ctx.Request.Context(), productCode, p, pages)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting executions: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetFXStatus(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetFXStatus(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting fx status: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
 func (h *BitFlyerHandler) BuyOrder(ctx *gin.Context) {
 	var dto usecase.BuyOrderDTO
 	if err := ctx.ShouldBindJSON(&dto); err != nil {
@@ -51,9 +144,16 @@ func (h *BitFlyerHandler) BuyOrder(ctx *gin.Context) {
 		return
 	}
 
-	res, statusCode, err := h.UseCase.BuyOrder(dto)
+	clientOrderID, err := idempotencyKey(ctx, dto.ClientOrderID)
 	if err != nil {
-		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto.ClientOrderID = clientOrderID
+
+	res, statusCode, err := h.UseCase.BuyOrder(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, orderErrorBody(err))
 		log.Printf("Error processing buy order: %v", err)
 		return
 	}
@@ -68,12 +168,416 @@ func (h *BitFlyerHandler) SellOrder(ctx *gin.Context) {
 		return
 	}
 
-	res, statusCode, err := h.UseCase.SellOrder(dto)
+	clientOrderID, err := idempotencyKey(ctx, dto.ClientOrderID)
 	if err != nil {
-		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dto.ClientOrderID = clientOrderID
+
+	res, statusCode, err := h.UseCase.SellOrder(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, orderErrorBody(err))
 		log.Printf("Error processing sell order: %v", err)
 		return
 	}
 
 	ctx.JSON(statusCode, res)
 }
+
+// Idempotency-Keyヘッダーとclient_order_idは、両方指定する場合は同じ値にする
+func idempotencyKey(ctx *gin.Context, clientOrderID string) (string, error) {
+	key := ctx.GetHeader("Idempotency-Key")
+	if key == "" {
+		return clientOrderID, nil
+	}
+	if clientOrderID != "" && clientOrderID != key {
+		return "", errors.New("Idempotency-Key header and client_order_id must match")
+	}
+	return key, nil
+}
+
+func (h *BitFlyerHandler) CancelOrder(ctx *gin.Context) {
+	var dto usecase.CancelOrderDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	statusCode, err := h.UseCase.CancelOrder(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error processing cancel order: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, gin.H{"status": "Cancel order accepted"})
+}
+
+func (h *BitFlyerHandler) CancelAllOrders(ctx *gin.Context) {
+	var dto usecase.CancelAllOrdersDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	statusCode, err := h.UseCase.CancelAllOrders(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error processing cancel all orders: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, gin.H{"status": "Cancel all orders accepted"})
+}
+
+func (h *BitFlyerHandler) GetChildOrders(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+	childOrderState := ctx.Request.URL.Query().Get("child_order_state")
+
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetChildOrders(ctx.Request.Context(), productCode, childOrderState, p)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting child orders: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetChildOrder(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+	acceptanceID := ctx.Param("acceptance_id")
+
+	res, statusCode, err := h.UseCase.GetChildOrder(ctx.Request.Context(), productCode, acceptanceID)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting child order: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetLiveOrders(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetLiveOrders()
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting live orders: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetOrderHistory(ctx *gin.Context) {
+	count, err := queryInt(ctx, "count", 0)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	productCode := ctx.Request.URL.Query().Get("product_code")
+	childOrderState := ctx.Request.URL.Query().Get("child_order_state")
+	res, statusCode, err := h.UseCase.GetOrderHistory(productCode, childOrderState, count)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting order history: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) SendParentOrder(ctx *gin.Context) {
+	var dto usecase.ParentOrderDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.SendParentOrder(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, orderErrorBody(err))
+		log.Printf("Error processing parent order: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetParentOrders(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+	parentOrderState := ctx.Request.URL.Query().Get("parent_order_state")
+
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetParentOrders(ctx.Request.Context(), productCode, parentOrderState, p)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting parent orders: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetParentOrder(ctx *gin.Context) {
+	acceptanceID := ctx.Param("acceptance_id")
+
+	res, statusCode, err := h.UseCase.GetParentOrder(ctx.Request.Context(), acceptanceID)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting parent order: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) CancelParentOrder(ctx *gin.Context) {
+	var dto usecase.CancelParentOrderDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	statusCode, err := h.UseCase.CancelParentOrder(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error processing cancel parent order: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, gin.H{"status": "Cancel parent order accepted"})
+}
+
+func (h *BitFlyerHandler) GetBalance(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetBalance(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting balance: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetCollateral(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetCollateral(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting collateral: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetCollateralHistory(ctx *gin.Context) {
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetCollateralHistory(ctx.Request.Context(), p)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting collateral history: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+// product_codeが指定されない場合はFX_BTC_JPYの建玉を返す
+func (h *BitFlyerHandler) GetPositions(ctx *gin.Context) {
+	productCode := ctx.DefaultQuery("product_code", consts.ProductCodeFXBTCJPY)
+
+	res, statusCode, err := h.UseCase.GetPositions(ctx.Request.Context(), productCode)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting positions: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetTradingCommission(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+
+	res, statusCode, err := h.UseCase.GetTradingCommission(ctx.Request.Context(), productCode)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting trading commission: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetOrderFills(ctx *gin.Context) {
+	productCode := ctx.Request.URL.Query().Get("product_code")
+
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pages, err := queryInt(ctx, "pages", 1)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetOrderFills(ctx.Request.Context(), productCode, p, pages)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting order fills: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetFundingMovements(ctx *gin.Context) {
+	currencyCode := ctx.Request.URL.Query().Get("currency_code")
+
+	p, err := parsePagination(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pages, err := queryInt(ctx, "pages", 1)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	res, statusCode, err := h.UseCase.GetFundingMovements(ctx.Request.Context(), currencyCode, p, pages)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting funding movements: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetRateLimitBudget(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetRateLimitBudget()
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting rate limit budget: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func (h *BitFlyerHandler) GetKillSwitch(ctx *gin.Context) {
+	res, statusCode, err := h.UseCase.GetKillSwitch()
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error getting kill switch: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+// sourceを省略した場合はHTTPから切り替えたものとして記録する
+func (h *BitFlyerHandler) SetKillSwitch(ctx *gin.Context) {
+	var dto usecase.KillSwitchDTO
+	if err := ctx.ShouldBindJSON(&dto); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if dto.Source == "" {
+		dto.Source = usecase.KillSwitchSourceHTTP
+	}
+
+	res, statusCode, err := h.UseCase.SetKillSwitch(ctx.Request.Context(), dto)
+	if err != nil {
+		ctx.JSON(statusCode, gin.H{"error": err.Error()})
+		log.Printf("Error setting kill switch: %v", err)
+		return
+	}
+
+	ctx.JSON(statusCode, res)
+}
+
+func queryInt(ctx *gin.Context, key string, defaultValue int) (int, error) {
+	v := ctx.Request.URL.Query().Get(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+	return i, nil
+}
+
+func queryInt64(ctx *gin.Context, key string) (int64, error) {
+	v := ctx.Request.URL.Query().Get(key)
+	if v == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+	return i, nil
+}
+
+func parsePagination(ctx *gin.Context) (api.Pagination, error) {
+	count, err := queryInt(ctx, "count", 0)
+	if err != nil {
+		return api.Pagination{}, err
+	}
+
+	before, err := queryInt64(ctx, "before")
+	if err != nil {
+		return api.Pagination{}, err
+	}
+
+	after, err := queryInt64(ctx, "after")
+	if err != nil {
+		return api.Pagination{}, err
+	}
+
+	return api.Pagination{
+		Count:  count,
+		Before: before,
+		After:  after,
+	}, nil
+}
+
+// リスクチェックで拒否された場合は理由を構造化して返し、板の状態で拒否された場合はcodeを付ける
+func orderErrorBody(err error) gin.H {
+	var rejection *usecase.RiskRejection
+	if errors.As(err, &rejection) {
+		return gin.H{"error": err.Error(), "rejection": rejection}
+	}
+	if code := usecase.OrderGateCode(err); code != "" {
+		return gin.H{"error": err.Error(), "code": code}
+	}
+	return gin.H{"error": err.Error()}
+}
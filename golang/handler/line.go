@@ -19,17 +19,20 @@ type ILineHandler interface {
 type LineHandler struct {
 	Config       config.Config
 	ILineUsecase usecase.ILineUsecase
+	// キルスイッチのコマンドを処理する
+	BitFlyerUsecase usecase.IBitFlyerUsecase
 }
 
-func NewLineHandler(cfg config.Config) (ILineHandler, error) {
+func NewLineHandler(cfg config.Config, bitFlyerUsecase usecase.IBitFlyerUsecase) (ILineHandler, error) {
 	lineUsecase, err := usecase.NewLineUsecase(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LineHandler{
-		Config:       cfg,
-		ILineUsecase: lineUsecase,
+		Config:          cfg,
+		ILineUsecase:    lineUsecase,
+		BitFlyerUsecase: bitFlyerUsecase,
 	}, nil
 }
 
@@ -40,7 +43,7 @@ func (h *LineHandler) PostMessage(ctx *gin.Context) {
 		return
 	}
 
-	statusCode, err := h.ILineUsecase.SendMessageToGroup(dto)
+	statusCode, err := h.ILineUsecase.SendMessageToGroup(ctx.Request.Context(), dto)
 	if err != nil {
 		ctx.JSON(statusCode, gin.H{"error": err.Error()})
 		return
@@ -50,7 +53,8 @@ func (h *LineHandler) PostMessage(ctx *gin.Context) {
 }
 
 func (h *LineHandler) CallbackMessage(c *gin.Context) {
-	// 本来Usecase層で処理するべきだが、利用されない想定のコードなのでここに残しておく。
+	// 本来Usecase層で処理するべきだが、グループIDの返信は利用されない想定のコードなのでここに残しておく。
+	// キルスイッチのコマンドはUsecase層で処理する
 
 	bot, err := linebot.New(string(h.Config.Line.ChannelSecret), string(h.Config.Line.ChannelToken))
 	if err != nil {
@@ -71,11 +75,18 @@ func (h *LineHandler) CallbackMessage(c *gin.Context) {
 
 	for _, event := range events {
 		if event.Type == linebot.EventTypeMessage {
-			if _, ok := event.Message.(*linebot.TextMessage); ok {
+			if message, ok := event.Message.(*linebot.TextMessage); ok {
 				if event.Source.Type == linebot.EventSourceTypeGroup {
 					groupID := event.Source.GroupID
 					replyText := "このグループのIDは: " + groupID
 
+					// キルスイッチは署名を検証した上で、通知先に設定したグループからのメッセージだけ受け付ける
+					if groupID == string(h.Config.Line.GroupID) {
+						if reply, ok := h.BitFlyerUsecase.HandleKillSwitchCommand(c.Request.Context(), message.Text); ok {
+							replyText = reply
+						}
+					}
+
 					_, err := bot.ReplyMessage(event.ReplyToken, linebot.NewTextMessage(replyText)).Do()
 					if err != nil {
 						log.Println("Reply error:", err)
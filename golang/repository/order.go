@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+// 取引所で一定回数見つからなかった注文の状態。bitFlyerの状態ではないため、突き合わせの対象から外す目印として使う
+const OrderStateUnknown = "UNKNOWN"
+
+// Order は発注した子注文の記録。Stateなどは取引所の状態と突き合わせて更新する
+type Order struct {
+	// リポジトリが採番するID。ドライランは受付IDがないためこれで識別する
+	ID                     int64                     `json:"id"`
+	Request                api.SendChildOrderRequest `json:"request"`
+	ChildOrderAcceptanceID string                    `json:"child_order_acceptance_id"`
+	IsDry                  bool                      `json:"is_dry"`
+	// 送信結果が不明で、注文一覧から受付IDを見つけた場合にtrue
+	Reconciled bool `json:"reconciled"`
+
+	// 取引所でまだ確認できていない場合は空文字
+	State           string  `json:"state"`
+	ChildOrderID    string  `json:"child_order_id"`
+	AveragePrice    float64 `json:"average_price"`
+	ExecutedSize    float64 `json:"executed_size"`
+	OutstandingSize float64 `json:"outstanding_size"`
+	CancelSize      float64 `json:"cancel_size"`
+	TotalCommission float64 `json:"total_commission"`
+	// 突き合わせで連続して取引所の注文一覧に見つからなかった回数
+	Misses int `json:"misses,omitempty"`
+
+	SubmittedAt time.Time `json:"submitted_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// 状態が変わらなくなった注文は突き合わせの対象から外す
+func (o Order) IsOpen() bool {
+	if o.IsDry || o.ChildOrderAcceptanceID == "" {
+		return false
+	}
+	switch o.State {
+	case consts.ChildOrderStateCompleted, consts.ChildOrderStateCanceled,
+		consts.ChildOrderStateExpired, consts.ChildOrderStateRejected, OrderStateUnknown:
+		return false
+	default:
+		return true
+	}
+}
+
+// 空の項目では絞り込まない。Countが0以下の場合はすべて返す
+type OrderFilter struct {
+	ProductCode string
+	State       string
+	Count       int
+}
+
+type IOrderRepository interface {
+	// IDを採番して保存し、採番後の注文を返す
+	Create(order Order) (Order, error)
+	Update(order Order) error
+	FindByAcceptanceID(childOrderAcceptanceID string) (Order, error)
+	// 新しい順に返す
+	List(filter OrderFilter) ([]Order, error)
+	ListOpen() ([]Order, error)
+}
+
+// FileOrderRepository は注文をメモリ上に保持し、変更のたびにJSONファイルへ書き出す。
+// ファイルが大きくなり続けないよう、終了してからretentionを過ぎた注文は発注のたびに削除する
+type FileOrderRepository struct {
+	mu        sync.RWMutex
+	path      string
+	retention time.Duration
+	orders    []Order
+	nextID    int64
+}
+
+func NewMemoryOrderRepository() *FileOrderRepository {
+	return &FileOrderRepository{nextID: 1}
+}
+
+// pathが空の場合はファイルに保存しない。ファイルがない場合は空の状態から始める。
+// retentionが0以下の場合は終了した注文も削除しない
+func NewFileOrderRepository(path string, retention time.Duration) (*FileOrderRepository, error) {
+	r := NewMemoryOrderRepository()
+	r.path = path
+	r.retention = retention
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read orders: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.orders); err != nil {
+		return nil, fmt.Errorf("failed to parse orders: %w", err)
+	}
+	for _, o := range r.orders {
+		if o.ID >= r.nextID {
+			r.nextID = o.ID + 1
+		}
+	}
+	r.orders = r.prune(r.orders, time.Now())
+	return r, nil
+}
+
+func (r *FileOrderRepository) Create(order Order) (Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order.ID = r.nextID
+	orders := append(r.prune(r.orders, time.Now()), order)
+	if err := r.save(orders); err != nil {
+		return Order{}, err
+	}
+	r.orders = orders
+	r.nextID++
+	return order, nil
+}
+
+func (r *FileOrderRepository) Update(order Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := sort.Search(len(r.orders), func(i int) bool { return r.orders[i].ID >= order.ID })
+	if i == len(r.orders) || r.orders[i].ID != order.ID {
+		return fmt.Errorf("%w: id %d", ErrOrderNotFound, order.ID)
+	}
+
+	orders := make([]Order, len(r.orders))
+	copy(orders, r.orders)
+	orders[i] = order
+	if err := r.save(orders); err != nil {
+		return err
+	}
+	r.orders = orders
+	return nil
+}
+
+func (r *FileOrderRepository) FindByAcceptanceID(childOrderAcceptanceID string) (Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.orders) - 1; i >= 0; i-- {
+		if r.orders[i].ChildOrderAcceptanceID == childOrderAcceptanceID {
+			return r.orders[i], nil
+		}
+	}
+	return Order{}, fmt.Errorf("%w: acceptance id %s", ErrOrderNotFound, childOrderAcceptanceID)
+}
+
+func (r *FileOrderRepository) List(filter OrderFilter) ([]Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := []Order{}
+	for i := len(r.orders) - 1; i >= 0; i-- {
+		o := r.orders[i]
+		if filter.ProductCode != "" && o.Request.ProductCode != filter.ProductCode {
+			continue
+		}
+		if filter.State != "" && o.State != filter.State {
+			continue
+		}
+		orders = append(orders, o)
+		if filter.Count > 0 && len(orders) >= filter.Count {
+			break
+		}
+	}
+	return orders, nil
+}
+
+func (r *FileOrderRepository) ListOpen() ([]Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := []Order{}
+	for _, o := range r.orders {
+		if o.IsOpen() {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+// 終了してからretentionを過ぎた注文を除いた新しいスライスを返す。IDの順序は保つ
+func (r *FileOrderRepository) prune(orders []Order, now time.Time) []Order {
+	if r.retention <= 0 {
+		return orders
+	}
+
+	kept := make([]Order, 0, len(orders))
+	for _, o := range orders {
+		if !o.IsOpen() && now.Sub(o.UpdatedAt) > r.retention {
+			continue
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// 書き込み途中で落ちても壊れたファイルが残らないよう、一時ファイルに書いてから置き換える
+func (r *FileOrderRepository) save(orders []Order) error {
+	if r.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(orders)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create orders directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write orders: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write orders: %w", err)
+	}
+	return nil
+}
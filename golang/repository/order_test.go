@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestFileOrderRepository_persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "orders.json")
+
+	r, err := NewFileOrderRepository(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileOrderRepository() error = %v", err)
+	}
+	created, err := r.Create(Order{
+		Request:                api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Size: 0.01},
+		ChildOrderAcceptanceID: "JRF20240101-000000-000001",
+	})
+	if err != nil {
+		t.Fatalf("FileOrderRepository.Create() error = %v", err)
+	}
+	created.State = consts.ChildOrderStateCompleted
+	created.ExecutedSize = 0.01
+	if err := r.Update(created); err != nil {
+		t.Fatalf("FileOrderRepository.Update() error = %v", err)
+	}
+
+	// 再起動を想定して別のインスタンスで読み込む
+	restarted, err := NewFileOrderRepository(path, 0)
+	if err != nil {
+		t.Fatalf("NewFileOrderRepository() error = %v", err)
+	}
+	got, _ := restarted.List(OrderFilter{})
+	if len(got) != 1 || got[0].State != consts.ChildOrderStateCompleted || got[0].ExecutedSize != 0.01 {
+		t.Fatalf("FileOrderRepository.List() = %+v, want the completed order", got)
+	}
+
+	next, err := restarted.Create(Order{ChildOrderAcceptanceID: "JRF20240101-000000-000002"})
+	if err != nil {
+		t.Fatalf("FileOrderRepository.Create() error = %v", err)
+	}
+	if next.ID != created.ID+1 {
+		t.Errorf("FileOrderRepository.Create() id = %v, want %v", next.ID, created.ID+1)
+	}
+}
+
+func TestFileOrderRepository_List(t *testing.T) {
+	r := NewMemoryOrderRepository()
+	orders := []Order{
+		{Request: api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY}, ChildOrderAcceptanceID: "a", State: consts.ChildOrderStateActive},
+		{Request: api.SendChildOrderRequest{ProductCode: consts.ProductCodeETHJPY}, ChildOrderAcceptanceID: "b", State: consts.ChildOrderStateActive},
+		{Request: api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY}, ChildOrderAcceptanceID: "c", State: consts.ChildOrderStateCompleted},
+		{Request: api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY}, IsDry: true},
+	}
+	for _, o := range orders {
+		if _, err := r.Create(o); err != nil {
+			t.Fatalf("FileOrderRepository.Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter OrderFilter
+		want   []int64
+	}{
+		{name: "all newest first", filter: OrderFilter{}, want: []int64{4, 3, 2, 1}},
+		{name: "product code", filter: OrderFilter{ProductCode: consts.ProductCodeBTCJPY}, want: []int64{4, 3, 1}},
+		{name: "state", filter: OrderFilter{State: consts.ChildOrderStateActive}, want: []int64{2, 1}},
+		{name: "count", filter: OrderFilter{Count: 2}, want: []int64{4, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.List(tt.filter)
+			if err != nil {
+				t.Fatalf("FileOrderRepository.List() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FileOrderRepository.List() = %+v, want ids %v", got, tt.want)
+			}
+			for i, id := range tt.want {
+				if got[i].ID != id {
+					t.Errorf("FileOrderRepository.List()[%d].ID = %v, want %v", i, got[i].ID, id)
+				}
+			}
+		})
+	}
+
+	open, _ := r.ListOpen()
+	if len(open) != 2 || open[0].ID != 1 || open[1].ID != 2 {
+		t.Errorf("FileOrderRepository.ListOpen() = %+v, want ids [1 2]", open)
+	}
+}
+
+func TestFileOrderRepository_Update_notFound(t *testing.T) {
+	r := NewMemoryOrderRepository()
+	if err := r.Update(Order{ID: 1}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("FileOrderRepository.Update() error = %v, want ErrOrderNotFound", err)
+	}
+}
+
+func TestFileOrderRepository_prune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	r, err := NewFileOrderRepository(path, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileOrderRepository() error = %v", err)
+	}
+
+	old := time.Now().Add(-48 * time.Hour)
+	orders := []Order{
+		{ChildOrderAcceptanceID: "completed", State: consts.ChildOrderStateCompleted, UpdatedAt: old},
+		{ChildOrderAcceptanceID: "active", State: consts.ChildOrderStateActive, UpdatedAt: old},
+		{ChildOrderAcceptanceID: "recent", State: consts.ChildOrderStateCanceled, UpdatedAt: time.Now()},
+	}
+	for _, o := range orders {
+		if _, err := r.Create(o); err != nil {
+			t.Fatalf("FileOrderRepository.Create() error = %v", err)
+		}
+	}
+
+	// 終了して保存期間を過ぎた注文だけが消え、終了していない注文は古くても残る
+	got, _ := r.List(OrderFilter{})
+	if len(got) != 2 || got[0].ChildOrderAcceptanceID != "recent" || got[1].ChildOrderAcceptanceID != "active" {
+		t.Errorf("FileOrderRepository.List() = %+v, want recent and active", got)
+	}
+	if _, err := r.FindByAcceptanceID("completed"); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("FileOrderRepository.FindByAcceptanceID() error = %v, want ErrOrderNotFound", err)
+	}
+}
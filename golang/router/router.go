@@ -8,17 +8,20 @@ import (
 
 	"bitcoin-app-golang/config"
 	"bitcoin-app-golang/handler"
+	"bitcoin-app-golang/usecase"
 )
 
-func NewRouter(cfg config.Config) *gin.Engine {
+func NewRouter(cfg config.Config, opts usecase.BitFlyerUsecaseOptions) *gin.Engine {
 	r := gin.Default()
 
-	return setRoutes(r, cfg)
+	return setRoutes(r, cfg, opts)
 }
 
-func setRoutes(r *gin.Engine, cfg config.Config) *gin.Engine {
-	bitFlyerHandler := handler.NewBitFlyerHandler(cfg)
-	lineHandler, err := handler.NewLineHandler(cfg)
+func setRoutes(r *gin.Engine, cfg config.Config, opts usecase.BitFlyerUsecaseOptions) *gin.Engine {
+	// キルスイッチなどの状態はHTTPとLINEのどちらから操作しても同じものを使う
+	bitFlyerUsecase := usecase.NewBitFlyerUsecase(cfg, opts)
+	bitFlyerHandler := handler.NewBitFlyerHandler(cfg, bitFlyerUsecase)
+	lineHandler, err := handler.NewLineHandler(cfg, bitFlyerUsecase)
 	if err != nil {
 		panic(fmt.Errorf("failed to create Line handler: %w", err))
 	}
@@ -28,9 +31,35 @@ func setRoutes(r *gin.Engine, cfg config.Config) *gin.Engine {
 	})
 
 	bitflyer := r.Group("/bitflyer")
+	bitflyer.GET("/markets", bitFlyerHandler.GetMarkets)
 	bitflyer.GET("/ticker", bitFlyerHandler.GetTickerFromBitFlyer)
+	bitflyer.GET("/board", bitFlyerHandler.GetBoard)
+	bitflyer.GET("/executions", bitFlyerHandler.GetExecutions)
+	bitflyer.GET("/fx/status", bitFlyerHandler.GetFXStatus)
 	bitflyer.POST("/order/buy", bitFlyerHandler.BuyOrder)
 	bitflyer.POST("/order/sell", bitFlyerHandler.SellOrder)
+	bitflyer.DELETE("/order", bitFlyerHandler.CancelOrder)
+	bitflyer.GET("/orders", bitFlyerHandler.GetChildOrders)
+	bitflyer.DELETE("/orders", bitFlyerHandler.CancelAllOrders)
+	bitflyer.GET("/orders/live", bitFlyerHandler.GetLiveOrders)
+	bitflyer.GET("/orders/history", bitFlyerHandler.GetOrderHistory)
+	bitflyer.GET("/orders/:acceptance_id", bitFlyerHandler.GetChildOrder)
+	bitflyer.POST("/parentorder", bitFlyerHandler.SendParentOrder)
+	bitflyer.DELETE("/parentorder", bitFlyerHandler.CancelParentOrder)
+	bitflyer.GET("/parentorders", bitFlyerHandler.GetParentOrders)
+	bitflyer.GET("/parentorders/:acceptance_id", bitFlyerHandler.GetParentOrder)
+	bitflyer.GET("/me/balance", bitFlyerHandler.GetBalance)
+	bitflyer.GET("/me/collateral", bitFlyerHandler.GetCollateral)
+	bitflyer.GET("/me/collateral/history", bitFlyerHandler.GetCollateralHistory)
+	bitflyer.GET("/me/positions", bitFlyerHandler.GetPositions)
+	bitflyer.GET("/me/commission", bitFlyerHandler.GetTradingCommission)
+	bitflyer.GET("/me/fills", bitFlyerHandler.GetOrderFills)
+	bitflyer.GET("/me/funding-movements", bitFlyerHandler.GetFundingMovements)
+	bitflyer.GET("/ratelimit", bitFlyerHandler.GetRateLimitBudget)
+
+	admin := r.Group("/admin", handler.AdminAuth(cfg))
+	admin.GET("/killswitch", bitFlyerHandler.GetKillSwitch)
+	admin.PUT("/killswitch", bitFlyerHandler.SetKillSwitch)
 
 	line := r.Group("/line")
 	line.POST("/message", lineHandler.PostMessage)
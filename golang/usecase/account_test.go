@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+)
+
+func TestBitFlyerUsecase_GetBalance(t *testing.T) {
+	balances := []api.BalanceFromBitFlyer{
+		{CurrencyCode: "JPY", Amount: 1024078, Available: 508000},
+		{CurrencyCode: "BTC", Amount: 10.24, Available: 4.12},
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		want        []api.BalanceFromBitFlyer
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBalanceFunc: func() ([]api.BalanceFromBitFlyer, error) { return balances, nil },
+			},
+			want:    balances,
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBalanceFunc: func() ([]api.BalanceFromBitFlyer, error) { return nil, errors.New("api error") },
+			},
+			want:    nil,
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetBalance(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetBalance() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetBalance() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetBalance() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_GetCollateral(t *testing.T) {
+	collateral := api.CollateralFromBitFlyer{
+		Collateral:        100000,
+		OpenPositionPnl:   -715,
+		RequireCollateral: 19857,
+		KeepRate:          5.000,
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		want        api.CollateralFromBitFlyer
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetCollateralFunc: func() (api.CollateralFromBitFlyer, error) { return collateral, nil },
+			},
+			want:    collateral,
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetCollateralFunc: func() (api.CollateralFromBitFlyer, error) {
+					return api.CollateralFromBitFlyer{}, errors.New("api error")
+				},
+			},
+			want:    api.CollateralFromBitFlyer{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetCollateral(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetCollateral() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetCollateral() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetCollateral() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_GetCollateralHistory(t *testing.T) {
+	history := []api.CollateralHistoryFromBitFlyer{
+		{ID: 4995, CurrencyCode: "JPY", Change: -6, Amount: -6, ReasonCode: "CLEARING_COLL", Date: "2017-05-18T02:37:41.327"},
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		p           api.Pagination
+		want        []api.CollateralHistoryFromBitFlyer
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetCollateralHistoryFunc: func(p api.Pagination) ([]api.CollateralHistoryFromBitFlyer, error) { return history, nil },
+			},
+			p:       api.Pagination{Count: 1},
+			want:    history,
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid pagination",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			p:           api.Pagination{Count: -1},
+			want:        nil,
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetCollateralHistory(context.Background(), tt.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetCollateralHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetCollateralHistory() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetCollateralHistory() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
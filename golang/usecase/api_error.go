@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"errors"
+	"net/http"
+
+	"bitcoin-app-golang/api"
+)
+
+// bitFlyer APIのエラーをクライアントに返すHTTPステータスに変換する
+func statusFromError(err error) int {
+	switch {
+	case errors.Is(err, api.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, api.ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, api.ErrUnauthorized):
+		// APIキーの設定はサーバ側の問題なので、クライアントには上流の失敗として返す
+		return http.StatusBadGateway
+	case errors.Is(err, api.ErrInvalidTimestamp):
+		// サーバの時刻ずれが原因なのでサーバ側のエラーとする
+		return http.StatusInternalServerError
+	}
+
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.HTTPStatus == http.StatusNotFound:
+			return http.StatusNotFound
+		case apiErr.HTTPStatus >= 500:
+			return http.StatusBadGateway
+		case apiErr.HTTPStatus >= 400:
+			// 価格やサイズの不備など、リクエスト内容に起因するもの
+			return http.StatusBadRequest
+		}
+	}
+
+	return http.StatusInternalServerError
+}
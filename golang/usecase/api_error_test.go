@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func Test_statusFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "insufficient funds", err: &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -208}, want: http.StatusUnprocessableEntity},
+		{name: "rate limited", err: &api.APIError{HTTPStatus: http.StatusTooManyRequests}, want: http.StatusTooManyRequests},
+		{name: "unauthorized", err: &api.APIError{HTTPStatus: http.StatusUnauthorized}, want: http.StatusBadGateway},
+		{name: "invalid timestamp", err: &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -500}, want: http.StatusInternalServerError},
+		{name: "other bad request", err: &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -106}, want: http.StatusBadRequest},
+		{name: "not found", err: &api.APIError{HTTPStatus: http.StatusNotFound}, want: http.StatusNotFound},
+		{name: "upstream server error", err: &api.APIError{HTTPStatus: http.StatusServiceUnavailable}, want: http.StatusBadGateway},
+		{name: "wrapped", err: fmt.Errorf("send order: %w", &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -208}), want: http.StatusUnprocessableEntity},
+		{name: "network error", err: errors.New("dial tcp: connection refused"), want: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statusFromError(tt.err); got != tt.want {
+				t.Errorf("statusFromError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_BuyOrder_apiError(t *testing.T) {
+	b := &BitFlyerUsecase{
+		Config: TestConfig,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				return api.SendChildOrderResponse{}, &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -208, ErrorMessage: "Insufficient funds"}
+			},
+		},
+	}
+
+	_, got, err := b.BuyOrder(context.Background(), BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          5000000,
+		Size:           0.01,
+		MinuteToExpire: 43200,
+		TimeInForce:    consts.TimeInForceGTC,
+	})
+	if !errors.Is(err, api.ErrInsufficientFunds) {
+		t.Errorf("BitFlyerUsecase.BuyOrder() error = %v, want ErrInsufficientFunds", err)
+	}
+	if got != http.StatusUnprocessableEntity {
+		t.Errorf("BitFlyerUsecase.BuyOrder() statusCode = %v, want %v", got, http.StatusUnprocessableEntity)
+	}
+}
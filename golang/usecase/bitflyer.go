@@ -1,19 +1,47 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"bitcoin-app-golang/api"
 	"bitcoin-app-golang/config"
 	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
 )
 
 type IBitFlyerUsecase interface {
-	GetTicker(productCode string) (api.TickerFromBitFlyer, int, error)
-	BuyOrder(dto BuyOrderDTO) (api.SendChildOrderResponse, int, error)
-	SellOrder(dto SellOrderDTO) (api.SendChildOrderResponse, int, error)
+	GetMarkets() (MarketList, int, error)
+	GetTicker(ctx context.Context, productCode string) (api.TickerFromBitFlyer, int, error)
+	GetBoard(ctx context.Context, productCode string, depth int) (api.BoardFromBitFlyer, int, error)
+	GetExecutions(ctx context.Context, productCode string, p api.Pagination, pages int) (ExecutionsPage, int, error)
+	GetFXStatus(ctx context.Context) (FXStatus, int, error)
+	BuyOrder(ctx context.Context, dto BuyOrderDTO) (api.SendChildOrderResponse, int, error)
+	SellOrder(ctx context.Context, dto SellOrderDTO) (api.SendChildOrderResponse, int, error)
+	CancelOrder(ctx context.Context, dto CancelOrderDTO) (int, error)
+	CancelAllOrders(ctx context.Context, dto CancelAllOrdersDTO) (int, error)
+	GetChildOrders(ctx context.Context, productCode, childOrderState string, p api.Pagination) ([]api.ChildOrderFromBitFlyer, int, error)
+	GetChildOrder(ctx context.Context, productCode, acceptanceID string) (api.ChildOrderFromBitFlyer, int, error)
+	GetLiveOrders() ([]OrderState, int, error)
+	SendParentOrder(ctx context.Context, dto ParentOrderDTO) (api.SendParentOrderResponse, int, error)
+	GetParentOrders(ctx context.Context, productCode, parentOrderState string, p api.Pagination) ([]api.ParentOrderFromBitFlyer, int, error)
+	GetParentOrder(ctx context.Context, acceptanceID string) (api.ParentOrderDetailFromBitFlyer, int, error)
+	CancelParentOrder(ctx context.Context, dto CancelParentOrderDTO) (int, error)
+	GetBalance(ctx context.Context) ([]api.BalanceFromBitFlyer, int, error)
+	GetCollateral(ctx context.Context) (api.CollateralFromBitFlyer, int, error)
+	GetCollateralHistory(ctx context.Context, p api.Pagination) ([]api.CollateralHistoryFromBitFlyer, int, error)
+	GetPositions(ctx context.Context, productCode string) (PositionSummary, int, error)
+	GetTradingCommission(ctx context.Context, productCode string) (api.TradingCommissionFromBitFlyer, int, error)
+	GetOrderFills(ctx context.Context, productCode string, p api.Pagination, pages int) (OrderFillsReport, int, error)
+	GetFundingMovements(ctx context.Context, currencyCode string, p api.Pagination, pages int) (FundingMovements, int, error)
+	GetRateLimitBudget() ([]api.RateLimitBudget, int, error)
+	GetKillSwitch() (KillSwitchState, int, error)
+	SetKillSwitch(ctx context.Context, dto KillSwitchDTO) (KillSwitchResult, int, error)
+	HandleKillSwitchCommand(ctx context.Context, text string) (string, bool)
+	GetOrderHistory(productCode, childOrderState string, count int) ([]repository.Order, int, error)
 }
 
 type BuyOrderDTO struct {
@@ -24,6 +52,10 @@ type BuyOrderDTO struct {
 	MinuteToExpire MinuteToExpire `json:"minute_to_expire"`
 	TimeInForce    TimeInForce    `json:"time_in_force"`
 	IsDry          bool           `json:"is_dry"`
+	// trueの場合は価格と数量を商品の刻みに合わせて丸める。falseなら刻みに合わない注文は拒否する
+	AutoRound bool `json:"auto_round"`
+	// 指定した場合は同じ値で再送された発注を重複として扱い、前回の結果を返す。Idempotency-Keyヘッダーでも指定できる
+	ClientOrderID string `json:"client_order_id"`
 }
 
 type SellOrderDTO struct {
@@ -34,37 +66,176 @@ type SellOrderDTO struct {
 	MinuteToExpire MinuteToExpire `json:"minute_to_expire"`
 	TimeInForce    TimeInForce    `json:"time_in_force"`
 	IsDry          bool           `json:"is_dry"`
+	// trueの場合は価格と数量を商品の刻みに合わせて丸める。falseなら刻みに合わない注文は拒否する
+	AutoRound bool `json:"auto_round"`
+	// 指定した場合は同じ値で再送された発注を重複として扱い、前回の結果を返す。Idempotency-Keyヘッダーでも指定できる
+	ClientOrderID string `json:"client_order_id"`
+}
+
+// ChildOrderIDとChildOrderAcceptanceIDはどちらか一方を指定する
+type CancelOrderDTO struct {
+	ProductCode            ProductCode `json:"product_code"`
+	ChildOrderID           string      `json:"child_order_id"`
+	ChildOrderAcceptanceID string      `json:"child_order_acceptance_id"`
+	IsDry                  bool        `json:"is_dry"`
+}
+
+type CancelAllOrdersDTO struct {
+	ProductCode ProductCode `json:"product_code"`
+	IsDry       bool        `json:"is_dry"`
+}
+
+type ExecutionsPage struct {
+	Executions []api.ExecutionFromBitFlyer `json:"executions"`
+	NextBefore int64                       `json:"next_before"`
+	HasNext    bool                        `json:"has_next"`
 }
 
 type BitFlyerUsecase struct {
 	Config      config.Config
 	BitFlyerAPI api.IBitFlyerAPI
+	// nilの場合は発注を記録しない
+	OrderRepository repository.IOrderRepository
+	// nilの場合はIdempotency-Key付きの発注を受け付けない
+	Idempotency *IdempotencyStore
+	// nilの場合は発注を止めず、切り替えも受け付けない
+	KillSwitch *KillSwitch
+	// nilの場合は日次損失を毎回計算する
+	dailyPnL *dailyPnLCache
+}
+
+// BitFlyerUsecaseOptions は起動時に開いた保存先を渡す。HTTPとLINEのハンドラーで同じものを共有する
+type BitFlyerUsecaseOptions struct {
+	OrderRepository repository.IOrderRepository
+	Idempotency     *IdempotencyStore
+	KillSwitch      *KillSwitch
 }
 
-func NewBitFlyerUsecase(cfg config.Config) IBitFlyerUsecase {
+func NewBitFlyerUsecase(cfg config.Config, opts BitFlyerUsecaseOptions) IBitFlyerUsecase {
 	return &BitFlyerUsecase{
-		Config:      cfg,
-		BitFlyerAPI: api.NewBitFlyerAPI(cfg),
+		Config:          cfg,
+		BitFlyerAPI:     api.NewBitFlyerAPI(cfg),
+		OrderRepository: opts.OrderRepository,
+		Idempotency:     opts.Idempotency,
+		KillSwitch:      opts.KillSwitch,
+		dailyPnL:        newDailyPnLCache(dailyPnLCacheTTL),
 	}
 }
 
-func (b *BitFlyerUsecase) GetTicker(productCode string) (api.TickerFromBitFlyer, int, error) {
+func (b *BitFlyerUsecase) GetMarkets() (MarketList, int, error) {
+	return defaultMarketRegistry.Markets(), http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetTicker(ctx context.Context, productCode string) (api.TickerFromBitFlyer, int, error) {
 	pc, err := NewProductCode(productCode)
 	if err != nil {
 		return api.TickerFromBitFlyer{}, http.StatusBadRequest, err
 	}
 
-	res, err := b.BitFlyerAPI.GetTicker(string(pc))
+	res, err := b.BitFlyerAPI.GetTicker(ctx, string(pc))
 	if err != nil {
-		return api.TickerFromBitFlyer{}, http.StatusInternalServerError, err
+		return api.TickerFromBitFlyer{}, statusFromError(err), err
 	}
 
 	return res, http.StatusOK, nil
 }
 
-func (b *BitFlyerUsecase) BuyOrder(dto BuyOrderDTO) (api.SendChildOrderResponse, int, error) {
+// depthが0の場合は板を全件返す
+func (b *BitFlyerUsecase) GetBoard(ctx context.Context, productCode string, depth int) (api.BoardFromBitFlyer, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return api.BoardFromBitFlyer{}, http.StatusBadRequest, err
+	}
+
+	if depth < 0 {
+		return api.BoardFromBitFlyer{}, http.StatusBadRequest, errors.New("depth must be greater than or equal to 0")
+	}
+
+	res, err := b.BitFlyerAPI.GetBoard(ctx, string(pc))
+	if err != nil {
+		return api.BoardFromBitFlyer{}, statusFromError(err), err
+	}
+
+	return truncateBoard(res, depth), http.StatusOK, nil
+}
+
+func truncateBoard(board api.BoardFromBitFlyer, depth int) api.BoardFromBitFlyer {
+	if depth == 0 {
+		return board
+	}
+
+	if len(board.Bids) > depth {
+		board.Bids = board.Bids[:depth]
+	}
+	if len(board.Asks) > depth {
+		board.Asks = board.Asks[:depth]
+	}
+	return board
+}
+
+// pagesの数だけbeforeを更新しながら過去方向にページを取得する
+func (b *BitFlyerUsecase) GetExecutions(ctx context.Context, productCode string, p api.Pagination, pages int) (ExecutionsPage, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return ExecutionsPage{}, http.StatusBadRequest, err
+	}
+
+	if err := validatePagination(p, pages); err != nil {
+		return ExecutionsPage{}, http.StatusBadRequest, err
+	}
+
+	it := api.NewExecutionIterator(ctx, b.BitFlyerAPI, string(pc), p)
+	executions := []api.ExecutionFromBitFlyer{}
+	for i := 0; i < pages && it.HasNext(); i++ {
+		page, err := it.Next()
+		if err != nil {
+			return ExecutionsPage{}, statusFromError(err), err
+		}
+		executions = append(executions, page...)
+	}
+
+	return ExecutionsPage{
+		Executions: executions,
+		NextBefore: it.Before(),
+		HasNext:    it.HasNext(),
+	}, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) BuyOrder(ctx context.Context, dto BuyOrderDTO) (api.SendChildOrderResponse, int, error) {
+	return b.submitChildOrder(ctx, dto.ClientOrderID, "buy", dto, dto.IsDry, func() (api.SendChildOrderRequest, int, error) {
+		return b.prepareBuyOrder(ctx, dto)
+	})
+}
+
+// 発注前のチェックをすべて通した場合に、取引所へ送る注文を返す
+func (b *BitFlyerUsecase) prepareBuyOrder(ctx context.Context, dto BuyOrderDTO) (api.SendChildOrderRequest, int, error) {
+	if statusCode, err := b.checkKillSwitch("buy", dto, dto.IsDry); err != nil {
+		return api.SendChildOrderRequest{}, statusCode, err
+	}
+
 	if err := validateBuyOrSellOrder(dto); err != nil {
-		return api.SendChildOrderResponse{}, http.StatusBadRequest, err
+		return api.SendChildOrderRequest{}, http.StatusBadRequest, err
+	}
+
+	price, size, err := b.applyTradingRule(dto.ProductCode, consts.SideBuy, dto.ChildOrderType, dto.Price, dto.Size, dto.AutoRound)
+	if err != nil {
+		return api.SendChildOrderRequest{}, http.StatusBadRequest, err
+	}
+	dto.Price, dto.Size = price, size
+
+	if !dto.IsDry {
+		if statusCode, err := b.checkOrderGate(ctx, dto.ProductCode); err != nil {
+			return api.SendChildOrderRequest{}, statusCode, err
+		}
+		if statusCode, err := b.checkRisk(ctx, riskOrder{
+			ProductCode:    dto.ProductCode,
+			Side:           consts.SideBuy,
+			ChildOrderType: dto.ChildOrderType,
+			Price:          dto.Price,
+			Size:           dto.Size,
+		}); err != nil {
+			return api.SendChildOrderRequest{}, statusCode, err
+		}
 	}
 
 	args := api.SendChildOrderRequest{
@@ -77,17 +248,44 @@ func (b *BitFlyerUsecase) BuyOrder(dto BuyOrderDTO) (api.SendChildOrderResponse,
 		TimeInForce:    string(dto.TimeInForce),
 	}
 
-	res, err := b.BitFlyerAPI.SendChildOrder(args, dto.IsDry)
-	if err != nil {
-		return api.SendChildOrderResponse{}, http.StatusInternalServerError, err
-	}
+	return args, http.StatusOK, nil
+}
 
-	return res, http.StatusOK, nil
+func (b *BitFlyerUsecase) SellOrder(ctx context.Context, dto SellOrderDTO) (api.SendChildOrderResponse, int, error) {
+	return b.submitChildOrder(ctx, dto.ClientOrderID, "sell", dto, dto.IsDry, func() (api.SendChildOrderRequest, int, error) {
+		return b.prepareSellOrder(ctx, dto)
+	})
 }
 
-func (b *BitFlyerUsecase) SellOrder(dto SellOrderDTO) (api.SendChildOrderResponse, int, error) {
+// 発注前のチェックをすべて通した場合に、取引所へ送る注文を返す
+func (b *BitFlyerUsecase) prepareSellOrder(ctx context.Context, dto SellOrderDTO) (api.SendChildOrderRequest, int, error) {
+	if statusCode, err := b.checkKillSwitch("sell", dto, dto.IsDry); err != nil {
+		return api.SendChildOrderRequest{}, statusCode, err
+	}
+
 	if err := validateBuyOrSellOrder(dto); err != nil {
-		return api.SendChildOrderResponse{}, http.StatusBadRequest, err
+		return api.SendChildOrderRequest{}, http.StatusBadRequest, err
+	}
+
+	price, size, err := b.applyTradingRule(dto.ProductCode, consts.SideSell, dto.ChildOrderType, dto.Price, dto.Size, dto.AutoRound)
+	if err != nil {
+		return api.SendChildOrderRequest{}, http.StatusBadRequest, err
+	}
+	dto.Price, dto.Size = price, size
+
+	if !dto.IsDry {
+		if statusCode, err := b.checkOrderGate(ctx, dto.ProductCode); err != nil {
+			return api.SendChildOrderRequest{}, statusCode, err
+		}
+		if statusCode, err := b.checkRisk(ctx, riskOrder{
+			ProductCode:    dto.ProductCode,
+			Side:           consts.SideSell,
+			ChildOrderType: dto.ChildOrderType,
+			Price:          dto.Price,
+			Size:           dto.Size,
+		}); err != nil {
+			return api.SendChildOrderRequest{}, statusCode, err
+		}
 	}
 
 	args := api.SendChildOrderRequest{
@@ -100,14 +298,157 @@ func (b *BitFlyerUsecase) SellOrder(dto SellOrderDTO) (api.SendChildOrderRespons
 		TimeInForce:    string(dto.TimeInForce),
 	}
 
-	res, err := b.BitFlyerAPI.SendChildOrder(args, dto.IsDry)
+	return args, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) sendChildOrder(ctx context.Context, args api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, int, error) {
+	var res api.SendChildOrderResponse
+	send := func() error {
+		var err error
+		res, err = b.BitFlyerAPI.SendChildOrder(ctx, args, isDry)
+		return err
+	}
+
+	// ドライランは板と手数料率を取得するだけで取引所に送らないため、キルスイッチの切り替えを待たせない
+	if isDry {
+		if err := send(); err != nil {
+			return api.SendChildOrderResponse{}, statusFromError(err), err
+		}
+	} else if statusCode, err := b.sendUnlessKillSwitch(strings.ToLower(args.Side), args, send); err != nil {
+		return api.SendChildOrderResponse{}, statusCode, err
+	}
+	b.recordOrder(args, res, isDry)
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) CancelOrder(ctx context.Context, dto CancelOrderDTO) (int, error) {
+	if err := validateCancelOrder(dto); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	args := api.CancelChildOrderRequest{
+		ProductCode:            string(dto.ProductCode),
+		ChildOrderID:           dto.ChildOrderID,
+		ChildOrderAcceptanceID: dto.ChildOrderAcceptanceID,
+	}
+
+	if err := b.BitFlyerAPI.CancelChildOrder(ctx, args, dto.IsDry); err != nil {
+		return statusFromError(err), err
+	}
+
+	return http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) CancelAllOrders(ctx context.Context, dto CancelAllOrdersDTO) (int, error) {
+	if err := dto.ProductCode.validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	args := api.CancelAllChildOrdersRequest{
+		ProductCode: string(dto.ProductCode),
+	}
+
+	if err := b.BitFlyerAPI.CancelAllChildOrders(ctx, args, dto.IsDry); err != nil {
+		return statusFromError(err), err
+	}
+
+	return http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetChildOrders(ctx context.Context, productCode, childOrderState string, p api.Pagination) ([]api.ChildOrderFromBitFlyer, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	state := ChildOrderState(childOrderState)
+	if err := state.validate(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if err := validatePagination(p, 1); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	res, err := b.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+		ProductCode:     string(pc),
+		ChildOrderState: string(state),
+		Pagination:      p,
+	})
+	if err != nil {
+		return nil, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetChildOrder(ctx context.Context, productCode, acceptanceID string) (api.ChildOrderFromBitFlyer, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return api.ChildOrderFromBitFlyer{}, http.StatusBadRequest, err
+	}
+
+	if acceptanceID == "" {
+		return api.ChildOrderFromBitFlyer{}, http.StatusBadRequest, errors.New("child order acceptance id is empty")
+	}
+
+	res, err := b.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+		ProductCode:            string(pc),
+		ChildOrderAcceptanceID: acceptanceID,
+	})
+	if err != nil {
+		return api.ChildOrderFromBitFlyer{}, statusFromError(err), err
+	}
+
+	if len(res) == 0 {
+		return api.ChildOrderFromBitFlyer{}, http.StatusNotFound, fmt.Errorf("child order not found: %s", acceptanceID)
+	}
+
+	return res[0], http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetBalance(ctx context.Context) ([]api.BalanceFromBitFlyer, int, error) {
+	res, err := b.BitFlyerAPI.GetBalance(ctx)
+	if err != nil {
+		return nil, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetCollateral(ctx context.Context) (api.CollateralFromBitFlyer, int, error) {
+	res, err := b.BitFlyerAPI.GetCollateral(ctx)
+	if err != nil {
+		return api.CollateralFromBitFlyer{}, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetCollateralHistory(ctx context.Context, p api.Pagination) ([]api.CollateralHistoryFromBitFlyer, int, error) {
+	if err := validatePagination(p, 1); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	res, err := b.BitFlyerAPI.GetCollateralHistory(ctx, p)
 	if err != nil {
-		return api.SendChildOrderResponse{}, http.StatusInternalServerError, err
+		return nil, statusFromError(err), err
 	}
 
 	return res, http.StatusOK, nil
 }
 
+// bitFlyer APIのレート制限の残りをエンドポイントの種類ごとに返す
+func (b *BitFlyerUsecase) GetRateLimitBudget() ([]api.RateLimitBudget, int, error) {
+	return b.BitFlyerAPI.GetRateLimitBudget(), http.StatusOK, nil
+}
+
+// 商品の取引ルールで価格と数量を検証し、必要なら丸めた値を返す
+func (b *BitFlyerUsecase) applyTradingRule(productCode ProductCode, side string, orderType ChildOrderType, price, size float64, autoRound bool) (float64, float64, error) {
+	return tradingRuleFor(b.Config, productCode).apply(side, orderType, price, size, autoRound)
+}
+
 func validateBuyOrSellOrder(dto any) error {
 	switch v := dto.(type) {
 	case BuyOrderDTO:
@@ -148,16 +489,42 @@ func validateBuyOrSellOrder(dto any) error {
 	return nil
 }
 
+func validateCancelOrder(dto CancelOrderDTO) error {
+	if err := dto.ProductCode.validate(); err != nil {
+		return err
+	}
+	if dto.ChildOrderID == "" && dto.ChildOrderAcceptanceID == "" {
+		return errors.New("child order id or child order acceptance id is required")
+	}
+	if dto.ChildOrderID != "" && dto.ChildOrderAcceptanceID != "" {
+		return errors.New("specify either child order id or child order acceptance id, not both")
+	}
+	return nil
+}
+
+func validatePagination(p api.Pagination, pages int) error {
+	if p.Count < 0 || p.Count > consts.MaxPaginationCount {
+		return fmt.Errorf("count must be between 0 and %d", consts.MaxPaginationCount)
+	}
+	if p.Before < 0 || p.After < 0 {
+		return errors.New("before and after must be greater than or equal to 0")
+	}
+	if p.Before > 0 && p.After > 0 && p.Before <= p.After {
+		return errors.New("before must be greater than after")
+	}
+	if pages < 1 || pages > consts.MaxPaginationPages {
+		return fmt.Errorf("pages must be between 1 and %d", consts.MaxPaginationPages)
+	}
+	return nil
+}
+
 type ProductCode string
 
 func (p ProductCode) validate() error {
-	switch p {
-	case consts.ProductCodeBTCJPY, consts.ProductCodeXRPJPY, consts.ProductCodeETHJPY, consts.ProductCodeXLMJPY, consts.ProductCodeMONAJPY,
-		consts.ProductCodeETHBTC, consts.ProductCodeBCHBTC, consts.ProductCodeFXBTCJPY:
-		return nil
-	default:
+	if !defaultMarketRegistry.Contains(string(p)) {
 		return fmt.Errorf("invalid product code: %s", p)
 	}
+	return nil
 }
 
 func NewProductCode(code string) (ProductCode, error) {
@@ -179,6 +546,19 @@ func (c ChildOrderType) validate() error {
 	}
 }
 
+// 空文字の場合は全ての状態を対象とする
+type ChildOrderState string
+
+func (c ChildOrderState) validate() error {
+	switch c {
+	case "", consts.ChildOrderStateActive, consts.ChildOrderStateCompleted, consts.ChildOrderStateCanceled,
+		consts.ChildOrderStateExpired, consts.ChildOrderStateRejected:
+		return nil
+	default:
+		return errors.New("invalid child order state")
+	}
+}
+
 type TimeInForce string
 
 func (t TimeInForce) validate() error {
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"reflect"
 	"testing"
@@ -8,11 +10,229 @@ import (
 	"bitcoin-app-golang/api"
 	"bitcoin-app-golang/config"
 	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
 )
 
+// MockBitFlyerAPI はテスト用のBitFlyerAPIモック
+type MockBitFlyerAPI struct {
+	api.IBitFlyerAPI
+
+	GetMarketsFunc           func() ([]api.MarketFromBitFlyer, error)
+	GetTickerFunc            func(productCode string) (api.TickerFromBitFlyer, error)
+	GetFundingRateFunc       func(productCode string) (api.FundingRateFromBitFlyer, error)
+	GetBoardFunc             func(productCode string) (api.BoardFromBitFlyer, error)
+	GetExecutionsFunc        func(productCode string, p api.Pagination) ([]api.ExecutionFromBitFlyer, error)
+	GetHealthFunc            func(productCode string) (api.HealthFromBitFlyer, error)
+	GetBoardStateFunc        func(productCode string) (api.BoardStateFromBitFlyer, error)
+	GetBalanceFunc           func() ([]api.BalanceFromBitFlyer, error)
+	GetCollateralFunc        func() (api.CollateralFromBitFlyer, error)
+	GetCollateralHistoryFunc func(p api.Pagination) ([]api.CollateralHistoryFromBitFlyer, error)
+	GetChildOrdersFunc       func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error)
+	CancelChildOrderFunc     func(req api.CancelChildOrderRequest, isDry bool) error
+	CancelAllChildOrdersFunc func(req api.CancelAllChildOrdersRequest, isDry bool) error
+	SendParentOrderFunc      func(req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error)
+	GetParentOrdersFunc      func(req api.GetParentOrdersRequest) ([]api.ParentOrderFromBitFlyer, error)
+	GetParentOrderFunc       func(req api.GetParentOrderRequest) (api.ParentOrderDetailFromBitFlyer, error)
+	CancelParentOrderFunc    func(req api.CancelParentOrderRequest, isDry bool) error
+	GetPositionsFunc         func(productCode string) ([]api.PositionFromBitFlyer, error)
+	GetMyExecutionsFunc      func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error)
+	GetTradingCommissionFunc func(productCode string) (api.TradingCommissionFromBitFlyer, error)
+	GetCoinInsFunc           func(p api.Pagination) ([]api.CoinInFromBitFlyer, error)
+	GetCoinOutsFunc          func(p api.Pagination) ([]api.CoinOutFromBitFlyer, error)
+	GetDepositsFunc          func(p api.Pagination) ([]api.DepositFromBitFlyer, error)
+	GetWithdrawalsFunc       func(p api.Pagination) ([]api.WithdrawalFromBitFlyer, error)
+	SendChildOrderFunc       func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error)
+	GetRateLimitBudgetFunc   func() []api.RateLimitBudget
+}
+
+func (m *MockBitFlyerAPI) GetMarkets(_ context.Context) ([]api.MarketFromBitFlyer, error) {
+	if m.GetMarketsFunc != nil {
+		return m.GetMarketsFunc()
+	}
+	return []api.MarketFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetTicker(_ context.Context, productCode string) (api.TickerFromBitFlyer, error) {
+	if m.GetTickerFunc != nil {
+		return m.GetTickerFunc(productCode)
+	}
+	return api.TickerFromBitFlyer{ProductCode: productCode}, nil
+}
+
+func (m *MockBitFlyerAPI) GetFundingRate(_ context.Context, productCode string) (api.FundingRateFromBitFlyer, error) {
+	if m.GetFundingRateFunc != nil {
+		return m.GetFundingRateFunc(productCode)
+	}
+	return api.FundingRateFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetBoard(_ context.Context, productCode string) (api.BoardFromBitFlyer, error) {
+	if m.GetBoardFunc != nil {
+		return m.GetBoardFunc(productCode)
+	}
+	return api.BoardFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetExecutions(_ context.Context, productCode string, p api.Pagination) ([]api.ExecutionFromBitFlyer, error) {
+	if m.GetExecutionsFunc != nil {
+		return m.GetExecutionsFunc(productCode, p)
+	}
+	return []api.ExecutionFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetHealth(_ context.Context, productCode string) (api.HealthFromBitFlyer, error) {
+	if m.GetHealthFunc != nil {
+		return m.GetHealthFunc(productCode)
+	}
+	return api.HealthFromBitFlyer{Status: consts.HealthNormal}, nil
+}
+
+func (m *MockBitFlyerAPI) GetBoardState(_ context.Context, productCode string) (api.BoardStateFromBitFlyer, error) {
+	if m.GetBoardStateFunc != nil {
+		return m.GetBoardStateFunc(productCode)
+	}
+	return api.BoardStateFromBitFlyer{Health: consts.HealthNormal, State: consts.BoardStateRunning}, nil
+}
+
+func (m *MockBitFlyerAPI) GetBalance(_ context.Context) ([]api.BalanceFromBitFlyer, error) {
+	if m.GetBalanceFunc != nil {
+		return m.GetBalanceFunc()
+	}
+	return []api.BalanceFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetCollateral(_ context.Context) (api.CollateralFromBitFlyer, error) {
+	if m.GetCollateralFunc != nil {
+		return m.GetCollateralFunc()
+	}
+	return api.CollateralFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetCollateralHistory(_ context.Context, p api.Pagination) ([]api.CollateralHistoryFromBitFlyer, error) {
+	if m.GetCollateralHistoryFunc != nil {
+		return m.GetCollateralHistoryFunc(p)
+	}
+	return []api.CollateralHistoryFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetChildOrders(_ context.Context, req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+	if m.GetChildOrdersFunc != nil {
+		return m.GetChildOrdersFunc(req)
+	}
+	return []api.ChildOrderFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) CancelChildOrder(_ context.Context, req api.CancelChildOrderRequest, isDry bool) error {
+	if m.CancelChildOrderFunc != nil {
+		return m.CancelChildOrderFunc(req, isDry)
+	}
+	return nil
+}
+
+func (m *MockBitFlyerAPI) CancelAllChildOrders(_ context.Context, req api.CancelAllChildOrdersRequest, isDry bool) error {
+	if m.CancelAllChildOrdersFunc != nil {
+		return m.CancelAllChildOrdersFunc(req, isDry)
+	}
+	return nil
+}
+
+func (m *MockBitFlyerAPI) SendParentOrder(_ context.Context, req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error) {
+	if m.SendParentOrderFunc != nil {
+		return m.SendParentOrderFunc(req, isDry)
+	}
+	return api.SendParentOrderResponse{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetParentOrders(_ context.Context, req api.GetParentOrdersRequest) ([]api.ParentOrderFromBitFlyer, error) {
+	if m.GetParentOrdersFunc != nil {
+		return m.GetParentOrdersFunc(req)
+	}
+	return []api.ParentOrderFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetParentOrder(_ context.Context, req api.GetParentOrderRequest) (api.ParentOrderDetailFromBitFlyer, error) {
+	if m.GetParentOrderFunc != nil {
+		return m.GetParentOrderFunc(req)
+	}
+	return api.ParentOrderDetailFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) CancelParentOrder(_ context.Context, req api.CancelParentOrderRequest, isDry bool) error {
+	if m.CancelParentOrderFunc != nil {
+		return m.CancelParentOrderFunc(req, isDry)
+	}
+	return nil
+}
+
+func (m *MockBitFlyerAPI) GetPositions(_ context.Context, productCode string) ([]api.PositionFromBitFlyer, error) {
+	if m.GetPositionsFunc != nil {
+		return m.GetPositionsFunc(productCode)
+	}
+	return []api.PositionFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetMyExecutions(_ context.Context, req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+	if m.GetMyExecutionsFunc != nil {
+		return m.GetMyExecutionsFunc(req)
+	}
+	return []api.MyExecutionFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetTradingCommission(_ context.Context, productCode string) (api.TradingCommissionFromBitFlyer, error) {
+	if m.GetTradingCommissionFunc != nil {
+		return m.GetTradingCommissionFunc(productCode)
+	}
+	return api.TradingCommissionFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetCoinIns(_ context.Context, p api.Pagination) ([]api.CoinInFromBitFlyer, error) {
+	if m.GetCoinInsFunc != nil {
+		return m.GetCoinInsFunc(p)
+	}
+	return []api.CoinInFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetCoinOuts(_ context.Context, p api.Pagination) ([]api.CoinOutFromBitFlyer, error) {
+	if m.GetCoinOutsFunc != nil {
+		return m.GetCoinOutsFunc(p)
+	}
+	return []api.CoinOutFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetDeposits(_ context.Context, p api.Pagination) ([]api.DepositFromBitFlyer, error) {
+	if m.GetDepositsFunc != nil {
+		return m.GetDepositsFunc(p)
+	}
+	return []api.DepositFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetWithdrawals(_ context.Context, p api.Pagination) ([]api.WithdrawalFromBitFlyer, error) {
+	if m.GetWithdrawalsFunc != nil {
+		return m.GetWithdrawalsFunc(p)
+	}
+	return []api.WithdrawalFromBitFlyer{}, nil
+}
+
+func (m *MockBitFlyerAPI) SendChildOrder(_ context.Context, req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+	if m.SendChildOrderFunc != nil {
+		return m.SendChildOrderFunc(req, isDry)
+	}
+	return api.SendChildOrderResponse{}, nil
+}
+
+func (m *MockBitFlyerAPI) GetRateLimitBudget() []api.RateLimitBudget {
+	if m.GetRateLimitBudgetFunc != nil {
+		return m.GetRateLimitBudgetFunc()
+	}
+	return nil
+}
+
 func TestNewBitFlyerUsecase(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+
 	type args struct {
-		cfg config.Config
+		cfg  config.Config
+		opts BitFlyerUsecaseOptions
 	}
 	tests := []struct {
 		name string
@@ -22,17 +242,20 @@ func TestNewBitFlyerUsecase(t *testing.T) {
 		{
 			name: "success",
 			args: args{
-				cfg: TestConfig,
+				cfg:  TestConfig,
+				opts: BitFlyerUsecaseOptions{OrderRepository: orders},
 			},
 			want: &BitFlyerUsecase{
-				Config:      TestConfig,
-				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+				Config:          TestConfig,
+				BitFlyerAPI:     api.NewBitFlyerAPI(TestConfig),
+				OrderRepository: orders,
+				dailyPnL:        newDailyPnLCache(dailyPnLCacheTTL),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := NewBitFlyerUsecase(tt.args.cfg); !reflect.DeepEqual(got, tt.want) {
+			if got := NewBitFlyerUsecase(tt.args.cfg, tt.args.opts); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("NewBitFlyerUsecase() = %v, want %v", got, tt.want)
 			}
 		})
@@ -107,7 +330,7 @@ func TestBitFlyerUsecase_GetTicker(t *testing.T) {
 				Config:      tt.fields.Config,
 				BitFlyerAPI: tt.fields.BitFlyerAPI,
 			}
-			got, got1, err := b.GetTicker(tt.args.productCode)
+			got, got1, err := b.GetTicker(context.Background(), tt.args.productCode)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUsecase.GetTicker() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -122,6 +345,232 @@ func TestBitFlyerUsecase_GetTicker(t *testing.T) {
 	}
 }
 
+func TestBitFlyerUsecase_GetBoard(t *testing.T) {
+	mockBoard := api.BoardFromBitFlyer{
+		MidPrice: 5000000,
+		Bids: []api.BoardOrder{
+			{Price: 4999000, Size: 0.1},
+			{Price: 4998000, Size: 0.2},
+			{Price: 4997000, Size: 0.3},
+		},
+		Asks: []api.BoardOrder{
+			{Price: 5001000, Size: 0.1},
+			{Price: 5002000, Size: 0.2},
+		},
+	}
+
+	type args struct {
+		productCode string
+		depth       int
+	}
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		args        args
+		want        api.BoardFromBitFlyer
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success - depth 0 returns all",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBoardFunc: func(productCode string) (api.BoardFromBitFlyer, error) {
+					return mockBoard, nil
+				},
+			},
+			args:    args{productCode: consts.ProductCodeBTCJPY, depth: 0},
+			want:    mockBoard,
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "success - truncated by depth",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBoardFunc: func(productCode string) (api.BoardFromBitFlyer, error) {
+					return mockBoard, nil
+				},
+			},
+			args: args{productCode: consts.ProductCodeBTCJPY, depth: 1},
+			want: api.BoardFromBitFlyer{
+				MidPrice: 5000000,
+				Bids:     []api.BoardOrder{{Price: 4999000, Size: 0.1}},
+				Asks:     []api.BoardOrder{{Price: 5001000, Size: 0.1}},
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: "invalid", depth: 0},
+			want:        api.BoardFromBitFlyer{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name:        "negative depth",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: consts.ProductCodeBTCJPY, depth: -1},
+			want:        api.BoardFromBitFlyer{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBoardFunc: func(productCode string) (api.BoardFromBitFlyer, error) {
+					return api.BoardFromBitFlyer{}, errors.New("api error")
+				},
+			},
+			args:    args{productCode: consts.ProductCodeBTCJPY, depth: 0},
+			want:    api.BoardFromBitFlyer{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config:      TestConfig,
+				BitFlyerAPI: tt.bitFlyerAPI,
+			}
+			got, got1, err := b.GetBoard(context.Background(), tt.args.productCode, tt.args.depth)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetBoard() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetBoard() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetBoard() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_GetExecutions(t *testing.T) {
+	// beforeより小さいidを2件ずつ返すモック
+	pagedExecutions := func(productCode string, p api.Pagination) ([]api.ExecutionFromBitFlyer, error) {
+		before := p.Before
+		if before == 0 {
+			before = 6
+		}
+		page := []api.ExecutionFromBitFlyer{}
+		for id := before - 1; id > 0 && len(page) < p.Count; id-- {
+			page = append(page, api.ExecutionFromBitFlyer{ID: id})
+		}
+		return page, nil
+	}
+
+	type args struct {
+		productCode string
+		p           api.Pagination
+		pages       int
+	}
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		args        args
+		want        ExecutionsPage
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name:        "success - single page",
+			bitFlyerAPI: &MockBitFlyerAPI{GetExecutionsFunc: pagedExecutions},
+			args:        args{productCode: consts.ProductCodeBTCJPY, p: api.Pagination{Count: 2}, pages: 1},
+			want: ExecutionsPage{
+				Executions: []api.ExecutionFromBitFlyer{{ID: 5}, {ID: 4}},
+				NextBefore: 4,
+				HasNext:    true,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "success - walk backwards until the end",
+			bitFlyerAPI: &MockBitFlyerAPI{GetExecutionsFunc: pagedExecutions},
+			args:        args{productCode: consts.ProductCodeBTCJPY, p: api.Pagination{Count: 2}, pages: 5},
+			want: ExecutionsPage{
+				Executions: []api.ExecutionFromBitFlyer{{ID: 5}, {ID: 4}, {ID: 3}, {ID: 2}, {ID: 1}},
+				NextBefore: 1,
+				HasNext:    false,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: "invalid", pages: 1},
+			want:        ExecutionsPage{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid pages",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: consts.ProductCodeBTCJPY, pages: 0},
+			want:        ExecutionsPage{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name:        "before is less than after",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: consts.ProductCodeBTCJPY, p: api.Pagination{Before: 10, After: 20}, pages: 1},
+			want:        ExecutionsPage{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetExecutionsFunc: func(productCode string, p api.Pagination) ([]api.ExecutionFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			args:    args{productCode: consts.ProductCodeBTCJPY, pages: 1},
+			want:    ExecutionsPage{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config:      TestConfig,
+				BitFlyerAPI: tt.bitFlyerAPI,
+			}
+			got, got1, err := b.GetExecutions(context.Background(), tt.args.productCode, tt.args.p, tt.args.pages)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetExecutions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetExecutions() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetExecutions() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+// ドライランの見積もりはapiのテストで確認するため、ここでは発注がドライランのまま渡ることだけを確認する
+var (
+	dryRunResponse    = api.SendChildOrderResponse{ChildOrderAcceptanceID: "DRY20240101-000000-000001"}
+	dryRunBitFlyerAPI = &MockBitFlyerAPI{
+		SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+			if !isDry {
+				return api.SendChildOrderResponse{}, errors.New("order is not a dry run")
+			}
+			return dryRunResponse, nil
+		},
+	}
+)
+
 func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 	type fields struct {
 		Config      config.Config
@@ -143,7 +592,7 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 			name: "success - LIMIT order",
 			fields: fields{
 				Config:      TestConfig,
-				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+				BitFlyerAPI: dryRunBitFlyerAPI,
 			},
 			args: args{
 				dto: BuyOrderDTO{
@@ -156,7 +605,7 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 					IsDry:          true, // 注意: falseにすると実際の購入APIが実行されます
 				},
 			},
-			want:    api.SendChildOrderResponse{ChildOrderAcceptanceID: ""},
+			want:    dryRunResponse,
 			want1:   http.StatusOK,
 			wantErr: false,
 		},
@@ -164,7 +613,7 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 			name: "success - MARKET order",
 			fields: fields{
 				Config:      TestConfig,
-				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+				BitFlyerAPI: dryRunBitFlyerAPI,
 			},
 			args: args{
 				dto: BuyOrderDTO{
@@ -177,7 +626,7 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 					IsDry:          true, // 注意: falseにすると実際の購入APIが実行されます
 				},
 			},
-			want:    api.SendChildOrderResponse{ChildOrderAcceptanceID: ""},
+			want:    dryRunResponse,
 			want1:   http.StatusOK,
 			wantErr: false,
 		},
@@ -265,6 +714,27 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 			want1:   http.StatusBadRequest,
 			wantErr: true,
 		},
+		{
+			name: "size below minimum order size",
+			fields: fields{
+				Config:      TestConfig,
+				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+			},
+			args: args{
+				dto: BuyOrderDTO{
+					ProductCode:    consts.ProductCodeBTCJPY,
+					ChildOrderType: consts.ChildOrderTypeLimit,
+					Price:          1000000,
+					Size:           0.0001,
+					MinuteToExpire: 43200,
+					TimeInForce:    consts.TimeInForceGTC,
+					IsDry:          true, // 注意: falseにすると実際の購入APIが実行されます
+				},
+			},
+			want:    api.SendChildOrderResponse{},
+			want1:   http.StatusBadRequest,
+			wantErr: true,
+		},
 		{
 			name: "invalid price for LIMIT order",
 			fields: fields{
@@ -293,7 +763,7 @@ func TestBitFlyerUsecase_BuyOrder(t *testing.T) {
 				Config:      tt.fields.Config,
 				BitFlyerAPI: tt.fields.BitFlyerAPI,
 			}
-			got, got1, err := b.BuyOrder(tt.args.dto)
+			got, got1, err := b.BuyOrder(context.Background(), tt.args.dto)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUsecase.BuyOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -329,7 +799,7 @@ func TestBitFlyerUsecase_SellOrder(t *testing.T) {
 			name: "success - LIMIT order",
 			fields: fields{
 				Config:      TestConfig,
-				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+				BitFlyerAPI: dryRunBitFlyerAPI,
 			},
 			args: args{
 				dto: SellOrderDTO{
@@ -342,7 +812,7 @@ func TestBitFlyerUsecase_SellOrder(t *testing.T) {
 					IsDry:          true, // 注意: falseにすると実際の売却APIが実行されます
 				},
 			},
-			want:    api.SendChildOrderResponse{ChildOrderAcceptanceID: ""},
+			want:    dryRunResponse,
 			want1:   http.StatusOK,
 			wantErr: false,
 		},
@@ -350,7 +820,7 @@ func TestBitFlyerUsecase_SellOrder(t *testing.T) {
 			name: "success - MARKET order",
 			fields: fields{
 				Config:      TestConfig,
-				BitFlyerAPI: api.NewBitFlyerAPI(TestConfig),
+				BitFlyerAPI: dryRunBitFlyerAPI,
 			},
 			args: args{
 				dto: SellOrderDTO{
@@ -363,7 +833,7 @@ func TestBitFlyerUsecase_SellOrder(t *testing.T) {
 					IsDry:          true, // 注意: falseにすると実際の売却APIが実行されます
 				},
 			},
-			want:    api.SendChildOrderResponse{ChildOrderAcceptanceID: ""},
+			want:    dryRunResponse,
 			want1:   http.StatusOK,
 			wantErr: false,
 		},
@@ -437,7 +907,7 @@ func TestBitFlyerUsecase_SellOrder(t *testing.T) {
 				Config:      tt.fields.Config,
 				BitFlyerAPI: tt.fields.BitFlyerAPI,
 			}
-			got, got1, err := b.SellOrder(tt.args.dto)
+			got, got1, err := b.SellOrder(context.Background(), tt.args.dto)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BitFlyerUsecase.SellOrder() error = %v, wantErr %v", err, tt.wantErr)
 				return
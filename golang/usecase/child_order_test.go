@@ -0,0 +1,320 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_GetChildOrders(t *testing.T) {
+	orders := []api.ChildOrderFromBitFlyer{
+		{ID: 138398, ChildOrderID: "JOR20150707-084555-022523", ProductCode: consts.ProductCodeBTCJPY, ChildOrderState: consts.ChildOrderStateActive},
+	}
+
+	type args struct {
+		productCode     string
+		childOrderState string
+		p               api.Pagination
+	}
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		args        args
+		want        []api.ChildOrderFromBitFlyer
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+					if req.ChildOrderState != consts.ChildOrderStateActive || req.Count != 10 {
+						return nil, errors.New("unexpected request")
+					}
+					return orders, nil
+				},
+			},
+			args:    args{productCode: consts.ProductCodeBTCJPY, childOrderState: consts.ChildOrderStateActive, p: api.Pagination{Count: 10}},
+			want:    orders,
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid child order state",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: consts.ProductCodeBTCJPY, childOrderState: "INVALID"},
+			want:        nil,
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			args:        args{productCode: "invalid"},
+			want:        nil,
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			args:    args{productCode: consts.ProductCodeBTCJPY},
+			want:    nil,
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetChildOrders(context.Background(), tt.args.productCode, tt.args.childOrderState, tt.args.p)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetChildOrders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetChildOrders() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetChildOrders() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_GetChildOrder(t *testing.T) {
+	order := api.ChildOrderFromBitFlyer{
+		ID:                     138398,
+		ProductCode:            consts.ProductCodeBTCJPY,
+		ChildOrderAcceptanceID: "JRF20150707-084547-396699",
+		ChildOrderState:        consts.ChildOrderStateCompleted,
+	}
+
+	tests := []struct {
+		name         string
+		bitFlyerAPI  api.IBitFlyerAPI
+		acceptanceID string
+		want         api.ChildOrderFromBitFlyer
+		want1        int
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+					return []api.ChildOrderFromBitFlyer{order}, nil
+				},
+			},
+			acceptanceID: order.ChildOrderAcceptanceID,
+			want:         order,
+			want1:        http.StatusOK,
+			wantErr:      false,
+		},
+		{
+			name:         "not found",
+			bitFlyerAPI:  &MockBitFlyerAPI{},
+			acceptanceID: "JRF20150707-000000-000000",
+			want:         api.ChildOrderFromBitFlyer{},
+			want1:        http.StatusNotFound,
+			wantErr:      true,
+		},
+		{
+			name:         "empty acceptance id",
+			bitFlyerAPI:  &MockBitFlyerAPI{},
+			acceptanceID: "",
+			want:         api.ChildOrderFromBitFlyer{},
+			want1:        http.StatusBadRequest,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetChildOrder(context.Background(), consts.ProductCodeBTCJPY, tt.acceptanceID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetChildOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetChildOrder() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetChildOrder() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestChildOrderState_validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		c       ChildOrderState
+		wantErr bool
+	}{
+		{name: "empty", c: "", wantErr: false},
+		{name: "ACTIVE", c: consts.ChildOrderStateActive, wantErr: false},
+		{name: "COMPLETED", c: consts.ChildOrderStateCompleted, wantErr: false},
+		{name: "REJECTED", c: consts.ChildOrderStateRejected, wantErr: false},
+		{name: "invalid", c: "INVALID", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.c.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("ChildOrderState.validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_CancelOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		dto         CancelOrderDTO
+		want        int
+		wantErr     bool
+	}{
+		{
+			name: "success with child order id",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				CancelChildOrderFunc: func(req api.CancelChildOrderRequest, isDry bool) error {
+					if req.ChildOrderID != "JOR20150707-055555-022222" || req.ChildOrderAcceptanceID != "" {
+						return errors.New("unexpected request")
+					}
+					return nil
+				},
+			},
+			dto:     CancelOrderDTO{ProductCode: consts.ProductCodeBTCJPY, ChildOrderID: "JOR20150707-055555-022222"},
+			want:    http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "dry run is passed through",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				CancelChildOrderFunc: func(req api.CancelChildOrderRequest, isDry bool) error {
+					if !isDry {
+						return errors.New("isDry must be true")
+					}
+					return nil
+				},
+			},
+			dto:     CancelOrderDTO{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF20150707-033333-099999", IsDry: true},
+			want:    http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "no id",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			dto:         CancelOrderDTO{ProductCode: consts.ProductCodeBTCJPY},
+			want:        http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name:        "both ids",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			dto: CancelOrderDTO{
+				ProductCode:            consts.ProductCodeBTCJPY,
+				ChildOrderID:           "JOR20150707-055555-022222",
+				ChildOrderAcceptanceID: "JRF20150707-033333-099999",
+			},
+			want:    http.StatusBadRequest,
+			wantErr: true,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			dto:         CancelOrderDTO{ProductCode: "invalid", ChildOrderID: "JOR20150707-055555-022222"},
+			want:        http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				CancelChildOrderFunc: func(req api.CancelChildOrderRequest, isDry bool) error {
+					return errors.New("api error")
+				},
+			},
+			dto:     CancelOrderDTO{ProductCode: consts.ProductCodeBTCJPY, ChildOrderID: "JOR20150707-055555-022222"},
+			want:    http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, err := b.CancelOrder(context.Background(), tt.dto)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.CancelOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerUsecase.CancelOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_CancelAllOrders(t *testing.T) {
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		dto         CancelAllOrdersDTO
+		want        int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				CancelAllChildOrdersFunc: func(req api.CancelAllChildOrdersRequest, isDry bool) error {
+					if req.ProductCode != consts.ProductCodeBTCJPY || !isDry {
+						return errors.New("unexpected request")
+					}
+					return nil
+				},
+			},
+			dto:     CancelAllOrdersDTO{ProductCode: consts.ProductCodeBTCJPY, IsDry: true},
+			want:    http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			dto:         CancelAllOrdersDTO{ProductCode: "invalid"},
+			want:        http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				CancelAllChildOrdersFunc: func(req api.CancelAllChildOrdersRequest, isDry bool) error {
+					return errors.New("api error")
+				},
+			},
+			dto:     CancelAllOrdersDTO{ProductCode: consts.ProductCodeBTCJPY},
+			want:    http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, err := b.CancelAllOrders(context.Background(), tt.dto)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.CancelAllOrders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerUsecase.CancelAllOrders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+type OrderFill struct {
+	ChildOrderAcceptanceID string `json:"child_order_acceptance_id"`
+	ChildOrderID           string `json:"child_order_id"`
+	Side                   string `json:"side"`
+	// 取得範囲内に子注文が見つからない場合はnil
+	Order        *api.ChildOrderFromBitFlyer `json:"order"`
+	ExecutedSize float64                     `json:"executed_size"`
+	AveragePrice float64                     `json:"average_price"`
+	// 約定金額の合計(決済通貨建て)
+	Notional float64 `json:"notional"`
+	// 手数料の合計(約定した通貨建て)
+	Commission float64 `json:"commission"`
+	// 手数料を約定価格で決済通貨に換算した合計
+	CommissionQuote float64                       `json:"commission_quote"`
+	Executions      []api.MyExecutionFromBitFlyer `json:"executions"`
+}
+
+type OrderFillsReport struct {
+	ProductCode    string      `json:"product_code"`
+	CommissionRate float64     `json:"commission_rate"`
+	Fills          []OrderFill `json:"fills"`
+	NextBefore     int64       `json:"next_before"`
+	HasNext        bool        `json:"has_next"`
+}
+
+func (b *BitFlyerUsecase) GetTradingCommission(ctx context.Context, productCode string) (api.TradingCommissionFromBitFlyer, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return api.TradingCommissionFromBitFlyer{}, http.StatusBadRequest, err
+	}
+
+	res, err := b.BitFlyerAPI.GetTradingCommission(ctx, string(pc))
+	if err != nil {
+		return api.TradingCommissionFromBitFlyer{}, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+// 自分の約定をpagesの数だけ取得し、子注文ごとにまとめて手数料を集計する
+func (b *BitFlyerUsecase) GetOrderFills(ctx context.Context, productCode string, p api.Pagination, pages int) (OrderFillsReport, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return OrderFillsReport{}, http.StatusBadRequest, err
+	}
+
+	if err := validatePagination(p, pages); err != nil {
+		return OrderFillsReport{}, http.StatusBadRequest, err
+	}
+
+	commission, err := b.BitFlyerAPI.GetTradingCommission(ctx, string(pc))
+	if err != nil {
+		return OrderFillsReport{}, statusFromError(err), err
+	}
+
+	it := api.NewMyExecutionIterator(ctx, b.BitFlyerAPI, api.GetMyExecutionsRequest{
+		ProductCode: string(pc),
+		Pagination:  p,
+	})
+	executions := []api.MyExecutionFromBitFlyer{}
+	for i := 0; i < pages && it.HasNext(); i++ {
+		page, err := it.Next()
+		if err != nil {
+			return OrderFillsReport{}, statusFromError(err), err
+		}
+		executions = append(executions, page...)
+	}
+
+	fills := groupExecutionsByOrder(executions)
+
+	orders, err := b.findChildOrders(ctx, string(pc), fills, pages)
+	if err != nil {
+		return OrderFillsReport{}, statusFromError(err), err
+	}
+	for i := range fills {
+		if o, ok := orders[fills[i].ChildOrderAcceptanceID]; ok {
+			fills[i].Order = &o
+		}
+	}
+
+	return OrderFillsReport{
+		ProductCode:    string(pc),
+		CommissionRate: commission.CommissionRate,
+		Fills:          fills,
+		NextBefore:     it.Before(),
+		HasNext:        it.HasNext(),
+	}, http.StatusOK, nil
+}
+
+// 約定の新しい順を保ったまま子注文の受付IDごとにまとめる
+func groupExecutionsByOrder(executions []api.MyExecutionFromBitFlyer) []OrderFill {
+	fills := []OrderFill{}
+	index := map[string]int{}
+	for _, e := range executions {
+		i, ok := index[e.ChildOrderAcceptanceID]
+		if !ok {
+			i = len(fills)
+			index[e.ChildOrderAcceptanceID] = i
+			fills = append(fills, OrderFill{
+				ChildOrderAcceptanceID: e.ChildOrderAcceptanceID,
+				ChildOrderID:           e.ChildOrderID,
+				Side:                   e.Side,
+			})
+		}
+
+		f := &fills[i]
+		f.ExecutedSize += e.Size
+		f.Notional += e.Price * e.Size
+		f.Commission += e.Commission
+		f.CommissionQuote += e.Commission * e.Price
+		f.Executions = append(f.Executions, e)
+	}
+
+	for i := range fills {
+		if fills[i].ExecutedSize > 0 {
+			fills[i].AveragePrice = fills[i].Notional / fills[i].ExecutedSize
+		}
+	}
+	return fills
+}
+
+// 子注文一覧を新しい順にpagesの数だけ辿り、約定に対応する子注文を探す
+func (b *BitFlyerUsecase) findChildOrders(ctx context.Context, productCode string, fills []OrderFill, pages int) (map[string]api.ChildOrderFromBitFlyer, error) {
+	orders := map[string]api.ChildOrderFromBitFlyer{}
+	if len(fills) == 0 {
+		return orders, nil
+	}
+
+	wanted := make(map[string]bool, len(fills))
+	for _, f := range fills {
+		wanted[f.ChildOrderAcceptanceID] = true
+	}
+
+	it := api.NewChildOrderIterator(ctx, b.BitFlyerAPI, api.GetChildOrdersRequest{
+		ProductCode: productCode,
+		Pagination:  api.Pagination{Count: consts.MaxPaginationCount},
+	})
+	for i := 0; i < pages && it.HasNext() && len(orders) < len(wanted); i++ {
+		page, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range page {
+			if wanted[o.ChildOrderAcceptanceID] {
+				orders[o.ChildOrderAcceptanceID] = o
+			}
+		}
+	}
+	return orders, nil
+}
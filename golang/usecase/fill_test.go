@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_GetOrderFills(t *testing.T) {
+	executions := []api.MyExecutionFromBitFlyer{
+		{ID: 40, ChildOrderID: "JOR-2", Side: consts.SideSell, Price: 5100000, Size: 0.02, Commission: 0.00002, ChildOrderAcceptanceID: "JRF-2"},
+		{ID: 30, ChildOrderID: "JOR-1", Side: consts.SideBuy, Price: 5000000, Size: 0.01, Commission: 0.00001, ChildOrderAcceptanceID: "JRF-1"},
+		{ID: 20, ChildOrderID: "JOR-1", Side: consts.SideBuy, Price: 5020000, Size: 0.03, Commission: 0.00003, ChildOrderAcceptanceID: "JRF-1"},
+	}
+	order := api.ChildOrderFromBitFlyer{ID: 7, ChildOrderAcceptanceID: "JRF-1", ChildOrderState: consts.ChildOrderStateCompleted}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		productCode string
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetTradingCommissionFunc: func(productCode string) (api.TradingCommissionFromBitFlyer, error) {
+					return api.TradingCommissionFromBitFlyer{CommissionRate: 0.001}, nil
+				},
+				GetMyExecutionsFunc: func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+					return executions, nil
+				},
+				GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+					return []api.ChildOrderFromBitFlyer{order}, nil
+				},
+			},
+			productCode: consts.ProductCodeBTCJPY,
+			want1:       http.StatusOK,
+			wantErr:     false,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			productCode: "invalid",
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetMyExecutionsFunc: func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			productCode: consts.ProductCodeBTCJPY,
+			want1:       http.StatusInternalServerError,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetOrderFills(context.Background(), tt.productCode, api.Pagination{Count: 10}, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetOrderFills() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetOrderFills() got1 = %v, want %v", got1, tt.want1)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.CommissionRate != 0.001 || len(got.Fills) != 2 {
+				t.Fatalf("BitFlyerUsecase.GetOrderFills() = %+v", got)
+			}
+			if got.Fills[0].ChildOrderAcceptanceID != "JRF-2" || got.Fills[0].Order != nil {
+				t.Errorf("Fills[0] = %+v, want JRF-2 without order", got.Fills[0])
+			}
+			if got.Fills[1].Order == nil || got.Fills[1].Order.ID != order.ID {
+				t.Errorf("Fills[1].Order = %v, want %v", got.Fills[1].Order, order)
+			}
+		})
+	}
+}
+
+func Test_groupExecutionsByOrder(t *testing.T) {
+	executions := []api.MyExecutionFromBitFlyer{
+		{ID: 2, Side: consts.SideBuy, Price: 4000000, Size: 0.25, Commission: 0.0005, ChildOrderAcceptanceID: "JRF-1"},
+		{ID: 1, Side: consts.SideBuy, Price: 6000000, Size: 0.25, Commission: 0.0005, ChildOrderAcceptanceID: "JRF-1"},
+	}
+
+	got := groupExecutionsByOrder(executions)
+	if len(got) != 1 {
+		t.Fatalf("groupExecutionsByOrder() len = %v, want 1", len(got))
+	}
+
+	f := got[0]
+	if f.ExecutedSize != 0.5 || f.Notional != 2500000 || f.AveragePrice != 5000000 {
+		t.Errorf("groupExecutionsByOrder() size/notional/avg = %v/%v/%v", f.ExecutedSize, f.Notional, f.AveragePrice)
+	}
+	if f.Commission != 0.001 || f.CommissionQuote != 5000 {
+		t.Errorf("groupExecutionsByOrder() commission = %v, quote = %v", f.Commission, f.CommissionQuote)
+	}
+	if len(f.Executions) != 2 {
+		t.Errorf("groupExecutionsByOrder() executions = %v, want 2", len(f.Executions))
+	}
+}
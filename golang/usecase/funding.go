@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"bitcoin-app-golang/api"
+)
+
+const (
+	FundingMovementCoinIn     = "COIN_IN"
+	FundingMovementCoinOut    = "COIN_OUT"
+	FundingMovementDeposit    = "DEPOSIT"
+	FundingMovementWithdrawal = "WITHDRAWAL"
+)
+
+// bitFlyerのevent_dateはタイムゾーンなしのUTC
+const bitFlyerEventDateLayout = "2006-01-02T15:04:05"
+
+type FundingMovement struct {
+	Type         string `json:"type"`
+	ID           int64  `json:"id"`
+	OrderID      string `json:"order_id"`
+	CurrencyCode string `json:"currency_code"`
+	// 入金・預入は正、出金・送付は負
+	Amount float64 `json:"amount"`
+	// 送付時の手数料(fee + additional_fee)
+	Fee       float64 `json:"fee"`
+	Status    string  `json:"status"`
+	EventDate string  `json:"event_date"`
+	Address   string  `json:"address,omitempty"`
+	TxHash    string  `json:"tx_hash,omitempty"`
+}
+
+type FundingMovements struct {
+	Movements []FundingMovement `json:"movements"`
+	// いずれかの履歴にまだ取得していないページがある
+	HasNext bool `json:"has_next"`
+}
+
+// 入出金・送受付の履歴をそれぞれpagesの数だけ取得し、新しい順の1つの時系列にまとめる
+func (b *BitFlyerUsecase) GetFundingMovements(ctx context.Context, currencyCode string, p api.Pagination, pages int) (FundingMovements, int, error) {
+	if err := validatePagination(p, pages); err != nil {
+		return FundingMovements{}, http.StatusBadRequest, err
+	}
+	// before/afterのIDは履歴ごとに独立しているため、まとめた時系列には使えない
+	if p.Before != 0 || p.After != 0 {
+		return FundingMovements{}, http.StatusBadRequest, errors.New("before and after are not supported for funding movements")
+	}
+
+	movements := []FundingMovement{}
+	hasNext := false
+
+	coinIns, more, err := collectPages(api.NewCoinInIterator(ctx, b.BitFlyerAPI, p), pages)
+	if err != nil {
+		return FundingMovements{}, statusFromError(err), err
+	}
+	hasNext = hasNext || more
+	for _, c := range coinIns {
+		movements = append(movements, FundingMovement{
+			Type:         FundingMovementCoinIn,
+			ID:           c.ID,
+			OrderID:      c.OrderID,
+			CurrencyCode: c.CurrencyCode,
+			Amount:       c.Amount,
+			Status:       c.Status,
+			EventDate:    c.EventDate,
+			Address:      c.Address,
+			TxHash:       c.TxHash,
+		})
+	}
+
+	coinOuts, more, err := collectPages(api.NewCoinOutIterator(ctx, b.BitFlyerAPI, p), pages)
+	if err != nil {
+		return FundingMovements{}, statusFromError(err), err
+	}
+	hasNext = hasNext || more
+	for _, c := range coinOuts {
+		movements = append(movements, FundingMovement{
+			Type:         FundingMovementCoinOut,
+			ID:           c.ID,
+			OrderID:      c.OrderID,
+			CurrencyCode: c.CurrencyCode,
+			Amount:       -c.Amount,
+			Fee:          c.Fee + c.AdditionalFee,
+			Status:       c.Status,
+			EventDate:    c.EventDate,
+			Address:      c.Address,
+			TxHash:       c.TxHash,
+		})
+	}
+
+	deposits, more, err := collectPages(api.NewDepositIterator(ctx, b.BitFlyerAPI, p), pages)
+	if err != nil {
+		return FundingMovements{}, statusFromError(err), err
+	}
+	hasNext = hasNext || more
+	for _, d := range deposits {
+		movements = append(movements, FundingMovement{
+			Type:         FundingMovementDeposit,
+			ID:           d.ID,
+			OrderID:      d.OrderID,
+			CurrencyCode: d.CurrencyCode,
+			Amount:       d.Amount,
+			Status:       d.Status,
+			EventDate:    d.EventDate,
+		})
+	}
+
+	withdrawals, more, err := collectPages(api.NewWithdrawalIterator(ctx, b.BitFlyerAPI, p), pages)
+	if err != nil {
+		return FundingMovements{}, statusFromError(err), err
+	}
+	hasNext = hasNext || more
+	for _, w := range withdrawals {
+		movements = append(movements, FundingMovement{
+			Type:         FundingMovementWithdrawal,
+			ID:           w.ID,
+			OrderID:      w.OrderID,
+			CurrencyCode: w.CurrencyCode,
+			Amount:       -w.Amount,
+			Status:       w.Status,
+			EventDate:    w.EventDate,
+		})
+	}
+
+	if currencyCode != "" {
+		filtered := []FundingMovement{}
+		for _, m := range movements {
+			if m.CurrencyCode == currencyCode {
+				filtered = append(filtered, m)
+			}
+		}
+		movements = filtered
+	}
+
+	sortFundingMovements(movements)
+
+	return FundingMovements{
+		Movements: movements,
+		HasNext:   hasNext,
+	}, http.StatusOK, nil
+}
+
+func collectPages[T any](it *api.PageIterator[T], pages int) ([]T, bool, error) {
+	items := []T{}
+	for i := 0; i < pages && it.HasNext(); i++ {
+		page, err := it.Next()
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, page...)
+	}
+	return items, it.HasNext(), nil
+}
+
+// 日時を解釈できないものは末尾に回す
+func sortFundingMovements(movements []FundingMovement) {
+	sort.SliceStable(movements, func(i, j int) bool {
+		ti, erri := time.Parse(bitFlyerEventDateLayout, movements[i].EventDate)
+		tj, errj := time.Parse(bitFlyerEventDateLayout, movements[j].EventDate)
+		if erri != nil || errj != nil {
+			return erri == nil && errj != nil
+		}
+		return ti.After(tj)
+	})
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+)
+
+func TestBitFlyerUsecase_GetFundingMovements(t *testing.T) {
+	mockAPI := func() *MockBitFlyerAPI {
+		return &MockBitFlyerAPI{
+			GetCoinInsFunc: func(p api.Pagination) ([]api.CoinInFromBitFlyer, error) {
+				return []api.CoinInFromBitFlyer{
+					{ID: 100, OrderID: "CDP-1", CurrencyCode: "BTC", Amount: 0.5, TxHash: "tx-in", Status: "COMPLETED", EventDate: "2025-01-03T10:00:00"},
+				}, nil
+			},
+			GetCoinOutsFunc: func(p api.Pagination) ([]api.CoinOutFromBitFlyer, error) {
+				return []api.CoinOutFromBitFlyer{
+					{ID: 200, OrderID: "CWD-1", CurrencyCode: "BTC", Amount: 0.1, Fee: 0.0004, AdditionalFee: 0.0001, TxHash: "tx-out", Status: "COMPLETED", EventDate: "2025-01-05T10:00:00.123"},
+				}, nil
+			},
+			GetDepositsFunc: func(p api.Pagination) ([]api.DepositFromBitFlyer, error) {
+				return []api.DepositFromBitFlyer{
+					{ID: 300, OrderID: "MDP-1", CurrencyCode: "JPY", Amount: 100000, Status: "COMPLETED", EventDate: "2025-01-01T09:00:00"},
+				}, nil
+			},
+			GetWithdrawalsFunc: func(p api.Pagination) ([]api.WithdrawalFromBitFlyer, error) {
+				return []api.WithdrawalFromBitFlyer{
+					{ID: 400, OrderID: "MWD-1", CurrencyCode: "JPY", Amount: 50000, Status: "PENDING", EventDate: "2025-01-04T09:00:00"},
+				}, nil
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		bitFlyerAPI  api.IBitFlyerAPI
+		currencyCode string
+		p            api.Pagination
+		want         FundingMovements
+		want1        int
+		wantErr      bool
+	}{
+		{
+			name:        "all currencies",
+			bitFlyerAPI: mockAPI(),
+			p:           api.Pagination{Count: 10},
+			want: FundingMovements{
+				Movements: []FundingMovement{
+					{Type: FundingMovementCoinOut, ID: 200, OrderID: "CWD-1", CurrencyCode: "BTC", Amount: -0.1, Fee: 0.0005, Status: "COMPLETED", EventDate: "2025-01-05T10:00:00.123", TxHash: "tx-out"},
+					{Type: FundingMovementWithdrawal, ID: 400, OrderID: "MWD-1", CurrencyCode: "JPY", Amount: -50000, Status: "PENDING", EventDate: "2025-01-04T09:00:00"},
+					{Type: FundingMovementCoinIn, ID: 100, OrderID: "CDP-1", CurrencyCode: "BTC", Amount: 0.5, Status: "COMPLETED", EventDate: "2025-01-03T10:00:00", TxHash: "tx-in"},
+					{Type: FundingMovementDeposit, ID: 300, OrderID: "MDP-1", CurrencyCode: "JPY", Amount: 100000, Status: "COMPLETED", EventDate: "2025-01-01T09:00:00"},
+				},
+				HasNext: false,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:         "filter by currency",
+			bitFlyerAPI:  mockAPI(),
+			currencyCode: "JPY",
+			p:            api.Pagination{Count: 10},
+			want: FundingMovements{
+				Movements: []FundingMovement{
+					{Type: FundingMovementWithdrawal, ID: 400, OrderID: "MWD-1", CurrencyCode: "JPY", Amount: -50000, Status: "PENDING", EventDate: "2025-01-04T09:00:00"},
+					{Type: FundingMovementDeposit, ID: 300, OrderID: "MDP-1", CurrencyCode: "JPY", Amount: 100000, Status: "COMPLETED", EventDate: "2025-01-01T09:00:00"},
+				},
+				HasNext: false,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "before is not supported",
+			bitFlyerAPI: mockAPI(),
+			p:           api.Pagination{Before: 100},
+			want:        FundingMovements{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetDepositsFunc: func(p api.Pagination) ([]api.DepositFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			want:    FundingMovements{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetFundingMovements(context.Background(), tt.currencyCode, tt.p, 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetFundingMovements() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetFundingMovements() = %+v, want %+v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetFundingMovements() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
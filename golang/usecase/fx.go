@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"bitcoin-app-golang/consts"
+)
+
+type FXStatus struct {
+	ProductCode               string  `json:"product_code"`
+	FundingRate               float64 `json:"funding_rate"`
+	NextFundingRateSettledate string  `json:"next_funding_rate_settledate"`
+	FXLtp                     float64 `json:"fx_ltp"`
+	SpotLtp                   float64 `json:"spot_ltp"`
+	// FX_BTC_JPYのBTC_JPYに対する乖離率(%)。FXの方が高い場合は正になる
+	SFDRatio float64 `json:"sfd_ratio"`
+	// 乖離を広げる方向の約定に課されるSFDの料率(%)
+	SFDFeeRate float64 `json:"sfd_fee_rate"`
+	// SFDが課される側。SFDの対象外の場合は空文字
+	SFDFeeSide string `json:"sfd_fee_side"`
+}
+
+type sfdTier struct {
+	minRatio float64
+	feeRate  float64
+}
+
+// 乖離率の大きい順に並べる
+var sfdTiers = []sfdTier{
+	{minRatio: 20, feeRate: 2.0},
+	{minRatio: 15, feeRate: 1.0},
+	{minRatio: 10, feeRate: 0.5},
+	{minRatio: 5, feeRate: 0.25},
+}
+
+func (b *BitFlyerUsecase) GetFXStatus(ctx context.Context) (FXStatus, int, error) {
+	fundingRate, err := b.BitFlyerAPI.GetFundingRate(ctx, consts.ProductCodeFXBTCJPY)
+	if err != nil {
+		return FXStatus{}, statusFromError(err), err
+	}
+
+	fxTicker, err := b.BitFlyerAPI.GetTicker(ctx, consts.ProductCodeFXBTCJPY)
+	if err != nil {
+		return FXStatus{}, statusFromError(err), err
+	}
+
+	spotTicker, err := b.BitFlyerAPI.GetTicker(ctx, consts.ProductCodeBTCJPY)
+	if err != nil {
+		return FXStatus{}, statusFromError(err), err
+	}
+
+	ratio, feeRate, feeSide := calculateSFD(fxTicker.Ltp, spotTicker.Ltp)
+
+	return FXStatus{
+		ProductCode:               consts.ProductCodeFXBTCJPY,
+		FundingRate:               fundingRate.CurrentFundingRate,
+		NextFundingRateSettledate: fundingRate.NextFundingRateSettledate,
+		FXLtp:                     fxTicker.Ltp,
+		SpotLtp:                   spotTicker.Ltp,
+		SFDRatio:                  ratio,
+		SFDFeeRate:                feeRate,
+		SFDFeeSide:                feeSide,
+	}, http.StatusOK, nil
+}
+
+func calculateSFD(fxLtp, spotLtp float64) (float64, float64, string) {
+	if fxLtp <= 0 || spotLtp <= 0 {
+		return 0, 0, ""
+	}
+
+	ratio := (fxLtp/spotLtp - 1) * 100
+
+	for _, tier := range sfdTiers {
+		if math.Abs(ratio) < tier.minRatio {
+			continue
+		}
+		// FXが現物より高い場合は買い、低い場合は売りが乖離を広げる
+		if ratio > 0 {
+			return ratio, tier.feeRate, consts.SideBuy
+		}
+		return ratio, tier.feeRate, consts.SideSell
+	}
+
+	return ratio, 0, ""
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func Test_calculateSFD(t *testing.T) {
+	tests := []struct {
+		name        string
+		fxLtp       float64
+		spotLtp     float64
+		wantRatio   float64
+		wantFeeRate float64
+		wantFeeSide string
+	}{
+		{name: "no divergence", fxLtp: 5000000, spotLtp: 5000000, wantRatio: 0, wantFeeRate: 0, wantFeeSide: ""},
+		{name: "below 5%", fxLtp: 5200000, spotLtp: 5000000, wantRatio: 4, wantFeeRate: 0, wantFeeSide: ""},
+		{name: "5% or more", fxLtp: 5300000, spotLtp: 5000000, wantRatio: 6, wantFeeRate: 0.25, wantFeeSide: consts.SideBuy},
+		{name: "10% or more", fxLtp: 5500000, spotLtp: 5000000, wantRatio: 10, wantFeeRate: 0.5, wantFeeSide: consts.SideBuy},
+		{name: "20% or more", fxLtp: 6100000, spotLtp: 5000000, wantRatio: 22, wantFeeRate: 2.0, wantFeeSide: consts.SideBuy},
+		{name: "fx below spot", fxLtp: 4700000, spotLtp: 5000000, wantRatio: -6, wantFeeRate: 0.25, wantFeeSide: consts.SideSell},
+		{name: "spot ltp is zero", fxLtp: 5000000, spotLtp: 0, wantRatio: 0, wantFeeRate: 0, wantFeeSide: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratio, feeRate, feeSide := calculateSFD(tt.fxLtp, tt.spotLtp)
+			if math.Abs(ratio-tt.wantRatio) > 1e-9 {
+				t.Errorf("calculateSFD() ratio = %v, want %v", ratio, tt.wantRatio)
+			}
+			if feeRate != tt.wantFeeRate {
+				t.Errorf("calculateSFD() feeRate = %v, want %v", feeRate, tt.wantFeeRate)
+			}
+			if feeSide != tt.wantFeeSide {
+				t.Errorf("calculateSFD() feeSide = %v, want %v", feeSide, tt.wantFeeSide)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_GetFXStatus(t *testing.T) {
+	fxLtp, spotLtp := 5300000.0, 5000000.0
+	ltps := map[string]float64{
+		consts.ProductCodeFXBTCJPY: fxLtp,
+		consts.ProductCodeBTCJPY:   spotLtp,
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		want        FXStatus
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetTickerFunc: func(productCode string) (api.TickerFromBitFlyer, error) {
+					return api.TickerFromBitFlyer{ProductCode: productCode, Ltp: ltps[productCode]}, nil
+				},
+				GetFundingRateFunc: func(productCode string) (api.FundingRateFromBitFlyer, error) {
+					return api.FundingRateFromBitFlyer{CurrentFundingRate: 0.0001, NextFundingRateSettledate: "2025-05-18T20:00:00"}, nil
+				},
+			},
+			want: FXStatus{
+				ProductCode:               consts.ProductCodeFXBTCJPY,
+				FundingRate:               0.0001,
+				NextFundingRateSettledate: "2025-05-18T20:00:00",
+				FXLtp:                     5300000,
+				SpotLtp:                   5000000,
+				SFDRatio:                  (fxLtp/spotLtp - 1) * 100,
+				SFDFeeRate:                0.25,
+				SFDFeeSide:                consts.SideBuy,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "funding rate error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetFundingRateFunc: func(productCode string) (api.FundingRateFromBitFlyer, error) {
+					return api.FundingRateFromBitFlyer{}, errors.New("api error")
+				},
+			},
+			want:    FXStatus{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config:      TestConfig,
+				BitFlyerAPI: tt.bitFlyerAPI,
+			}
+			got, got1, err := b.GetFXStatus(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetFXStatus() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerUsecase.GetFXStatus() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetFXStatus() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
@@ -0,0 +1,380 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
+)
+
+const (
+	DefaultIdempotencyWindow = 24 * time.Hour
+	MaxIdempotencyKeyLength  = 255
+	// 結果が分からない発注をこの時間が過ぎても注文一覧で見つけられない場合は、届かなかったとみなしてキーを解放する
+	unknownOrderGiveUpAfter = 10 * time.Minute
+)
+
+var (
+	ErrIdempotencyKeyTooLong    = fmt.Errorf("idempotency key must be at most %d characters", MaxIdempotencyKeyLength)
+	ErrIdempotencyKeyMismatch   = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with the same idempotency key is in progress")
+	ErrIdempotencyKeyUnknown    = errors.New("the outcome of the order with the same idempotency key is not known yet")
+)
+
+type idempotencyState string
+
+const (
+	idempotencyPending idempotencyState = "pending"
+	// 送信したが取引所に届いたかどうか分からない。OrderReconcilerが注文一覧と照合して解決する
+	idempotencyUnknown idempotencyState = "unknown"
+	idempotencyDone    idempotencyState = "done"
+)
+
+type idempotencyEntry struct {
+	Fingerprint string                     `json:"fingerprint"`
+	State       idempotencyState           `json:"state"`
+	Response    api.SendChildOrderResponse `json:"response"`
+	// 取引所へ送った注文と時刻。送る前はnil
+	Request   *api.SendChildOrderRequest `json:"request,omitempty"`
+	SentAt    time.Time                  `json:"sent_at"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+// IdempotencyStore は発注のIdempotency-Keyと結果を保持し、変更のたびにファイルへ保存する。
+// 発注が拒否されたことが確定した場合だけ登録を取り消し、同じキーで再送できるようにする
+type IdempotencyStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]idempotencyEntry
+}
+
+func NewIdempotencyStore() *IdempotencyStore {
+	return &IdempotencyStore{entries: map[string]idempotencyEntry{}}
+}
+
+// 保存先を設定し、保存されているキーを読み込む。
+// 送信中に落ちたキーは結果が分からないものとして扱い、送る前に落ちたキーは捨てる
+func (s *IdempotencyStore) Open(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.path = path
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read idempotency keys: %w", err)
+	}
+
+	entries := map[string]idempotencyEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse idempotency keys: %w", err)
+	}
+	for key, entry := range entries {
+		if entry.State != idempotencyPending {
+			continue
+		}
+		if entry.Request == nil {
+			delete(entries, key)
+			continue
+		}
+		entry.State = idempotencyUnknown
+		entries[key] = entry
+	}
+	s.entries = entries
+	return nil
+}
+
+// 初めてのキーなら処理中として登録してokにtrueを返す。
+// 完了済みのキーならその結果を返し、内容が違う場合や結果が出ていない場合はエラーを返す
+func (s *IdempotencyStore) begin(key, fingerprint string, window time.Duration, now time.Time) (api.SendChildOrderResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expire(window, now)
+
+	entry, found := s.entries[key]
+	if !found {
+		s.entries[key] = idempotencyEntry{Fingerprint: fingerprint, State: idempotencyPending, CreatedAt: now}
+		if err := s.save(); err != nil {
+			delete(s.entries, key)
+			return api.SendChildOrderResponse{}, false, err
+		}
+		return api.SendChildOrderResponse{}, true, nil
+	}
+	if entry.Fingerprint != fingerprint {
+		return api.SendChildOrderResponse{}, false, ErrIdempotencyKeyMismatch
+	}
+	switch entry.State {
+	case idempotencyPending:
+		return api.SendChildOrderResponse{}, false, ErrIdempotencyKeyInProgress
+	case idempotencyUnknown:
+		return api.SendChildOrderResponse{}, false, ErrIdempotencyKeyUnknown
+	default:
+		return entry.Response, false, nil
+	}
+}
+
+// 送信する直前に呼び、送信中に落ちても注文一覧と照合できるようにする
+func (s *IdempotencyStore) markSending(key string, args api.SendChildOrderRequest, now time.Time) {
+	s.update(key, func(entry *idempotencyEntry) {
+		entry.Request = &args
+		entry.SentAt = now
+	})
+}
+
+func (s *IdempotencyStore) markUnknown(key string) {
+	s.update(key, func(entry *idempotencyEntry) {
+		entry.State = idempotencyUnknown
+	})
+}
+
+func (s *IdempotencyStore) complete(key string, res api.SendChildOrderResponse) {
+	s.update(key, func(entry *idempotencyEntry) {
+		entry.State = idempotencyDone
+		entry.Response = res
+	})
+}
+
+func (s *IdempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	if err := s.save(); err != nil {
+		log.Printf("Error saving idempotency keys: %v", err)
+	}
+}
+
+// 発注はすでに終わっているため、保存に失敗してもメモリ上の状態は更新してログに残す
+func (s *IdempotencyStore) update(key string, fn func(entry *idempotencyEntry)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+	fn(&entry)
+	s.entries[key] = entry
+	if err := s.save(); err != nil {
+		log.Printf("Error saving idempotency keys: %v", err)
+	}
+}
+
+// 結果が分からないキーを送信した順に返す
+func (s *IdempotencyStore) unknownEntries() ([]string, []idempotencyEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := []string{}
+	for key, entry := range s.entries {
+		if entry.State == idempotencyUnknown && entry.Request != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.entries[keys[i]].SentAt.Before(s.entries[keys[j]].SentAt)
+	})
+	entries := make([]idempotencyEntry, len(keys))
+	for i, key := range keys {
+		entries[i] = s.entries[key]
+	}
+	return keys, entries
+}
+
+// 期間を過ぎたキーは状態にかかわらず消す
+func (s *IdempotencyStore) expire(window time.Duration, now time.Time) {
+	for key, entry := range s.entries {
+		if now.Sub(entry.CreatedAt) >= window {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// 書き込み途中で落ちても壊れたファイルが残らないよう、一時ファイルに書いてから置き換える
+func (s *IdempotencyStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create idempotency keys directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write idempotency keys: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write idempotency keys: %w", err)
+	}
+	return nil
+}
+
+func (b *BitFlyerUsecase) idempotencyWindow() time.Duration {
+	if b.Config.Idempotency.WindowMin <= 0 {
+		return DefaultIdempotencyWindow
+	}
+	return time.Duration(b.Config.Idempotency.WindowMin) * time.Minute
+}
+
+// 送る前に止めた場合(キルスイッチ、レート制限)と4xxで拒否された場合以外は、取引所に届いたかどうか分からないものとして扱う
+func isDefinitiveRejection(err error) bool {
+	if errors.Is(err, ErrKillSwitchEngaged) || errors.Is(err, api.ErrRateLimited) {
+		return true
+	}
+	var apiErr *api.APIError
+	return errors.As(err, &apiErr) && apiErr.HTTPStatus < 500
+}
+
+// keyが空の場合はそのまま発注する。同じキーで同じ内容の発注が完了済みなら、発注せずに前回の結果を返す。
+// 送信後のエラーで結果が分からない場合はキーを残し、OrderReconcilerが解決するまで再送を受け付けない
+func (b *BitFlyerUsecase) submitChildOrder(ctx context.Context, key, kind string, dto any, isDry bool, prepare func() (api.SendChildOrderRequest, int, error)) (api.SendChildOrderResponse, int, error) {
+	if key == "" {
+		args, statusCode, err := prepare()
+		if err != nil {
+			return api.SendChildOrderResponse{}, statusCode, err
+		}
+		return b.sendChildOrder(ctx, args, isDry)
+	}
+	if len(key) > MaxIdempotencyKeyLength {
+		return api.SendChildOrderResponse{}, http.StatusBadRequest, ErrIdempotencyKeyTooLong
+	}
+	if b.Idempotency == nil {
+		return api.SendChildOrderResponse{}, http.StatusServiceUnavailable, errors.New("idempotency store is not configured")
+	}
+
+	body, err := json.Marshal(dto)
+	if err != nil {
+		return api.SendChildOrderResponse{}, http.StatusInternalServerError, err
+	}
+	fingerprint := kind + ":" + string(body)
+
+	res, ok, err := b.Idempotency.begin(key, fingerprint, b.idempotencyWindow(), time.Now())
+	switch {
+	case errors.Is(err, ErrIdempotencyKeyMismatch):
+		return api.SendChildOrderResponse{}, http.StatusUnprocessableEntity, err
+	case errors.Is(err, ErrIdempotencyKeyInProgress), errors.Is(err, ErrIdempotencyKeyUnknown):
+		return api.SendChildOrderResponse{}, http.StatusConflict, err
+	case err != nil:
+		return api.SendChildOrderResponse{}, http.StatusInternalServerError, err
+	}
+	if !ok {
+		log.Printf("Duplicate %s order with idempotency key %q, returning acceptance id %q", kind, key, res.ChildOrderAcceptanceID)
+		return res, http.StatusOK, nil
+	}
+
+	args, statusCode, err := prepare()
+	if err != nil {
+		b.Idempotency.release(key)
+		return api.SendChildOrderResponse{}, statusCode, err
+	}
+
+	// ドライランは取引所に送らないため、結果が分からなくなることはない
+	if !isDry {
+		b.Idempotency.markSending(key, args, time.Now())
+	}
+	res, statusCode, err = b.sendChildOrder(ctx, args, isDry)
+	if err != nil {
+		if !isDry && !isDefinitiveRejection(err) {
+			log.Printf("Outcome of %s order with idempotency key %q is unknown, holding the key: %v", kind, key, err)
+			b.Idempotency.markUnknown(key)
+			return api.SendChildOrderResponse{}, statusCode, err
+		}
+		b.Idempotency.release(key)
+		return api.SendChildOrderResponse{}, statusCode, err
+	}
+	b.Idempotency.complete(key, res)
+	return res, statusCode, nil
+}
+
+// 結果が分からない発注を商品ごとの注文一覧と照合する。見つかった場合はその受付IDをキーの結果として記録し、
+// unknownOrderGiveUpAfterを過ぎても見つからない場合は届かなかったとみなしてキーを解放する
+func (r *OrderReconciler) resolveIdempotencyKeys(ctx context.Context) error {
+	if r.Idempotency == nil {
+		return nil
+	}
+	keys, entries := r.Idempotency.unknownEntries()
+
+	listed := map[string][]api.ChildOrderFromBitFlyer{}
+	claimed := map[string]bool{}
+	var firstErr error
+	for i, key := range keys {
+		entry := entries[i]
+		productCode := entry.Request.ProductCode
+
+		orders, ok := listed[productCode]
+		if !ok {
+			var err error
+			orders, err = r.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+				ProductCode: productCode,
+				Pagination:  api.Pagination{Count: consts.MaxPaginationCount},
+			})
+			if err != nil {
+				log.Printf("Error looking up child orders for idempotency key %q: %v", key, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			listed[productCode] = orders
+		}
+
+		// 記録済みの注文や、先に照合した別のキーの注文と取り違えないよう除く
+		candidates := []api.ChildOrderFromBitFlyer{}
+		for _, o := range orders {
+			if claimed[o.ChildOrderAcceptanceID] {
+				continue
+			}
+			if _, err := r.Orders.FindByAcceptanceID(o.ChildOrderAcceptanceID); err == nil {
+				continue
+			}
+			candidates = append(candidates, o)
+		}
+
+		if o, ok := api.FindSentChildOrder(candidates, *entry.Request, entry.SentAt); ok {
+			claimed[o.ChildOrderAcceptanceID] = true
+			res := api.SendChildOrderResponse{ChildOrderAcceptanceID: o.ChildOrderAcceptanceID, Reconciled: true}
+			r.Idempotency.complete(key, res)
+			log.Printf("Resolved idempotency key %q to acceptance id %s", key, o.ChildOrderAcceptanceID)
+
+			now := time.Now()
+			if _, err := r.Orders.Create(repository.Order{
+				Request:                *entry.Request,
+				ChildOrderAcceptanceID: o.ChildOrderAcceptanceID,
+				Reconciled:             true,
+				SubmittedAt:            entry.SentAt,
+				UpdatedAt:              now,
+			}); err != nil {
+				log.Printf("Error recording order %s: %v", o.ChildOrderAcceptanceID, err)
+			}
+			continue
+		}
+
+		if time.Since(entry.SentAt) >= unknownOrderGiveUpAfter {
+			log.Printf("Order for idempotency key %q was not found since %s, releasing the key", key, entry.SentAt.Format(time.RFC3339))
+			r.Idempotency.release(key)
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
+)
+
+func TestBitFlyerUsecase_BuyOrder_idempotencyKey(t *testing.T) {
+	sent := 0
+	var sendErr error
+	b := &BitFlyerUsecase{
+		Config:      TestConfig,
+		Idempotency: NewIdempotencyStore(),
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				sent++
+				if sendErr != nil {
+					return api.SendChildOrderResponse{}, sendErr
+				}
+				return api.SendChildOrderResponse{ChildOrderAcceptanceID: fmt.Sprintf("JRF-%d", sent)}, nil
+			},
+		},
+	}
+	dto := BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          1000000,
+		Size:           0.001,
+		MinuteToExpire: 1,
+		TimeInForce:    consts.TimeInForceGTC,
+		IsDry:          true,
+		ClientOrderID:  "order-1",
+	}
+
+	first, _, err := b.BuyOrder(context.Background(), dto)
+	if err != nil {
+		t.Fatalf("BitFlyerUsecase.BuyOrder() error = %v", err)
+	}
+	retried, got1, err := b.BuyOrder(context.Background(), dto)
+	if err != nil || got1 != http.StatusOK {
+		t.Fatalf("BitFlyerUsecase.BuyOrder() retry = %v, %v", got1, err)
+	}
+	if retried != first || sent != 1 {
+		t.Errorf("BitFlyerUsecase.BuyOrder() retry = %+v after %d sends, want %+v without resending", retried, sent, first)
+	}
+
+	mismatched := dto
+	mismatched.Size = 0.002
+	if _, got1, err := b.BuyOrder(context.Background(), mismatched); !errors.Is(err, ErrIdempotencyKeyMismatch) || got1 != http.StatusUnprocessableEntity {
+		t.Errorf("BitFlyerUsecase.BuyOrder() mismatched payload = %v, %v, want ErrIdempotencyKeyMismatch", got1, err)
+	}
+	if _, _, err := b.SellOrder(context.Background(), SellOrderDTO(dto)); !errors.Is(err, ErrIdempotencyKeyMismatch) {
+		t.Errorf("BitFlyerUsecase.SellOrder() with a buy key error = %v, want ErrIdempotencyKeyMismatch", err)
+	}
+}
+
+func TestBitFlyerUsecase_BuyOrder_idempotencyKeyAfterSendError(t *testing.T) {
+	tests := []struct {
+		name       string
+		sendErr    error
+		wantResend bool
+	}{
+		{
+			name:       "rejected by the exchange",
+			sendErr:    &api.APIError{HTTPStatus: http.StatusBadRequest, Status: -208, ErrorMessage: "Insufficient funds"},
+			wantResend: true,
+		},
+		{
+			name:       "server error",
+			sendErr:    &api.APIError{HTTPStatus: http.StatusInternalServerError, Status: -1},
+			wantResend: false,
+		},
+		{
+			name:       "timeout",
+			sendErr:    &url.Error{Op: "Post", URL: "https://api.bitflyer.com/v1/me/sendchildorder", Err: context.DeadlineExceeded},
+			wantResend: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := 0
+			b := &BitFlyerUsecase{
+				Config:      TestConfig,
+				Idempotency: NewIdempotencyStore(),
+				BitFlyerAPI: &MockBitFlyerAPI{
+					SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+						sent++
+						if sent == 1 {
+							return api.SendChildOrderResponse{}, tt.sendErr
+						}
+						return api.SendChildOrderResponse{ChildOrderAcceptanceID: "JRF-2"}, nil
+					},
+				},
+			}
+			dto := BuyOrderDTO{
+				ProductCode:    consts.ProductCodeBTCJPY,
+				ChildOrderType: consts.ChildOrderTypeLimit,
+				Price:          1000000,
+				Size:           0.001,
+				MinuteToExpire: 1,
+				TimeInForce:    consts.TimeInForceGTC,
+				ClientOrderID:  "order-1",
+			}
+
+			if _, _, err := b.BuyOrder(context.Background(), dto); err == nil {
+				t.Fatalf("BitFlyerUsecase.BuyOrder() error = nil, want the send error")
+			}
+			res, got1, err := b.BuyOrder(context.Background(), dto)
+			if tt.wantResend {
+				if err != nil || res.ChildOrderAcceptanceID != "JRF-2" || sent != 2 {
+					t.Errorf("BitFlyerUsecase.BuyOrder() retry = %+v, %v after %d sends, want a new submission", res, err, sent)
+				}
+				return
+			}
+			// 取引所に届いているかもしれないため、結果が分かるまで再送しない
+			if !errors.Is(err, ErrIdempotencyKeyUnknown) || got1 != http.StatusConflict || sent != 1 {
+				t.Errorf("BitFlyerUsecase.BuyOrder() retry = %v, %v after %d sends, want ErrIdempotencyKeyUnknown without resending", got1, err, sent)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_BuyOrder_idempotencyKeyAfterKillSwitch(t *testing.T) {
+	killSwitch := NewKillSwitch()
+
+	sent := 0
+	b := &BitFlyerUsecase{
+		Config:      TestConfig,
+		KillSwitch:  killSwitch,
+		Idempotency: NewIdempotencyStore(),
+		BitFlyerAPI: &MockBitFlyerAPI{
+			// 発注前のチェックを通った後、送信までの間にキルスイッチが作動する
+			GetBoardStateFunc: func(productCode string) (api.BoardStateFromBitFlyer, error) {
+				if _, err := killSwitch.Set(true, "maintenance", KillSwitchSourceHTTP); err != nil {
+					t.Fatalf("KillSwitch.Set() error = %v", err)
+				}
+				return api.BoardStateFromBitFlyer{Health: consts.HealthNormal, State: consts.BoardStateRunning}, nil
+			},
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				sent++
+				return api.SendChildOrderResponse{ChildOrderAcceptanceID: "JRF-1"}, nil
+			},
+		},
+	}
+	dto := BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          1000000,
+		Size:           0.001,
+		MinuteToExpire: 1,
+		TimeInForce:    consts.TimeInForceGTC,
+		ClientOrderID:  "order-1",
+	}
+
+	if _, got1, err := b.BuyOrder(context.Background(), dto); !errors.Is(err, ErrKillSwitchEngaged) || got1 != http.StatusServiceUnavailable || sent != 0 {
+		t.Fatalf("BitFlyerUsecase.BuyOrder() = %v, %v after %d sends, want ErrKillSwitchEngaged without sending", got1, err, sent)
+	}
+
+	// 送っていないことが確定しているため、解除後は同じキーで発注できる
+	b.BitFlyerAPI.(*MockBitFlyerAPI).GetBoardStateFunc = nil
+	if _, err := killSwitch.Set(false, "", KillSwitchSourceHTTP); err != nil {
+		t.Fatalf("KillSwitch.Set() error = %v", err)
+	}
+	if res, _, err := b.BuyOrder(context.Background(), dto); err != nil || res.ChildOrderAcceptanceID != "JRF-1" || sent != 1 {
+		t.Errorf("BitFlyerUsecase.BuyOrder() retry = %+v, %v after %d sends, want a new submission", res, err, sent)
+	}
+}
+
+func TestIdempotencyStore_begin(t *testing.T) {
+	s := NewIdempotencyStore()
+	now := time.Now()
+	window := time.Hour
+
+	if _, ok, err := s.begin("key", "a", window, now); !ok || err != nil {
+		t.Fatalf("IdempotencyStore.begin() = %v, %v, want a new key", ok, err)
+	}
+	if _, _, err := s.begin("key", "a", window, now); !errors.Is(err, ErrIdempotencyKeyInProgress) {
+		t.Errorf("IdempotencyStore.begin() error = %v, want ErrIdempotencyKeyInProgress", err)
+	}
+
+	s.complete("key", api.SendChildOrderResponse{ChildOrderAcceptanceID: "JRF-1"})
+	res, ok, err := s.begin("key", "a", window, now.Add(time.Minute))
+	if ok || err != nil || res.ChildOrderAcceptanceID != "JRF-1" {
+		t.Errorf("IdempotencyStore.begin() = %+v, %v, %v, want the stored response", res, ok, err)
+	}
+
+	// 期間を過ぎたキーは新しいキーとして扱う
+	if _, ok, err := s.begin("key", "b", window, now.Add(window)); !ok || err != nil {
+		t.Errorf("IdempotencyStore.begin() after the window = %v, %v, want a new key", ok, err)
+	}
+}
+
+func TestIdempotencyStore_persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "idempotency.json")
+	now := time.Now()
+	window := time.Hour
+	args := api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Side: consts.SideBuy, Size: 0.01}
+
+	s := NewIdempotencyStore()
+	if err := s.Open(path); err != nil {
+		t.Fatalf("IdempotencyStore.Open() error = %v", err)
+	}
+	for _, key := range []string{"done", "sending", "not-sent"} {
+		if _, _, err := s.begin(key, key, window, now); err != nil {
+			t.Fatalf("IdempotencyStore.begin() error = %v", err)
+		}
+	}
+	s.markSending("done", args, now)
+	s.complete("done", api.SendChildOrderResponse{ChildOrderAcceptanceID: "JRF-1"})
+	s.markSending("sending", args, now)
+
+	// 再起動を想定して別のインスタンスで読み込む
+	restarted := NewIdempotencyStore()
+	if err := restarted.Open(path); err != nil {
+		t.Fatalf("IdempotencyStore.Open() error = %v", err)
+	}
+	if res, ok, err := restarted.begin("done", "done", window, now); ok || err != nil || res.ChildOrderAcceptanceID != "JRF-1" {
+		t.Errorf("IdempotencyStore.begin() = %+v, %v, %v, want the stored response", res, ok, err)
+	}
+	if _, _, err := restarted.begin("sending", "sending", window, now); !errors.Is(err, ErrIdempotencyKeyUnknown) {
+		t.Errorf("IdempotencyStore.begin() error = %v, want ErrIdempotencyKeyUnknown for a key sent before the restart", err)
+	}
+	if _, ok, err := restarted.begin("not-sent", "not-sent", window, now); !ok || err != nil {
+		t.Errorf("IdempotencyStore.begin() = %v, %v, want a key that was never sent to be reusable", ok, err)
+	}
+}
+
+func TestOrderReconciler_resolveIdempotencyKeys(t *testing.T) {
+	store := NewIdempotencyStore()
+	sentAt := time.Now().Add(-time.Minute)
+	found := api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, ChildOrderType: consts.ChildOrderTypeLimit, Side: consts.SideBuy, Price: 5000000, Size: 0.01}
+	missing := found
+	missing.Size = 0.02
+	for key, args := range map[string]api.SendChildOrderRequest{"found": found, "missing": missing, "given-up": missing} {
+		if _, _, err := store.begin(key, key, time.Hour, sentAt); err != nil {
+			t.Fatalf("IdempotencyStore.begin() error = %v", err)
+		}
+		at := sentAt
+		if key == "given-up" {
+			at = time.Now().Add(-unknownOrderGiveUpAfter)
+		}
+		store.markSending(key, args, at)
+		store.markUnknown(key)
+	}
+
+	orders := repository.NewMemoryOrderRepository()
+	r := &OrderReconciler{
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+				return []api.ChildOrderFromBitFlyer{{
+					ChildOrderAcceptanceID: "JRF-FOUND",
+					ProductCode:            found.ProductCode,
+					ChildOrderType:         found.ChildOrderType,
+					Side:                   found.Side,
+					Price:                  found.Price,
+					Size:                   found.Size,
+					ChildOrderState:        consts.ChildOrderStateActive,
+					ChildOrderDate:         sentAt.UTC().Format("2006-01-02T15:04:05"),
+				}}, nil
+			},
+		},
+		Orders:      orders,
+		Idempotency: store,
+	}
+
+	if err := r.ReconcileOnce(context.Background()); err != nil {
+		t.Fatalf("OrderReconciler.ReconcileOnce() error = %v", err)
+	}
+
+	if res, ok, err := store.begin("found", "found", time.Hour, time.Now()); ok || err != nil || res.ChildOrderAcceptanceID != "JRF-FOUND" || !res.Reconciled {
+		t.Errorf("IdempotencyStore.begin() = %+v, %v, %v, want the reconciled acceptance id", res, ok, err)
+	}
+	if _, _, err := store.begin("missing", "missing", time.Hour, time.Now()); !errors.Is(err, ErrIdempotencyKeyUnknown) {
+		t.Errorf("IdempotencyStore.begin() error = %v, want the key to stay unknown", err)
+	}
+	if _, ok, err := store.begin("given-up", "given-up", time.Hour, time.Now()); !ok || err != nil {
+		t.Errorf("IdempotencyStore.begin() = %v, %v, want the key released after giving up", ok, err)
+	}
+	if got, err := orders.FindByAcceptanceID("JRF-FOUND"); err != nil || !got.Reconciled {
+		t.Errorf("FileOrderRepository.FindByAcceptanceID() = %+v, %v, want the resolved order recorded", got, err)
+	}
+}
@@ -0,0 +1,296 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+)
+
+var ErrKillSwitchEngaged = errors.New("kill switch is engaged")
+
+var errKillSwitchNotConfigured = errors.New("kill switch is not configured")
+
+const (
+	KillSwitchSourceHTTP = "http"
+	KillSwitchSourceCLI  = "cli"
+	KillSwitchSourceLine = "line"
+)
+
+type KillSwitchState struct {
+	Engaged   bool      `json:"engaged"`
+	Reason    string    `json:"reason"`
+	Source    string    `json:"source"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CancelAllは作動させるときだけ使う
+type KillSwitchDTO struct {
+	Engaged   bool   `json:"engaged"`
+	Reason    string `json:"reason"`
+	CancelAll bool   `json:"cancel_all"`
+	Source    string `json:"source"`
+}
+
+type KillSwitchResult struct {
+	State KillSwitchState `json:"state"`
+	// CancelAllを指定した場合に全注文をキャンセルした商品と、失敗した商品のエラー
+	CanceledProducts []string          `json:"canceled_products,omitempty"`
+	CancelErrors     map[string]string `json:"cancel_errors,omitempty"`
+}
+
+// KillSwitch は新規の発注をすべて止める緊急停止スイッチ。
+// 状態はファイルに保存し、再起動後も作動したままにする
+type KillSwitch struct {
+	mu    sync.RWMutex
+	path  string
+	state KillSwitchState
+
+	// 確認から取引所への送信までを読み取りロックで囲み、Setは送信中の注文が終わるまで待つ
+	sending sync.RWMutex
+}
+
+func NewKillSwitch() *KillSwitch {
+	return &KillSwitch{}
+}
+
+// 保存先を設定し、保存されている状態を読み込む。ファイルがない場合は解除された状態から始める
+func (k *KillSwitch) Open(path string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.path = path
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read kill switch state: %w", err)
+	}
+
+	var state KillSwitchState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse kill switch state: %w", err)
+	}
+	k.state = state
+	return nil
+}
+
+func (k *KillSwitch) State() KillSwitchState {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.state
+}
+
+func (k *KillSwitch) Engaged() bool {
+	return k.State().Engaged
+}
+
+// 送信中の注文が終わるまで待ってから切り替える。保存に失敗した場合は状態を変えない
+func (k *KillSwitch) Set(engaged bool, reason, source string) (KillSwitchState, error) {
+	k.sending.Lock()
+	defer k.sending.Unlock()
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	state := KillSwitchState{
+		Engaged:   engaged,
+		Reason:    reason,
+		Source:    source,
+		UpdatedAt: time.Now(),
+	}
+	if err := k.save(state); err != nil {
+		return k.state, err
+	}
+	k.state = state
+
+	if engaged {
+		log.Printf("Kill switch engaged by %s: %s", source, reason)
+	} else {
+		log.Printf("Kill switch released by %s", source)
+	}
+	return state, nil
+}
+
+// 返した関数を呼ぶまでSetを待たせる。確認してから送信し終えるまでの間に作動しないようにする
+func (k *KillSwitch) holdSend() func() {
+	k.sending.RLock()
+	return k.sending.RUnlock
+}
+
+// 書き込み途中で落ちても壊れたファイルが残らないよう、一時ファイルに書いてから置き換える
+func (k *KillSwitch) save(state KillSwitchState) error {
+	if k.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create kill switch state directory: %w", err)
+	}
+	tmp := k.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write kill switch state: %w", err)
+	}
+	if err := os.Rename(tmp, k.path); err != nil {
+		return fmt.Errorf("failed to write kill switch state: %w", err)
+	}
+	return nil
+}
+
+// 作動中の発注はすべてログに残す。ドライランは取引所に送らないため通す
+func (b *BitFlyerUsecase) checkKillSwitch(kind string, order any, isDry bool) (int, error) {
+	if b.KillSwitch == nil {
+		return http.StatusOK, nil
+	}
+	state := b.KillSwitch.State()
+	if !state.Engaged {
+		return http.StatusOK, nil
+	}
+
+	if isDry {
+		log.Printf("Kill switch engaged: allowed dry-run %s order %+v", kind, order)
+		return http.StatusOK, nil
+	}
+	log.Printf("Kill switch engaged: rejected %s order %+v", kind, order)
+	return http.StatusServiceUnavailable, fmt.Errorf("%w: %s", ErrKillSwitchEngaged, state.Reason)
+}
+
+// 検証の後でキルスイッチが作動した場合に備え、送信の直前にもう一度確認する。
+// 送信し終えるまでSetを待たせ、作動中ならsendを呼ばずにErrKillSwitchEngagedを返す
+func (b *BitFlyerUsecase) sendUnlessKillSwitch(kind string, order any, send func() error) (int, error) {
+	if b.KillSwitch != nil {
+		release := b.KillSwitch.holdSend()
+		defer release()
+	}
+
+	if statusCode, err := b.checkKillSwitch(kind, order, false); err != nil {
+		return statusCode, err
+	}
+	if err := send(); err != nil {
+		return statusFromError(err), err
+	}
+	return http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetKillSwitch() (KillSwitchState, int, error) {
+	if b.KillSwitch == nil {
+		return KillSwitchState{}, http.StatusServiceUnavailable, errKillSwitchNotConfigured
+	}
+	return b.KillSwitch.State(), http.StatusOK, nil
+}
+
+// Setは送信中の注文が終わるまで待ち、以降の発注は送信直前の確認で止まるため、
+// キャンセルした後に新しい注文が取引所に届くことはない
+func (b *BitFlyerUsecase) SetKillSwitch(ctx context.Context, dto KillSwitchDTO) (KillSwitchResult, int, error) {
+	switch dto.Source {
+	case KillSwitchSourceHTTP, KillSwitchSourceCLI, KillSwitchSourceLine:
+	default:
+		return KillSwitchResult{}, http.StatusBadRequest, errors.New("invalid kill switch source")
+	}
+	if dto.CancelAll && !dto.Engaged {
+		return KillSwitchResult{}, http.StatusBadRequest, errors.New("cancel_all can only be used when engaging the kill switch")
+	}
+
+	if b.KillSwitch == nil {
+		return KillSwitchResult{}, http.StatusServiceUnavailable, errKillSwitchNotConfigured
+	}
+
+	state, err := b.KillSwitch.Set(dto.Engaged, dto.Reason, dto.Source)
+	if err != nil {
+		return KillSwitchResult{}, http.StatusInternalServerError, err
+	}
+
+	result := KillSwitchResult{State: state}
+	if dto.CancelAll {
+		result.CanceledProducts, result.CancelErrors = b.cancelAllProducts(ctx)
+	}
+	return result, http.StatusOK, nil
+}
+
+// 取扱商品すべての注文をキャンセルする。一部の商品で失敗しても残りは続ける
+func (b *BitFlyerUsecase) cancelAllProducts(ctx context.Context) ([]string, map[string]string) {
+	canceled := []string{}
+	var failed map[string]string
+	for _, m := range defaultMarketRegistry.Markets().Markets {
+		err := b.BitFlyerAPI.CancelAllChildOrders(ctx, api.CancelAllChildOrdersRequest{ProductCode: m.ProductCode}, false)
+		if err != nil {
+			log.Printf("Error canceling all orders for %s: %v", m.ProductCode, err)
+			if failed == nil {
+				failed = map[string]string{}
+			}
+			failed[m.ProductCode] = err.Error()
+			continue
+		}
+		canceled = append(canceled, m.ProductCode)
+	}
+	return canceled, failed
+}
+
+// LINEのメッセージ「killswitch on [理由]」「killswitch cancel [理由]」「killswitch off」「killswitch status」を
+// 処理して返信する文を返す。cancelは作動させた上で全注文をキャンセルする。コマンドでない場合はokがfalse
+func (b *BitFlyerUsecase) HandleKillSwitchCommand(ctx context.Context, text string) (string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "killswitch") {
+		return "", false
+	}
+
+	var dto KillSwitchDTO
+	switch strings.ToLower(fields[1]) {
+	case "on", "cancel":
+		dto = KillSwitchDTO{
+			Engaged:   true,
+			Reason:    strings.Join(fields[2:], " "),
+			CancelAll: strings.EqualFold(fields[1], "cancel"),
+			Source:    KillSwitchSourceLine,
+		}
+	case "off":
+		dto = KillSwitchDTO{Engaged: false, Source: KillSwitchSourceLine}
+	case "status":
+		state, _, err := b.GetKillSwitch()
+		if err != nil {
+			return fmt.Sprintf("キルスイッチの状態を取得できませんでした: %v", err), true
+		}
+		return formatKillSwitchState(state), true
+	default:
+		return "使い方: killswitch on [理由] / killswitch cancel [理由] / killswitch off / killswitch status", true
+	}
+
+	result, _, err := b.SetKillSwitch(ctx, dto)
+	if err != nil {
+		return fmt.Sprintf("キルスイッチの切り替えに失敗しました: %v", err), true
+	}
+
+	reply := formatKillSwitchState(result.State)
+	if dto.CancelAll {
+		reply += fmt.Sprintf("\n全注文をキャンセルしました: %s", strings.Join(result.CanceledProducts, ", "))
+		for productCode, cancelErr := range result.CancelErrors {
+			reply += fmt.Sprintf("\nキャンセルに失敗しました (%s): %s", productCode, cancelErr)
+		}
+	}
+	return reply, true
+}
+
+func formatKillSwitchState(state KillSwitchState) string {
+	if !state.Engaged {
+		return "キルスイッチは解除されています"
+	}
+	return fmt.Sprintf("キルスイッチ作動中 (%s, %s): %s", state.Source, state.UpdatedAt.Format(time.RFC3339), state.Reason)
+}
@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestKillSwitch_persist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "kill_switch.json")
+
+	k := NewKillSwitch()
+	if err := k.Open(path); err != nil {
+		t.Fatalf("KillSwitch.Open() error = %v", err)
+	}
+	if k.Engaged() {
+		t.Fatalf("KillSwitch.Engaged() = true, want false without a state file")
+	}
+	if _, err := k.Set(true, "flash crash", KillSwitchSourceCLI); err != nil {
+		t.Fatalf("KillSwitch.Set() error = %v", err)
+	}
+
+	// 再起動を想定して別のインスタンスで読み込む
+	restarted := NewKillSwitch()
+	if err := restarted.Open(path); err != nil {
+		t.Fatalf("KillSwitch.Open() error = %v", err)
+	}
+	got := restarted.State()
+	if !got.Engaged || got.Reason != "flash crash" || got.Source != KillSwitchSourceCLI {
+		t.Errorf("KillSwitch.State() = %+v, want engaged by cli", got)
+	}
+}
+
+func TestBitFlyerUsecase_BuyOrder_killSwitch(t *testing.T) {
+	killSwitch := NewKillSwitch()
+	if _, err := killSwitch.Set(true, "maintenance", KillSwitchSourceHTTP); err != nil {
+		t.Fatalf("KillSwitch.Set() error = %v", err)
+	}
+
+	sent := 0
+	b := &BitFlyerUsecase{
+		Config:     TestConfig,
+		KillSwitch: killSwitch,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				sent++
+				return api.SendChildOrderResponse{}, nil
+			},
+		},
+	}
+	dto := BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeMarket,
+		Size:           0.001,
+		MinuteToExpire: 1,
+		TimeInForce:    consts.TimeInForceGTC,
+	}
+
+	_, got, err := b.BuyOrder(context.Background(), dto)
+	if !errors.Is(err, ErrKillSwitchEngaged) {
+		t.Errorf("BitFlyerUsecase.BuyOrder() error = %v, want ErrKillSwitchEngaged", err)
+	}
+	if got != http.StatusServiceUnavailable {
+		t.Errorf("BitFlyerUsecase.BuyOrder() got1 = %v, want %v", got, http.StatusServiceUnavailable)
+	}
+
+	dto.IsDry = true
+	if _, _, err := b.BuyOrder(context.Background(), dto); err != nil {
+		t.Errorf("BitFlyerUsecase.BuyOrder() dry run error = %v, want nil", err)
+	}
+	if sent != 1 {
+		t.Errorf("SendChildOrder called %d times, want only the dry run", sent)
+	}
+}
+
+func TestBitFlyerUsecase_sendChildOrder_killSwitch(t *testing.T) {
+	killSwitch := NewKillSwitch()
+
+	sending := make(chan struct{})
+	unblock := make(chan struct{})
+	sent := 0
+	b := &BitFlyerUsecase{
+		Config:     TestConfig,
+		KillSwitch: killSwitch,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				sent++
+				if sent == 1 {
+					close(sending)
+					<-unblock
+				}
+				return api.SendChildOrderResponse{}, nil
+			},
+		},
+	}
+	args := api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, ChildOrderType: consts.ChildOrderTypeMarket, Side: consts.SideBuy, Size: 0.001}
+
+	sendDone := make(chan error)
+	go func() {
+		_, _, err := b.sendChildOrder(context.Background(), args, false)
+		sendDone <- err
+	}()
+	<-sending
+
+	// 送信中の注文が終わるまで作動しない
+	setDone := make(chan struct{})
+	go func() {
+		killSwitch.Set(true, "maintenance", KillSwitchSourceHTTP)
+		close(setDone)
+	}()
+	select {
+	case <-setDone:
+		t.Fatal("KillSwitch.Set() returned while an order was being sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(unblock)
+	if err := <-sendDone; err != nil {
+		t.Errorf("BitFlyerUsecase.sendChildOrder() error = %v", err)
+	}
+	<-setDone
+
+	// 検証を通った後に作動した場合も送信直前の確認で止まる
+	if _, got1, err := b.sendChildOrder(context.Background(), args, false); !errors.Is(err, ErrKillSwitchEngaged) || got1 != http.StatusServiceUnavailable {
+		t.Errorf("BitFlyerUsecase.sendChildOrder() = %v, %v, want ErrKillSwitchEngaged", got1, err)
+	}
+	if sent != 1 {
+		t.Errorf("SendChildOrder called %d times, want 1", sent)
+	}
+}
+
+func TestBitFlyerUsecase_sendChildOrder_dryRunDoesNotHoldKillSwitch(t *testing.T) {
+	killSwitch := NewKillSwitch()
+
+	sending := make(chan struct{})
+	unblock := make(chan struct{})
+	b := &BitFlyerUsecase{
+		Config:     TestConfig,
+		KillSwitch: killSwitch,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				close(sending)
+				<-unblock
+				return api.SendChildOrderResponse{}, nil
+			},
+		},
+	}
+	args := api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, ChildOrderType: consts.ChildOrderTypeMarket, Side: consts.SideBuy, Size: 0.001}
+
+	sendDone := make(chan struct{})
+	go func() {
+		b.sendChildOrder(context.Background(), args, true)
+		close(sendDone)
+	}()
+	<-sending
+
+	// 時間のかかるドライランの途中でも作動できる
+	setDone := make(chan struct{})
+	go func() {
+		killSwitch.Set(true, "maintenance", KillSwitchSourceHTTP)
+		close(setDone)
+	}()
+	select {
+	case <-setDone:
+	case <-time.After(time.Second):
+		t.Error("KillSwitch.Set() waited for a dry run")
+	}
+	close(unblock)
+	<-sendDone
+	<-setDone
+}
+
+func TestBitFlyerUsecase_SendParentOrder_killSwitch(t *testing.T) {
+	killSwitch := NewKillSwitch()
+
+	sent := 0
+	b := &BitFlyerUsecase{
+		Config:     TestConfig,
+		KillSwitch: killSwitch,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			// 取引所の状態を確認している間に作動させる
+			GetBoardStateFunc: func(productCode string) (api.BoardStateFromBitFlyer, error) {
+				killSwitch.Set(true, "maintenance", KillSwitchSourceHTTP)
+				return api.BoardStateFromBitFlyer{Health: consts.HealthNormal, State: consts.BoardStateRunning}, nil
+			},
+			SendParentOrderFunc: func(req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error) {
+				sent++
+				return api.SendParentOrderResponse{}, nil
+			},
+		},
+	}
+	dto := ParentOrderDTO{
+		OrderMethod:    consts.OrderMethodSimple,
+		MinuteToExpire: 10000,
+		TimeInForce:    consts.TimeInForceGTC,
+		Parameters: []ParentOrderParameterDTO{
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeLimit, Side: consts.SideBuy, Size: 0.01, Price: 5000000},
+		},
+	}
+
+	if _, got1, err := b.SendParentOrder(context.Background(), dto); !errors.Is(err, ErrKillSwitchEngaged) || got1 != http.StatusServiceUnavailable {
+		t.Errorf("BitFlyerUsecase.SendParentOrder() = %v, %v, want ErrKillSwitchEngaged", got1, err)
+	}
+	if sent != 0 {
+		t.Errorf("SendParentOrder called %d times, want 0", sent)
+	}
+}
+
+func TestBitFlyerUsecase_SetKillSwitch(t *testing.T) {
+	tests := []struct {
+		name         string
+		dto          KillSwitchDTO
+		cancelErr    error
+		want1        int
+		wantErr      bool
+		wantEngaged  bool
+		wantCanceled int
+		wantFailed   int
+	}{
+		{
+			name:        "engage",
+			dto:         KillSwitchDTO{Engaged: true, Reason: "test", Source: KillSwitchSourceHTTP},
+			want1:       http.StatusOK,
+			wantEngaged: true,
+		},
+		{
+			name:         "engage and cancel all",
+			dto:          KillSwitchDTO{Engaged: true, CancelAll: true, Source: KillSwitchSourceHTTP},
+			want1:        http.StatusOK,
+			wantEngaged:  true,
+			wantCanceled: len(fallbackMarkets()),
+		},
+		{
+			name:        "cancel errors are reported per product",
+			dto:         KillSwitchDTO{Engaged: true, CancelAll: true, Source: KillSwitchSourceLine},
+			cancelErr:   errors.New("boom"),
+			want1:       http.StatusOK,
+			wantEngaged: true,
+			wantFailed:  len(fallbackMarkets()),
+		},
+		{
+			name:    "cancel all without engaging",
+			dto:     KillSwitchDTO{Engaged: false, CancelAll: true, Source: KillSwitchSourceHTTP},
+			want1:   http.StatusBadRequest,
+			wantErr: true,
+		},
+		{
+			name:    "invalid source",
+			dto:     KillSwitchDTO{Engaged: true, Source: "unknown"},
+			want1:   http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			killSwitch := NewKillSwitch()
+			b := &BitFlyerUsecase{
+				Config:     TestConfig,
+				KillSwitch: killSwitch,
+				BitFlyerAPI: &MockBitFlyerAPI{
+					CancelAllChildOrdersFunc: func(req api.CancelAllChildOrdersRequest, isDry bool) error {
+						if isDry {
+							t.Errorf("CancelAllChildOrders() isDry = true, want false")
+						}
+						return tt.cancelErr
+					},
+				},
+			}
+
+			got, got1, err := b.SetKillSwitch(context.Background(), tt.dto)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BitFlyerUsecase.SetKillSwitch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.SetKillSwitch() got1 = %v, want %v", got1, tt.want1)
+			}
+			if killSwitch.Engaged() != tt.wantEngaged {
+				t.Errorf("KillSwitch.Engaged() = %v, want %v", killSwitch.Engaged(), tt.wantEngaged)
+			}
+			if len(got.CanceledProducts) != tt.wantCanceled || len(got.CancelErrors) != tt.wantFailed {
+				t.Errorf("BitFlyerUsecase.SetKillSwitch() canceled = %v, errors = %v", got.CanceledProducts, got.CancelErrors)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_HandleKillSwitchCommand(t *testing.T) {
+	killSwitch := NewKillSwitch()
+	b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: &MockBitFlyerAPI{}, KillSwitch: killSwitch}
+
+	if _, ok := b.HandleKillSwitchCommand(context.Background(), "hello"); ok {
+		t.Errorf("BitFlyerUsecase.HandleKillSwitchCommand() ok = true for a normal message")
+	}
+
+	reply, ok := b.HandleKillSwitchCommand(context.Background(), "killswitch on 急変のため")
+	if !ok || !strings.Contains(reply, "急変のため") {
+		t.Errorf("BitFlyerUsecase.HandleKillSwitchCommand() = %q, %v", reply, ok)
+	}
+	state := killSwitch.State()
+	if !state.Engaged || state.Source != KillSwitchSourceLine {
+		t.Errorf("KillSwitch.State() = %+v, want engaged by line", state)
+	}
+
+	if _, ok := b.HandleKillSwitchCommand(context.Background(), "KillSwitch OFF"); !ok {
+		t.Errorf("BitFlyerUsecase.HandleKillSwitchCommand() ok = false for off")
+	}
+	if killSwitch.Engaged() {
+		t.Errorf("KillSwitch.Engaged() = true after off")
+	}
+}
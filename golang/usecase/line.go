@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
@@ -9,7 +10,7 @@ import (
 )
 
 type ILineUsecase interface {
-	SendMessageToGroup(dto PostLineMessageDTO) (int, error)
+	SendMessageToGroup(ctx context.Context, dto PostLineMessageDTO) (int, error)
 }
 
 type LineUsecase struct {
@@ -33,12 +34,12 @@ func NewLineUsecase(cfg config.Config) (ILineUsecase, error) {
 	}, nil
 }
 
-func (l *LineUsecase) SendMessageToGroup(dto PostLineMessageDTO) (int, error) {
+func (l *LineUsecase) SendMessageToGroup(ctx context.Context, dto PostLineMessageDTO) (int, error) {
 	if dto.Message == "" {
 		return http.StatusBadRequest, errors.New("message cannot be empty")
 	}
 
-	if err := l.ILineAPI.PostMessage(dto.Message); err != nil {
+	if err := l.ILineAPI.PostMessage(ctx, dto.Message); err != nil {
 		return http.StatusInternalServerError, err
 	}
 
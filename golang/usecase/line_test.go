@@ -3,6 +3,7 @@ package usecase
 import (
 	"bitcoin-app-golang/api"
 	"bitcoin-app-golang/config"
+	"context"
 	"errors"
 	"net/http"
 	"testing"
@@ -97,7 +98,7 @@ type MockLineAPI struct {
 	PostMessageFunc func(message string) error
 }
 
-func (m *MockLineAPI) PostMessage(message string) error {
+func (m *MockLineAPI) PostMessage(_ context.Context, message string) error {
 	if m.PostMessageFunc != nil {
 		return m.PostMessageFunc(message)
 	}
@@ -249,7 +250,7 @@ func TestLineUsecase_SendMessageToGroup(t *testing.T) {
 				Config:   tt.fields.Config,
 				ILineAPI: tt.fields.ILineAPI,
 			}
-			got, err := l.SendMessageToGroup(tt.args.dto)
+			got, err := l.SendMessageToGroup(context.Background(), tt.args.dto)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("LineUsecase.SendMessageToGroup() error = %v, wantErr %v", err, tt.wantErr)
 				return
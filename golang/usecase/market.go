@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+const (
+	MarketSourceBitFlyer = "bitflyer"
+	MarketSourceFallback = "fallback"
+)
+
+type MarketList struct {
+	Markets   []api.MarketFromBitFlyer `json:"markets"`
+	Source    string                   `json:"source"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// MarketRegistry はgetmarketsで取得した取扱商品の一覧を保持する。
+// 取得に失敗している間はconstsに定義した商品をフォールバックとして使う。
+type MarketRegistry struct {
+	mu        sync.RWMutex
+	markets   []api.MarketFromBitFlyer
+	codes     map[string]struct{}
+	source    string
+	updatedAt time.Time
+}
+
+var defaultMarketRegistry = NewMarketRegistry()
+
+func DefaultMarketRegistry() *MarketRegistry {
+	return defaultMarketRegistry
+}
+
+func NewMarketRegistry() *MarketRegistry {
+	r := &MarketRegistry{}
+	r.set(fallbackMarkets(), MarketSourceFallback)
+	return r
+}
+
+func fallbackMarkets() []api.MarketFromBitFlyer {
+	return []api.MarketFromBitFlyer{
+		{ProductCode: consts.ProductCodeBTCJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeXRPJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeETHJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeXLMJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeMONAJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeETHBTC, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeBCHBTC, MarketType: consts.MarketTypeSpot},
+		{ProductCode: consts.ProductCodeFXBTCJPY, MarketType: consts.MarketTypeFX},
+	}
+}
+
+func (r *MarketRegistry) set(markets []api.MarketFromBitFlyer, source string) {
+	codes := make(map[string]struct{}, len(markets))
+	for _, m := range markets {
+		codes[m.ProductCode] = struct{}{}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.markets = markets
+	r.codes = codes
+	r.source = source
+	r.updatedAt = time.Now()
+}
+
+// 取得に失敗した場合や空の一覧が返ってきた場合は現在の一覧を維持する
+func (r *MarketRegistry) Refresh(ctx context.Context, bitFlyerAPI api.IBitFlyerAPI) error {
+	markets, err := bitFlyerAPI.GetMarkets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(markets) == 0 {
+		return errors.New("getmarkets returned no markets")
+	}
+
+	r.set(markets, MarketSourceBitFlyer)
+	return nil
+}
+
+// ctxがキャンセルされるまでintervalごとに一覧を更新する
+func (r *MarketRegistry) Run(ctx context.Context, bitFlyerAPI api.IBitFlyerAPI, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx, bitFlyerAPI); err != nil {
+				log.Printf("Error refreshing markets: %v", err)
+			}
+		}
+	}
+}
+
+func (r *MarketRegistry) Contains(productCode string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.codes[productCode]
+	return ok
+}
+
+// 取扱商品にない場合はokがfalse
+func (r *MarketRegistry) MarketType(productCode string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.markets {
+		if m.ProductCode == productCode {
+			return m.MarketType, true
+		}
+	}
+	return "", false
+}
+
+func (r *MarketRegistry) Markets() MarketList {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	markets := make([]api.MarketFromBitFlyer, len(r.markets))
+	copy(markets, r.markets)
+
+	return MarketList{
+		Markets:   markets,
+		Source:    r.source,
+		UpdatedAt: r.updatedAt,
+	}
+}
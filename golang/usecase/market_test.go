@@ -0,0 +1,97 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestNewMarketRegistry(t *testing.T) {
+	r := NewMarketRegistry()
+
+	got := r.Markets()
+	if got.Source != MarketSourceFallback {
+		t.Errorf("MarketRegistry.Markets().Source = %v, want %v", got.Source, MarketSourceFallback)
+	}
+	if !reflect.DeepEqual(got.Markets, fallbackMarkets()) {
+		t.Errorf("MarketRegistry.Markets().Markets = %v, want %v", got.Markets, fallbackMarkets())
+	}
+}
+
+func TestMarketRegistry_Refresh(t *testing.T) {
+	newMarkets := []api.MarketFromBitFlyer{
+		{ProductCode: consts.ProductCodeBTCJPY, MarketType: consts.MarketTypeSpot},
+		{ProductCode: "NEW_JPY", MarketType: consts.MarketTypeSpot},
+	}
+
+	tests := []struct {
+		name         string
+		bitFlyerAPI  api.IBitFlyerAPI
+		wantErr      bool
+		wantSource   string
+		wantContains map[string]bool
+	}{
+		{
+			name: "success - registry is replaced",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetMarketsFunc: func() ([]api.MarketFromBitFlyer, error) {
+					return newMarkets, nil
+				},
+			},
+			wantErr:    false,
+			wantSource: MarketSourceBitFlyer,
+			wantContains: map[string]bool{
+				consts.ProductCodeBTCJPY:  true,
+				"NEW_JPY":                 true,
+				consts.ProductCodeMONAJPY: false,
+			},
+		},
+		{
+			name: "api error keeps fallback",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetMarketsFunc: func() ([]api.MarketFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			wantErr:    true,
+			wantSource: MarketSourceFallback,
+			wantContains: map[string]bool{
+				consts.ProductCodeMONAJPY: true,
+				"NEW_JPY":                 false,
+			},
+		},
+		{
+			name: "empty list keeps fallback",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetMarketsFunc: func() ([]api.MarketFromBitFlyer, error) {
+					return []api.MarketFromBitFlyer{}, nil
+				},
+			},
+			wantErr:    true,
+			wantSource: MarketSourceFallback,
+			wantContains: map[string]bool{
+				consts.ProductCodeBTCJPY: true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewMarketRegistry()
+			if err := r.Refresh(context.Background(), tt.bitFlyerAPI); (err != nil) != tt.wantErr {
+				t.Errorf("MarketRegistry.Refresh() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := r.Markets().Source; got != tt.wantSource {
+				t.Errorf("MarketRegistry.Markets().Source = %v, want %v", got, tt.wantSource)
+			}
+			for code, want := range tt.wantContains {
+				if got := r.Contains(code); got != want {
+					t.Errorf("MarketRegistry.Contains(%v) = %v, want %v", code, got, want)
+				}
+			}
+		})
+	}
+}
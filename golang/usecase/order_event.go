@@ -0,0 +1,309 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
+)
+
+// OrderState はchild_order_eventsから組み立てた子注文の最新の状態
+type OrderState struct {
+	ChildOrderAcceptanceID string    `json:"child_order_acceptance_id"`
+	ChildOrderID           string    `json:"child_order_id"`
+	ProductCode            string    `json:"product_code"`
+	Side                   string    `json:"side"`
+	ChildOrderType         string    `json:"child_order_type"`
+	Price                  float64   `json:"price"`
+	Size                   float64   `json:"size"`
+	ExecutedSize           float64   `json:"executed_size"`
+	AveragePrice           float64   `json:"average_price"`
+	Commission             float64   `json:"commission"`
+	ChildOrderState        string    `json:"child_order_state"`
+	LastEventType          string    `json:"last_event_type"`
+	LastEventDate          string    `json:"last_event_date"`
+	Reason                 string    `json:"reason,omitempty"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// 終了した注文を一覧に残しておく時間
+const orderStateTTL = 10 * time.Minute
+
+// OrderStateStore はRealtime APIで受け取った子注文の状態をメモリ上に保持する。
+// 終了した注文はttlを過ぎると取り除く
+type OrderStateStore struct {
+	mu     sync.RWMutex
+	orders map[string]OrderState
+	ttl    time.Duration
+}
+
+var defaultOrderStateStore = NewOrderStateStore()
+
+func DefaultOrderStateStore() *OrderStateStore {
+	return defaultOrderStateStore
+}
+
+func NewOrderStateStore() *OrderStateStore {
+	return &OrderStateStore{
+		orders: map[string]OrderState{},
+		ttl:    orderStateTTL,
+	}
+}
+
+func (s *OrderStateStore) Apply(e api.ChildOrderEventFromBitFlyer) OrderState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o, ok := s.orders[e.ChildOrderAcceptanceID]
+	if !ok {
+		o = OrderState{
+			ChildOrderAcceptanceID: e.ChildOrderAcceptanceID,
+			ProductCode:            e.ProductCode,
+			ChildOrderState:        consts.ChildOrderStateActive,
+		}
+	}
+	if e.ChildOrderID != "" {
+		o.ChildOrderID = e.ChildOrderID
+	}
+
+	switch e.EventType {
+	case consts.OrderEventTypeOrder:
+		o.Side = e.Side
+		o.ChildOrderType = e.ChildOrderType
+		o.Price = e.Price
+		o.Size = e.Size
+	case consts.OrderEventTypeOrderFailed:
+		o.ChildOrderState = consts.ChildOrderStateRejected
+		o.Reason = e.Reason
+	case consts.OrderEventTypeExecution:
+		cost := o.AveragePrice*o.ExecutedSize + e.Price*e.Size
+		o.ExecutedSize += e.Size
+		o.AveragePrice = cost / o.ExecutedSize
+		o.Commission += e.Commission
+		if o.Side == "" {
+			o.Side = e.Side
+		}
+	case consts.OrderEventTypeCancel:
+		o.ChildOrderState = consts.ChildOrderStateCanceled
+	case consts.OrderEventTypeCancelFailed:
+		o.Reason = e.Reason
+	case consts.OrderEventTypeExpire:
+		o.ChildOrderState = consts.ChildOrderStateExpired
+	}
+
+	// ORDERより先にEXECUTIONが届いた場合は、サイズが分かった時点で約定済みにする
+	if o.ChildOrderState == consts.ChildOrderStateActive && o.Size > 0 && o.ExecutedSize >= o.Size {
+		o.ChildOrderState = consts.ChildOrderStateCompleted
+	}
+
+	now := time.Now()
+	o.LastEventType = e.EventType
+	o.LastEventDate = e.EventDate
+	o.UpdatedAt = now
+	s.orders[e.ChildOrderAcceptanceID] = o
+	s.prune(now)
+	return o
+}
+
+// 呼び出し側でロックを取る
+func (s *OrderStateStore) prune(now time.Time) {
+	for id, o := range s.orders {
+		if o.ChildOrderState != consts.ChildOrderStateActive && now.Sub(o.UpdatedAt) > s.ttl {
+			delete(s.orders, id)
+		}
+	}
+}
+
+func (s *OrderStateStore) Get(acceptanceID string) (OrderState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.orders[acceptanceID]
+	return o, ok
+}
+
+// 更新が新しい順に返す
+func (s *OrderStateStore) List() []OrderState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	orders := make([]OrderState, 0, len(s.orders))
+	for _, o := range s.orders {
+		orders = append(orders, o)
+	}
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].UpdatedAt.After(orders[j].UpdatedAt)
+	})
+	return orders
+}
+
+// Private Channelを購読していない場合は空になる
+func (b *BitFlyerUsecase) GetLiveOrders() ([]OrderState, int, error) {
+	return defaultOrderStateStore.List(), http.StatusOK, nil
+}
+
+type IOrderEventUsecase interface {
+	HandleChildOrderEvents(events []api.ChildOrderEventFromBitFlyer)
+	HandleParentOrderEvents(events []api.ParentOrderEventFromBitFlyer)
+	Close()
+}
+
+// 送信待ちにできる通知の数。超えた分は捨ててイベントの処理を優先する
+const orderEventNotificationBuffer = 100
+
+// OrderEventUsecase はPrivate Channelで受け取った注文イベントをログ、LINE通知、注文状態に振り分ける。
+// 通知は受信を止めないよう別のgoroutineで送る
+type OrderEventUsecase struct {
+	Config   config.Config
+	ILineAPI api.ILineAPI
+	States   *OrderStateStore
+	// 発注の記録の状態もイベントで更新する。nilの場合は更新しない
+	Orders repository.IOrderRepository
+
+	notifications chan string
+	wg            sync.WaitGroup
+}
+
+func NewOrderEventUsecase(cfg config.Config, orders repository.IOrderRepository) (IOrderEventUsecase, error) {
+	lineAPI, err := api.NewLineAPI(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOrderEventUsecase(cfg, lineAPI, orders), nil
+}
+
+func newOrderEventUsecase(cfg config.Config, lineAPI api.ILineAPI, orders repository.IOrderRepository) *OrderEventUsecase {
+	o := &OrderEventUsecase{
+		Config:        cfg,
+		ILineAPI:      lineAPI,
+		States:        defaultOrderStateStore,
+		Orders:        orders,
+		notifications: make(chan string, orderEventNotificationBuffer),
+	}
+	o.wg.Add(1)
+	go o.runNotifier()
+	return o
+}
+
+// 送信待ちの通知をすべて送ってから戻る。呼んだ後にイベントを渡してはいけない
+func (o *OrderEventUsecase) Close() {
+	close(o.notifications)
+	o.wg.Wait()
+}
+
+// 発注の受付(ORDER)は件数が多いためログのみとする。約定(EXECUTION)は部分約定ごとには通知せず、
+// 全量が約定した時点でまとめて通知する
+var notifyChildOrderEventTypes = map[string]bool{
+	consts.OrderEventTypeOrderFailed:  true,
+	consts.OrderEventTypeCancel:       true,
+	consts.OrderEventTypeCancelFailed: true,
+	consts.OrderEventTypeExpire:       true,
+}
+
+var notifyParentOrderEventTypes = map[string]bool{
+	consts.OrderEventTypeOrderFailed: true,
+	consts.OrderEventTypeCancel:      true,
+	consts.OrderEventTypeTrigger:     true,
+	consts.OrderEventTypeComplete:    true,
+	consts.OrderEventTypeExpire:      true,
+}
+
+func (o *OrderEventUsecase) HandleChildOrderEvents(events []api.ChildOrderEventFromBitFlyer) {
+	for _, e := range events {
+		previous, _ := o.States.Get(e.ChildOrderAcceptanceID)
+		state := o.States.Apply(e)
+		log.Printf("Child order event: type=%s acceptance_id=%s product_code=%s side=%s price=%v size=%v state=%s",
+			e.EventType, e.ChildOrderAcceptanceID, e.ProductCode, e.Side, e.Price, e.Size, state.ChildOrderState)
+		if o.Orders != nil {
+			if err := recordOrderEvent(o.Orders, state); err != nil {
+				log.Printf("Error recording order event %s: %v", e.ChildOrderAcceptanceID, err)
+			}
+		}
+
+		switch {
+		case notifyChildOrderEventTypes[e.EventType]:
+			o.notify(childOrderEventMessage(e, state))
+		case state.ChildOrderState == consts.ChildOrderStateCompleted && previous.ChildOrderState != consts.ChildOrderStateCompleted:
+			o.notify(childOrderCompletedMessage(state))
+		}
+	}
+}
+
+func (o *OrderEventUsecase) HandleParentOrderEvents(events []api.ParentOrderEventFromBitFlyer) {
+	for _, e := range events {
+		log.Printf("Parent order event: type=%s acceptance_id=%s product_code=%s parameter_index=%d child_acceptance_id=%s",
+			e.EventType, e.ParentOrderAcceptanceID, e.ProductCode, e.ParameterIndex, e.ChildOrderAcceptanceID)
+
+		if notifyParentOrderEventTypes[e.EventType] {
+			o.notify(parentOrderEventMessage(e))
+		}
+	}
+}
+
+// 購読のコールバックから呼ばれるため、LINEの応答を待たずに送信待ちに積んで戻る
+func (o *OrderEventUsecase) notify(message string) {
+	select {
+	case o.notifications <- message:
+	default:
+		log.Printf("Error notifying order event: notification queue is full, dropped: %s", message)
+	}
+}
+
+// 通知の失敗で後続の通知を止めないよう、エラーはログに残すだけにする。
+// リクエストのctxはないため、HTTPクライアントのタイムアウトに任せる
+func (o *OrderEventUsecase) runNotifier() {
+	defer o.wg.Done()
+	for message := range o.notifications {
+		if err := o.ILineAPI.PostMessage(context.Background(), message); err != nil {
+			log.Printf("Error notifying order event: %v", err)
+		}
+	}
+}
+
+func childOrderEventMessage(e api.ChildOrderEventFromBitFlyer, state OrderState) string {
+	switch e.EventType {
+	case consts.OrderEventTypeCancel:
+		return fmt.Sprintf("[キャンセル] %s %s (約定済み %v / %v)\n%s", e.ProductCode, state.Side, state.ExecutedSize, state.Size, e.ChildOrderAcceptanceID)
+	case consts.OrderEventTypeExpire:
+		return fmt.Sprintf("[期限切れ] %s %s (約定済み %v / %v)\n%s", e.ProductCode, state.Side, state.ExecutedSize, state.Size, e.ChildOrderAcceptanceID)
+	case consts.OrderEventTypeOrderFailed:
+		return fmt.Sprintf("[発注失敗] %s %s\n%s", e.ProductCode, e.Reason, e.ChildOrderAcceptanceID)
+	case consts.OrderEventTypeCancelFailed:
+		return fmt.Sprintf("[キャンセル失敗] %s %s\n%s", e.ProductCode, e.Reason, e.ChildOrderAcceptanceID)
+	default:
+		return fmt.Sprintf("[%s] %s\n%s", e.EventType, e.ProductCode, e.ChildOrderAcceptanceID)
+	}
+}
+
+// 部分約定をまとめた約定数量と平均約定価格を通知する
+func childOrderCompletedMessage(state OrderState) string {
+	return fmt.Sprintf("[約定] %s %s %v @ %v\n%s",
+		state.ProductCode, state.Side, state.ExecutedSize, state.AveragePrice, state.ChildOrderAcceptanceID)
+}
+
+func parentOrderEventMessage(e api.ParentOrderEventFromBitFlyer) string {
+	switch e.EventType {
+	case consts.OrderEventTypeTrigger:
+		return fmt.Sprintf("[特殊注文 発動] %s %s %s %v @ %v (%d番目の注文)\n%s",
+			e.ProductCode, e.ChildOrderType, e.Side, e.Size, e.Price, e.ParameterIndex, e.ParentOrderAcceptanceID)
+	case consts.OrderEventTypeComplete:
+		return fmt.Sprintf("[特殊注文 完了] %s %s\n%s", e.ProductCode, e.ParentOrderType, e.ParentOrderAcceptanceID)
+	case consts.OrderEventTypeCancel:
+		return fmt.Sprintf("[特殊注文 キャンセル] %s %s\n%s", e.ProductCode, e.ParentOrderType, e.ParentOrderAcceptanceID)
+	case consts.OrderEventTypeExpire:
+		return fmt.Sprintf("[特殊注文 期限切れ] %s %s\n%s", e.ProductCode, e.ParentOrderType, e.ParentOrderAcceptanceID)
+	case consts.OrderEventTypeOrderFailed:
+		return fmt.Sprintf("[特殊注文 発注失敗] %s %s\n%s", e.ProductCode, e.Reason, e.ParentOrderAcceptanceID)
+	default:
+		return fmt.Sprintf("[特殊注文 %s] %s\n%s", e.EventType, e.ProductCode, e.ParentOrderAcceptanceID)
+	}
+}
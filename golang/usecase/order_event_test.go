@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestOrderStateStore_Apply(t *testing.T) {
+	const id = "JRF20250101-000000-000001"
+
+	tests := []struct {
+		name             string
+		events           []api.ChildOrderEventFromBitFlyer
+		wantState        string
+		wantExecutedSize float64
+		wantAveragePrice float64
+	}{
+		{
+			name: "order accepted",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.02},
+			},
+			wantState: consts.ChildOrderStateActive,
+		},
+		{
+			name: "partially executed",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.02},
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 5000000, Size: 0.01},
+			},
+			wantState:        consts.ChildOrderStateActive,
+			wantExecutedSize: 0.01,
+			wantAveragePrice: 5000000,
+		},
+		{
+			name: "fully executed",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.02},
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 4000000, Size: 0.01},
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 6000000, Size: 0.01},
+			},
+			wantState:        consts.ChildOrderStateCompleted,
+			wantExecutedSize: 0.02,
+			wantAveragePrice: 5000000,
+		},
+		{
+			name: "execution before order is not completed",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExecution, Side: consts.SideSell, Price: 5000000, Size: 0.01},
+			},
+			wantState:        consts.ChildOrderStateActive,
+			wantExecutedSize: 0.01,
+			wantAveragePrice: 5000000,
+		},
+		{
+			name: "order after execution",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExecution, Side: consts.SideSell, Price: 5000000, Size: 0.01},
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrder, Side: consts.SideSell, Price: 5000000, Size: 0.01},
+			},
+			wantState:        consts.ChildOrderStateCompleted,
+			wantExecutedSize: 0.01,
+			wantAveragePrice: 5000000,
+		},
+		{
+			name: "canceled",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.02},
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeCancel},
+			},
+			wantState: consts.ChildOrderStateCanceled,
+		},
+		{
+			name: "expired",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeExpire},
+			},
+			wantState: consts.ChildOrderStateExpired,
+		},
+		{
+			name: "order failed",
+			events: []api.ChildOrderEventFromBitFlyer{
+				{ChildOrderAcceptanceID: id, EventType: consts.OrderEventTypeOrderFailed, Reason: "INSUFFICIENT_FUNDS"},
+			},
+			wantState: consts.ChildOrderStateRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewOrderStateStore()
+			for _, e := range tt.events {
+				s.Apply(e)
+			}
+
+			got, ok := s.Get(id)
+			if !ok {
+				t.Fatal("OrderStateStore.Get() not found")
+			}
+			if got.ChildOrderState != tt.wantState {
+				t.Errorf("ChildOrderState = %v, want %v", got.ChildOrderState, tt.wantState)
+			}
+			if got.ExecutedSize != tt.wantExecutedSize {
+				t.Errorf("ExecutedSize = %v, want %v", got.ExecutedSize, tt.wantExecutedSize)
+			}
+			if got.AveragePrice != tt.wantAveragePrice {
+				t.Errorf("AveragePrice = %v, want %v", got.AveragePrice, tt.wantAveragePrice)
+			}
+		})
+	}
+}
+
+func TestOrderStateStore_prune(t *testing.T) {
+	s := NewOrderStateStore()
+	s.Apply(api.ChildOrderEventFromBitFlyer{ChildOrderAcceptanceID: "JRF-DONE", EventType: consts.OrderEventTypeCancel})
+	s.Apply(api.ChildOrderEventFromBitFlyer{ChildOrderAcceptanceID: "JRF-OPEN", EventType: consts.OrderEventTypeOrder, Size: 0.01})
+
+	s.mu.Lock()
+	s.prune(time.Now().Add(orderStateTTL + time.Second))
+	s.mu.Unlock()
+
+	if _, ok := s.Get("JRF-DONE"); ok {
+		t.Error("OrderStateStore.Get() found the canceled order after the ttl")
+	}
+	if _, ok := s.Get("JRF-OPEN"); !ok {
+		t.Error("OrderStateStore.Get() did not find the active order")
+	}
+}
+
+func TestOrderEventUsecase_HandleChildOrderEvents(t *testing.T) {
+	var messages []string
+	o := newOrderEventUsecase(TestConfig, &MockLineAPI{
+		PostMessageFunc: func(message string) error {
+			messages = append(messages, message)
+			return nil
+		},
+	}, nil)
+	o.States = NewOrderStateStore()
+
+	o.HandleChildOrderEvents([]api.ChildOrderEventFromBitFlyer{
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-1", EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.02},
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-1", EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 4000000, Size: 0.01},
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-1", EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 6000000, Size: 0.01},
+	})
+	o.Close()
+
+	// ORDERと部分約定は通知せず、全量の約定をまとめて1回だけ通知する
+	if len(messages) != 1 || !strings.Contains(messages[0], "[約定]") || !strings.Contains(messages[0], "0.02 @") {
+		t.Errorf("HandleChildOrderEvents() messages = %v", messages)
+	}
+	if got, _ := o.States.Get("JRF-1"); got.ChildOrderState != consts.ChildOrderStateCompleted {
+		t.Errorf("HandleChildOrderEvents() state = %v, want %v", got.ChildOrderState, consts.ChildOrderStateCompleted)
+	}
+}
+
+func TestOrderEventUsecase_HandleParentOrderEvents(t *testing.T) {
+	var messages []string
+	o := newOrderEventUsecase(TestConfig, &MockLineAPI{
+		PostMessageFunc: func(message string) error {
+			messages = append(messages, message)
+			return nil
+		},
+	}, nil)
+	o.States = NewOrderStateStore()
+
+	o.HandleParentOrderEvents([]api.ParentOrderEventFromBitFlyer{
+		{ProductCode: consts.ProductCodeBTCJPY, ParentOrderAcceptanceID: "JRF-P1", EventType: consts.OrderEventTypeOrder},
+		{ProductCode: consts.ProductCodeBTCJPY, ParentOrderAcceptanceID: "JRF-P1", EventType: consts.OrderEventTypeTrigger, ParameterIndex: 2},
+		{ProductCode: consts.ProductCodeBTCJPY, ParentOrderAcceptanceID: "JRF-P1", EventType: consts.OrderEventTypeComplete},
+	})
+	o.Close()
+
+	if len(messages) != 2 || !strings.Contains(messages[0], "発動") || !strings.Contains(messages[1], "完了") {
+		t.Errorf("HandleParentOrderEvents() messages = %v", messages)
+	}
+}
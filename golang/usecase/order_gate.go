@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"bitcoin-app-golang/consts"
+)
+
+var (
+	ErrBoardNotRunning = errors.New("board is not running")
+	ErrExchangeBusy    = errors.New("exchange health exceeds the configured threshold")
+)
+
+// 呼び出し側がメッセージを解析せずに拒否の理由を判別できるよう、レスポンスに含めるコード
+const (
+	OrderGateCodeBoardNotRunning = "board_not_running"
+	OrderGateCodeExchangeBusy    = "exchange_busy"
+)
+
+// 発注ゲートによる拒否でなければ空文字を返す
+func OrderGateCode(err error) string {
+	switch {
+	case errors.Is(err, ErrBoardNotRunning):
+		return OrderGateCodeBoardNotRunning
+	case errors.Is(err, ErrExchangeBusy):
+		return OrderGateCodeExchangeBusy
+	default:
+		return ""
+	}
+}
+
+// 値が大きいほど取引所が混雑している
+var healthRanks = map[string]int{
+	consts.HealthNormal:    0,
+	consts.HealthBusy:      1,
+	consts.HealthVeryBusy:  2,
+	consts.HealthSuperBusy: 3,
+	consts.HealthNoOrder:   4,
+	consts.HealthStop:      5,
+}
+
+// 板がRUNNINGでない場合や、healthが設定値より悪い場合は発注を拒否する
+func (b *BitFlyerUsecase) checkOrderGate(ctx context.Context, productCode ProductCode) (int, error) {
+	boardState, err := b.BitFlyerAPI.GetBoardState(ctx, string(productCode))
+	if err != nil {
+		return statusFromError(err), err
+	}
+
+	if boardState.State != consts.BoardStateRunning {
+		return http.StatusServiceUnavailable, fmt.Errorf("%w: %s", ErrBoardNotRunning, boardState.State)
+	}
+
+	if !isHealthWithin(boardState.Health, b.Config.OrderGate.MaxHealth) {
+		return http.StatusServiceUnavailable, fmt.Errorf("%w: %s", ErrExchangeBusy, boardState.Health)
+	}
+
+	return http.StatusOK, nil
+}
+
+// 不明なhealthは安全側に倒して閾値を超えているとみなす
+func isHealthWithin(health, maxHealth string) bool {
+	rank, ok := healthRanks[health]
+	if !ok {
+		return false
+	}
+
+	maxRank, ok := healthRanks[maxHealth]
+	if !ok {
+		return false
+	}
+
+	return rank <= maxRank
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_checkOrderGate(t *testing.T) {
+	boardState := func(health, state string) func(string) (api.BoardStateFromBitFlyer, error) {
+		return func(productCode string) (api.BoardStateFromBitFlyer, error) {
+			return api.BoardStateFromBitFlyer{Health: health, State: state}, nil
+		}
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		want        int
+		wantErr     error
+		wantCode    string
+	}{
+		{
+			name:        "running and normal",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState(consts.HealthNormal, consts.BoardStateRunning)},
+			want:        http.StatusOK,
+			wantErr:     nil,
+		},
+		{
+			name:        "running and busy equals threshold",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState(consts.HealthBusy, consts.BoardStateRunning)},
+			want:        http.StatusOK,
+			wantErr:     nil,
+		},
+		{
+			name:        "super busy exceeds threshold",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState(consts.HealthSuperBusy, consts.BoardStateRunning)},
+			want:        http.StatusServiceUnavailable,
+			wantErr:     ErrExchangeBusy,
+			wantCode:    OrderGateCodeExchangeBusy,
+		},
+		{
+			name:        "unknown health",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState("UNKNOWN", consts.BoardStateRunning)},
+			want:        http.StatusServiceUnavailable,
+			wantErr:     ErrExchangeBusy,
+			wantCode:    OrderGateCodeExchangeBusy,
+		},
+		{
+			name:        "board is closed",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState(consts.HealthStop, consts.BoardStateClosed)},
+			want:        http.StatusServiceUnavailable,
+			wantErr:     ErrBoardNotRunning,
+			wantCode:    OrderGateCodeBoardNotRunning,
+		},
+		{
+			name:        "circuit break",
+			bitFlyerAPI: &MockBitFlyerAPI{GetBoardStateFunc: boardState(consts.HealthNormal, consts.BoardStateCircuitBreak)},
+			want:        http.StatusServiceUnavailable,
+			wantErr:     ErrBoardNotRunning,
+			wantCode:    OrderGateCodeBoardNotRunning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config:      TestConfig,
+				BitFlyerAPI: tt.bitFlyerAPI,
+			}
+			got, err := b.checkOrderGate(context.Background(), consts.ProductCodeBTCJPY)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("BitFlyerUsecase.checkOrderGate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerUsecase.checkOrderGate() = %v, want %v", got, tt.want)
+			}
+			if code := OrderGateCode(err); code != tt.wantCode {
+				t.Errorf("OrderGateCode() = %q, want %q", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_BuyOrder_orderGate(t *testing.T) {
+	b := &BitFlyerUsecase{
+		Config: TestConfig,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetBoardStateFunc: func(productCode string) (api.BoardStateFromBitFlyer, error) {
+				return api.BoardStateFromBitFlyer{Health: consts.HealthStop, State: consts.BoardStateClosed}, nil
+			},
+		},
+	}
+
+	dto := BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          1000000,
+		Size:           0.001,
+		MinuteToExpire: 43200,
+		TimeInForce:    consts.TimeInForceGTC,
+		IsDry:          false, // 板がCLOSEDなのでSendChildOrderまで到達しない
+	}
+
+	_, got, err := b.BuyOrder(context.Background(), dto)
+	if !errors.Is(err, ErrBoardNotRunning) {
+		t.Errorf("BitFlyerUsecase.BuyOrder() error = %v, want %v", err, ErrBoardNotRunning)
+	}
+	if got != http.StatusServiceUnavailable {
+		t.Errorf("BitFlyerUsecase.BuyOrder() got1 = %v, want %v", got, http.StatusServiceUnavailable)
+	}
+}
+
+func Test_isHealthWithin(t *testing.T) {
+	tests := []struct {
+		name      string
+		health    string
+		maxHealth string
+		want      bool
+	}{
+		{name: "normal within busy", health: consts.HealthNormal, maxHealth: consts.HealthBusy, want: true},
+		{name: "very busy over busy", health: consts.HealthVeryBusy, maxHealth: consts.HealthBusy, want: false},
+		{name: "stop over super busy", health: consts.HealthStop, maxHealth: consts.HealthSuperBusy, want: false},
+		{name: "unknown max health", health: consts.HealthNormal, maxHealth: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isHealthWithin(tt.health, tt.maxHealth); got != tt.want {
+				t.Errorf("isHealthWithin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
+)
+
+const (
+	DefaultOrderHistoryCount = 100
+	DefaultMaxLookupMisses   = 20
+)
+
+// 発注は成功しているため、記録に失敗してもログに残すだけにする
+func (b *BitFlyerUsecase) recordOrder(args api.SendChildOrderRequest, res api.SendChildOrderResponse, isDry bool) {
+	if b.OrderRepository == nil {
+		return
+	}
+
+	now := time.Now()
+	order, err := b.OrderRepository.Create(repository.Order{
+		Request:                args,
+		ChildOrderAcceptanceID: res.ChildOrderAcceptanceID,
+		IsDry:                  isDry,
+		Reconciled:             res.Reconciled,
+		SubmittedAt:            now,
+		UpdatedAt:              now,
+	})
+	if err != nil {
+		log.Printf("Error recording order %s: %v", res.ChildOrderAcceptanceID, err)
+		return
+	}
+	log.Printf("Recorded order %d (acceptance id %q, dry run %v)", order.ID, order.ChildOrderAcceptanceID, isDry)
+}
+
+// 発注した子注文の記録を新しい順に返す。商品と状態は空なら絞り込まない
+func (b *BitFlyerUsecase) GetOrderHistory(productCode, childOrderState string, count int) ([]repository.Order, int, error) {
+	if b.OrderRepository == nil {
+		return nil, http.StatusServiceUnavailable, errors.New("order repository is not configured")
+	}
+
+	if count < 0 || count > consts.MaxPaginationCount {
+		return nil, http.StatusBadRequest, fmt.Errorf("count must be between 0 and %d", consts.MaxPaginationCount)
+	}
+	if count == 0 {
+		count = DefaultOrderHistoryCount
+	}
+
+	filter := repository.OrderFilter{Count: count}
+	if productCode != "" {
+		pc, err := NewProductCode(productCode)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		filter.ProductCode = string(pc)
+	}
+	if childOrderState != "" {
+		state := ChildOrderState(childOrderState)
+		// UNKNOWNは記録だけで使う状態のため、bitFlyerの状態とは別に受け付ける
+		if err := state.validate(); err != nil && childOrderState != repository.OrderStateUnknown {
+			return nil, http.StatusBadRequest, err
+		}
+		filter.State = string(state)
+	}
+	orders, err := b.OrderRepository.List(filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return orders, http.StatusOK, nil
+}
+
+// OrderReconciler は終了していない注文の状態と約定を定期的に取引所から取得して記録に反映する。
+// child_order_eventsを購読している場合はイベントでも記録を更新するため、ここでは取りこぼしを補う
+type OrderReconciler struct {
+	BitFlyerAPI api.IBitFlyerAPI
+	Orders      repository.IOrderRepository
+	// 連続してこの回数だけ見つからなかった注文はUNKNOWNにして突き合わせをやめる
+	MaxMisses int
+	// 結果が分からない発注のIdempotency-Keyも注文一覧と照合して解決する。nilの場合は照合しない
+	Idempotency *IdempotencyStore
+}
+
+func NewOrderReconciler(cfg config.Config, orders repository.IOrderRepository, idempotency *IdempotencyStore) *OrderReconciler {
+	maxMisses := cfg.OrderStore.MaxLookupMisses
+	if maxMisses <= 0 {
+		maxMisses = DefaultMaxLookupMisses
+	}
+	return &OrderReconciler{
+		BitFlyerAPI: api.NewBitFlyerAPI(cfg),
+		Orders:      orders,
+		MaxMisses:   maxMisses,
+		Idempotency: idempotency,
+	}
+}
+
+func (r *OrderReconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.ReconcileOnce(ctx); err != nil {
+				log.Printf("Error reconciling orders: %v", err)
+			}
+		}
+	}
+}
+
+// Private APIの呼び出しを抑えるため、商品ごとにACTIVEの注文一覧を1回だけ取得し、
+// そこにない注文だけを受付IDで個別に確認する。失敗しても残りは続けて突き合わせ、最初のエラーを返す
+func (r *OrderReconciler) ReconcileOnce(ctx context.Context) error {
+	// 解決した発注は記録に加わるため、先に照合しておく
+	idempotencyErr := r.resolveIdempotencyKeys(ctx)
+
+	orders, err := r.Orders.ListOpen()
+	if err != nil {
+		return err
+	}
+
+	byProduct := map[string][]repository.Order{}
+	productCodes := []string{}
+	for _, o := range orders {
+		if _, ok := byProduct[o.Request.ProductCode]; !ok {
+			productCodes = append(productCodes, o.Request.ProductCode)
+		}
+		byProduct[o.Request.ProductCode] = append(byProduct[o.Request.ProductCode], o)
+	}
+
+	firstErr := idempotencyErr
+	for _, productCode := range productCodes {
+		if err := r.reconcileProduct(ctx, productCode, byProduct[productCode]); err != nil {
+			log.Printf("Error reconciling orders for %s: %v", productCode, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (r *OrderReconciler) reconcileProduct(ctx context.Context, productCode string, orders []repository.Order) error {
+	active, err := r.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+		ProductCode:     productCode,
+		ChildOrderState: consts.ChildOrderStateActive,
+		Pagination:      api.Pagination{Count: consts.MaxPaginationCount},
+	})
+	if err != nil {
+		return err
+	}
+	activeByID := make(map[string]api.ChildOrderFromBitFlyer, len(active))
+	for _, c := range active {
+		activeByID[c.ChildOrderAcceptanceID] = c
+	}
+
+	var firstErr error
+	for _, o := range orders {
+		if err := r.reconcile(ctx, o, activeByID); err != nil {
+			log.Printf("Error reconciling order %s: %v", o.ChildOrderAcceptanceID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func (r *OrderReconciler) reconcile(ctx context.Context, o repository.Order, activeByID map[string]api.ChildOrderFromBitFlyer) error {
+	c, ok := activeByID[o.ChildOrderAcceptanceID]
+	if !ok {
+		// ACTIVEでなくなった注文は最終的な状態を個別に取得する
+		found, err := r.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+			ProductCode:            o.Request.ProductCode,
+			ChildOrderAcceptanceID: o.ChildOrderAcceptanceID,
+		})
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			return r.miss(o)
+		}
+		c = found[0]
+	}
+
+	updated, changed := applyChildOrder(o, c)
+	if !changed {
+		return nil
+	}
+	updated.UpdatedAt = time.Now()
+	if err := r.Orders.Update(updated); err != nil {
+		return err
+	}
+	if updated.State != o.State {
+		log.Printf("Order %s: %s -> %s (executed %v/%v)", o.ChildOrderAcceptanceID, o.State, updated.State, updated.ExecutedSize, o.Request.Size)
+	}
+	return nil
+}
+
+// 受付直後は一覧に出てこないことがあるため、見つからなくてもすぐには諦めない。
+// 非同期で拒否された注文や保存期間を過ぎた注文はいつまでも見つからないため、MaxMisses回でUNKNOWNにする
+func (r *OrderReconciler) miss(o repository.Order) error {
+	o.Misses++
+	if r.MaxMisses > 0 && o.Misses >= r.MaxMisses {
+		log.Printf("Order %s was not found %d times, marking it %s", o.ChildOrderAcceptanceID, o.Misses, repository.OrderStateUnknown)
+		o.State = repository.OrderStateUnknown
+	}
+	o.UpdatedAt = time.Now()
+	return r.Orders.Update(o)
+}
+
+func applyChildOrder(o repository.Order, c api.ChildOrderFromBitFlyer) (repository.Order, bool) {
+	updated := o
+	updated.State = c.ChildOrderState
+	updated.ChildOrderID = c.ChildOrderID
+	updated.AveragePrice = c.AveragePrice
+	updated.ExecutedSize = c.ExecutedSize
+	updated.OutstandingSize = c.OutstandingSize
+	updated.CancelSize = c.CancelSize
+	updated.TotalCommission = c.TotalCommission
+	updated.Misses = 0
+	return updated, updated != o
+}
+
+// child_order_eventsで組み立てた状態を発注の記録に反映する。記録にない注文(取引画面からの発注など)は無視する
+func recordOrderEvent(orders repository.IOrderRepository, state OrderState) error {
+	o, err := orders.FindByAcceptanceID(state.ChildOrderAcceptanceID)
+	if errors.Is(err, repository.ErrOrderNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if o.IsDry || !o.IsOpen() {
+		return nil
+	}
+
+	updated := o
+	updated.State = state.ChildOrderState
+	if state.ChildOrderID != "" {
+		updated.ChildOrderID = state.ChildOrderID
+	}
+	// 再起動前の約定はイベントで受け取れないため、取引所から取得した値より少ない場合は上書きしない
+	if state.ExecutedSize > o.ExecutedSize {
+		updated.ExecutedSize = state.ExecutedSize
+		updated.AveragePrice = state.AveragePrice
+		updated.TotalCommission = state.Commission
+		updated.OutstandingSize = math.Max(o.Request.Size-state.ExecutedSize, 0)
+	}
+	updated.Misses = 0
+	if updated == o {
+		return nil
+	}
+	updated.UpdatedAt = time.Now()
+	return orders.Update(updated)
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+	"bitcoin-app-golang/repository"
+)
+
+func TestBitFlyerUsecase_BuyOrder_recordsOrder(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	b := &BitFlyerUsecase{
+		Config: TestConfig,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			SendChildOrderFunc: func(req api.SendChildOrderRequest, isDry bool) (api.SendChildOrderResponse, error) {
+				return api.SendChildOrderResponse{}, nil
+			},
+		},
+		OrderRepository: orders,
+	}
+
+	_, _, err := b.BuyOrder(context.Background(), BuyOrderDTO{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          1000000,
+		Size:           0.001,
+		MinuteToExpire: 1,
+		TimeInForce:    consts.TimeInForceGTC,
+		IsDry:          true,
+	})
+	if err != nil {
+		t.Fatalf("BitFlyerUsecase.BuyOrder() error = %v", err)
+	}
+
+	got, _, err := b.GetOrderHistory("", "", 0)
+	if err != nil {
+		t.Fatalf("BitFlyerUsecase.GetOrderHistory() error = %v", err)
+	}
+	if len(got) != 1 || !got[0].IsDry || got[0].Request.Side != consts.SideBuy || got[0].Request.Price != 1000000 {
+		t.Errorf("BitFlyerUsecase.GetOrderHistory() = %+v, want the dry-run buy order", got)
+	}
+}
+
+func TestBitFlyerUsecase_GetOrderHistory(t *testing.T) {
+	tests := []struct {
+		name            string
+		productCode     string
+		childOrderState string
+		count           int
+		want1           int
+		wantErr         bool
+	}{
+		{name: "success - no filter", want1: http.StatusOK},
+		{name: "success - filter", productCode: consts.ProductCodeBTCJPY, childOrderState: consts.ChildOrderStateActive, count: 10, want1: http.StatusOK},
+		{name: "invalid product code", productCode: "INVALID", want1: http.StatusBadRequest, wantErr: true},
+		{name: "invalid child order state", childOrderState: "INVALID", want1: http.StatusBadRequest, wantErr: true},
+		{name: "count too large", count: consts.MaxPaginationCount + 1, want1: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config:          TestConfig,
+				BitFlyerAPI:     &MockBitFlyerAPI{},
+				OrderRepository: repository.NewMemoryOrderRepository(),
+			}
+			_, got1, err := b.GetOrderHistory(tt.productCode, tt.childOrderState, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetOrderHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetOrderHistory() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestOrderReconciler_ReconcileOnce(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	for _, id := range []string{"JRF-FILLED", "JRF-PENDING"} {
+		if _, err := orders.Create(repository.Order{
+			Request:                api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Size: 0.01},
+			ChildOrderAcceptanceID: id,
+		}); err != nil {
+			t.Fatalf("FileOrderRepository.Create() error = %v", err)
+		}
+	}
+
+	r := &OrderReconciler{
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+				// 受付直後でまだ一覧に出てこない注文
+				if req.ChildOrderAcceptanceID != "JRF-FILLED" {
+					return []api.ChildOrderFromBitFlyer{}, nil
+				}
+				return []api.ChildOrderFromBitFlyer{{
+					ChildOrderID:           "JOR-1",
+					ChildOrderAcceptanceID: req.ChildOrderAcceptanceID,
+					ChildOrderState:        consts.ChildOrderStateCompleted,
+					AveragePrice:           5000000,
+					ExecutedSize:           0.01,
+					TotalCommission:        0.00001,
+				}}, nil
+			},
+		},
+		Orders: orders,
+	}
+
+	if err := r.ReconcileOnce(context.Background()); err != nil {
+		t.Fatalf("OrderReconciler.ReconcileOnce() error = %v", err)
+	}
+
+	got, _ := orders.List(repository.OrderFilter{State: consts.ChildOrderStateCompleted})
+	if len(got) != 1 || got[0].ChildOrderID != "JOR-1" || got[0].ExecutedSize != 0.01 || got[0].AveragePrice != 5000000 {
+		t.Errorf("completed orders = %+v, want the filled order", got)
+	}
+	open, _ := orders.ListOpen()
+	if len(open) != 1 || open[0].ChildOrderAcceptanceID != "JRF-PENDING" {
+		t.Errorf("FileOrderRepository.ListOpen() = %+v, want only the pending order", open)
+	}
+}
+
+func TestOrderReconciler_ReconcileOnce_batchesActiveOrders(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	for _, id := range []string{"JRF-1", "JRF-2", "JRF-3"} {
+		if _, err := orders.Create(repository.Order{
+			Request:                api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Size: 0.01},
+			ChildOrderAcceptanceID: id,
+		}); err != nil {
+			t.Fatalf("FileOrderRepository.Create() error = %v", err)
+		}
+	}
+
+	var requests []api.GetChildOrdersRequest
+	r := &OrderReconciler{
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetChildOrdersFunc: func(req api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+				requests = append(requests, req)
+				return []api.ChildOrderFromBitFlyer{
+					{ChildOrderAcceptanceID: "JRF-1", ChildOrderState: consts.ChildOrderStateActive, ExecutedSize: 0.005},
+					{ChildOrderAcceptanceID: "JRF-2", ChildOrderState: consts.ChildOrderStateActive},
+					{ChildOrderAcceptanceID: "JRF-3", ChildOrderState: consts.ChildOrderStateActive},
+				}, nil
+			},
+		},
+		Orders:    orders,
+		MaxMisses: DefaultMaxLookupMisses,
+	}
+
+	if err := r.ReconcileOnce(context.Background()); err != nil {
+		t.Fatalf("OrderReconciler.ReconcileOnce() error = %v", err)
+	}
+	if len(requests) != 1 || requests[0].ChildOrderState != consts.ChildOrderStateActive {
+		t.Errorf("GetChildOrders() requests = %+v, want one ACTIVE lookup per product", requests)
+	}
+	if got, _ := orders.FindByAcceptanceID("JRF-1"); got.ExecutedSize != 0.005 {
+		t.Errorf("FileOrderRepository.FindByAcceptanceID() = %+v, want the partial fill", got)
+	}
+}
+
+func TestOrderReconciler_ReconcileOnce_marksMissingOrdersUnknown(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	if _, err := orders.Create(repository.Order{
+		Request:                api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Size: 0.01},
+		ChildOrderAcceptanceID: "JRF-GONE",
+	}); err != nil {
+		t.Fatalf("FileOrderRepository.Create() error = %v", err)
+	}
+
+	r := &OrderReconciler{BitFlyerAPI: &MockBitFlyerAPI{}, Orders: orders, MaxMisses: 3}
+	for i := 1; i <= 3; i++ {
+		if err := r.ReconcileOnce(context.Background()); err != nil {
+			t.Fatalf("OrderReconciler.ReconcileOnce() error = %v", err)
+		}
+		open, _ := orders.ListOpen()
+		if wantOpen := i < 3; (len(open) == 1) != wantOpen {
+			t.Fatalf("after %d lookups ListOpen() = %+v, want open %v", i, open, wantOpen)
+		}
+	}
+
+	got, _ := orders.FindByAcceptanceID("JRF-GONE")
+	if got.State != repository.OrderStateUnknown || got.Misses != 3 {
+		t.Errorf("FileOrderRepository.FindByAcceptanceID() = %+v, want %s after 3 misses", got, repository.OrderStateUnknown)
+	}
+	if _, _, err := (&BitFlyerUsecase{OrderRepository: orders}).GetOrderHistory("", repository.OrderStateUnknown, 0); err != nil {
+		t.Errorf("BitFlyerUsecase.GetOrderHistory() error = %v, want UNKNOWN to be a valid filter", err)
+	}
+}
+
+func TestOrderEventUsecase_HandleChildOrderEvents_updatesOrderHistory(t *testing.T) {
+	orders := repository.NewMemoryOrderRepository()
+	if _, err := orders.Create(repository.Order{
+		Request:                api.SendChildOrderRequest{ProductCode: consts.ProductCodeBTCJPY, Side: consts.SideBuy, Size: 0.01},
+		ChildOrderAcceptanceID: "JRF-1",
+	}); err != nil {
+		t.Fatalf("FileOrderRepository.Create() error = %v", err)
+	}
+
+	o := newOrderEventUsecase(TestConfig, &MockLineAPI{}, orders)
+	defer o.Close()
+	o.States = NewOrderStateStore()
+	o.HandleChildOrderEvents([]api.ChildOrderEventFromBitFlyer{
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-1", ChildOrderID: "JOR-1", EventType: consts.OrderEventTypeOrder, Side: consts.SideBuy, Price: 5000000, Size: 0.01},
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-1", EventType: consts.OrderEventTypeExecution, Side: consts.SideBuy, Price: 5000000, Size: 0.01},
+		// 記録にない注文は無視する
+		{ProductCode: consts.ProductCodeBTCJPY, ChildOrderAcceptanceID: "JRF-OTHER", EventType: consts.OrderEventTypeCancel},
+	})
+
+	got, _ := orders.FindByAcceptanceID("JRF-1")
+	if got.State != consts.ChildOrderStateCompleted || got.ExecutedSize != 0.01 || got.ChildOrderID != "JOR-1" {
+		t.Errorf("FileOrderRepository.FindByAcceptanceID() = %+v, want the completed order", got)
+	}
+	if open, _ := orders.ListOpen(); len(open) != 0 {
+		t.Errorf("FileOrderRepository.ListOpen() = %+v, want no open orders to poll", open)
+	}
+}
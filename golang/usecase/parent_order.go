@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+// IFDは1つ目の注文が約定したら2つ目を発注、OCOは片方が約定したらもう片方をキャンセル、
+// IFDOCOは1つ目が約定したら2つ目と3つ目をOCOで発注する
+type ParentOrderDTO struct {
+	OrderMethod    OrderMethod               `json:"order_method"`
+	MinuteToExpire MinuteToExpire            `json:"minute_to_expire"`
+	TimeInForce    TimeInForce               `json:"time_in_force"`
+	Parameters     []ParentOrderParameterDTO `json:"parameters"`
+	IsDry          bool                      `json:"is_dry"`
+}
+
+type ParentOrderParameterDTO struct {
+	ProductCode   ProductCode   `json:"product_code"`
+	ConditionType ConditionType `json:"condition_type"`
+	Side          Side          `json:"side"`
+	Size          float64       `json:"size"`
+	Price         float64       `json:"price"`
+	TriggerPrice  float64       `json:"trigger_price"`
+	Offset        float64       `json:"offset"`
+}
+
+// ParentOrderIDとParentOrderAcceptanceIDはどちらか一方を指定する
+type CancelParentOrderDTO struct {
+	ProductCode             ProductCode `json:"product_code"`
+	ParentOrderID           string      `json:"parent_order_id"`
+	ParentOrderAcceptanceID string      `json:"parent_order_acceptance_id"`
+	IsDry                   bool        `json:"is_dry"`
+}
+
+func (b *BitFlyerUsecase) SendParentOrder(ctx context.Context, dto ParentOrderDTO) (api.SendParentOrderResponse, int, error) {
+	if statusCode, err := b.checkKillSwitch("parent", dto, dto.IsDry); err != nil {
+		return api.SendParentOrderResponse{}, statusCode, err
+	}
+
+	if err := validateParentOrder(dto); err != nil {
+		return api.SendParentOrderResponse{}, http.StatusBadRequest, err
+	}
+
+	if !dto.IsDry {
+		checked := map[ProductCode]bool{}
+		for _, p := range dto.Parameters {
+			if checked[p.ProductCode] {
+				continue
+			}
+			if statusCode, err := b.checkOrderGate(ctx, p.ProductCode); err != nil {
+				return api.SendParentOrderResponse{}, statusCode, err
+			}
+			checked[p.ProductCode] = true
+		}
+
+		orders := make([]riskOrder, 0, len(dto.Parameters))
+		for _, p := range dto.Parameters {
+			orders = append(orders, parentOrderRisk(p))
+		}
+		if statusCode, err := b.checkRisk(ctx, orders...); err != nil {
+			return api.SendParentOrderResponse{}, statusCode, err
+		}
+	}
+
+	params := make([]api.ParentOrderParameter, 0, len(dto.Parameters))
+	for _, p := range dto.Parameters {
+		params = append(params, api.ParentOrderParameter{
+			ProductCode:   string(p.ProductCode),
+			ConditionType: string(p.ConditionType),
+			Side:          string(p.Side),
+			Size:          p.Size,
+			Price:         p.Price,
+			TriggerPrice:  p.TriggerPrice,
+			Offset:        p.Offset,
+		})
+	}
+
+	args := api.SendParentOrderRequest{
+		OrderMethod:    string(dto.OrderMethod),
+		MinuteToExpire: int(dto.MinuteToExpire),
+		TimeInForce:    string(dto.TimeInForce),
+		Parameters:     params,
+	}
+
+	var res api.SendParentOrderResponse
+	send := func() error {
+		var err error
+		res, err = b.BitFlyerAPI.SendParentOrder(ctx, args, dto.IsDry)
+		return err
+	}
+
+	// 子注文と同じく、送信の直前にキルスイッチを確認してから送る
+	if dto.IsDry {
+		if err := send(); err != nil {
+			return api.SendParentOrderResponse{}, statusFromError(err), err
+		}
+	} else if statusCode, err := b.sendUnlessKillSwitch("parent", dto, send); err != nil {
+		return api.SendParentOrderResponse{}, statusCode, err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetParentOrders(ctx context.Context, productCode, parentOrderState string, p api.Pagination) ([]api.ParentOrderFromBitFlyer, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	state := ParentOrderState(parentOrderState)
+	if err := state.validate(); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	if err := validatePagination(p, 1); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	res, err := b.BitFlyerAPI.GetParentOrders(ctx, api.GetParentOrdersRequest{
+		ProductCode:      string(pc),
+		ParentOrderState: string(state),
+		Pagination:       p,
+	})
+	if err != nil {
+		return nil, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) GetParentOrder(ctx context.Context, acceptanceID string) (api.ParentOrderDetailFromBitFlyer, int, error) {
+	if acceptanceID == "" {
+		return api.ParentOrderDetailFromBitFlyer{}, http.StatusBadRequest, errors.New("parent order acceptance id is empty")
+	}
+
+	res, err := b.BitFlyerAPI.GetParentOrder(ctx, api.GetParentOrderRequest{
+		ParentOrderAcceptanceID: acceptanceID,
+	})
+	if err != nil {
+		return api.ParentOrderDetailFromBitFlyer{}, statusFromError(err), err
+	}
+
+	return res, http.StatusOK, nil
+}
+
+func (b *BitFlyerUsecase) CancelParentOrder(ctx context.Context, dto CancelParentOrderDTO) (int, error) {
+	if err := validateCancelParentOrder(dto); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	args := api.CancelParentOrderRequest{
+		ProductCode:             string(dto.ProductCode),
+		ParentOrderID:           dto.ParentOrderID,
+		ParentOrderAcceptanceID: dto.ParentOrderAcceptanceID,
+	}
+
+	if err := b.BitFlyerAPI.CancelParentOrder(ctx, args, dto.IsDry); err != nil {
+		return statusFromError(err), err
+	}
+
+	return http.StatusOK, nil
+}
+
+// 成行とトレール注文は気配値、逆指値はトリガー価格、ストップ・リミットは指値で金額を見積もる。
+// 価格乖離のチェックは指値にだけ適用する
+func parentOrderRisk(p ParentOrderParameterDTO) riskOrder {
+	order := riskOrder{
+		ProductCode:    p.ProductCode,
+		Side:           string(p.Side),
+		ChildOrderType: ChildOrderType(p.ConditionType),
+		Price:          p.Price,
+		Size:           p.Size,
+	}
+	switch p.ConditionType {
+	case consts.ConditionTypeStop:
+		order.Price = p.TriggerPrice
+	case consts.ConditionTypeTrail:
+		order.ChildOrderType = consts.ChildOrderTypeMarket
+	}
+	return order
+}
+
+func validateParentOrder(dto ParentOrderDTO) error {
+	if err := dto.OrderMethod.validate(); err != nil {
+		return err
+	}
+	if err := dto.TimeInForce.validate(); err != nil {
+		return err
+	}
+	if err := dto.MinuteToExpire.validate(); err != nil {
+		return err
+	}
+	if want := dto.OrderMethod.parameterCount(); len(dto.Parameters) != want {
+		return fmt.Errorf("%s order requires %d parameters, got %d", dto.OrderMethod, want, len(dto.Parameters))
+	}
+	for i, p := range dto.Parameters {
+		if err := validateParentOrderParameter(p); err != nil {
+			return fmt.Errorf("parameters[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateParentOrderParameter(p ParentOrderParameterDTO) error {
+	if err := p.ProductCode.validate(); err != nil {
+		return err
+	}
+	if err := p.ConditionType.validate(); err != nil {
+		return err
+	}
+	if err := p.Side.validate(); err != nil {
+		return err
+	}
+	if p.Size <= 0 {
+		return errors.New("size must be greater than 0")
+	}
+
+	switch p.ConditionType {
+	case consts.ConditionTypeLimit:
+		if p.Price <= 0 {
+			return errors.New("price must be greater than 0 for LIMIT orders")
+		}
+	case consts.ConditionTypeStop:
+		if p.TriggerPrice <= 0 {
+			return errors.New("trigger price must be greater than 0 for STOP orders")
+		}
+	case consts.ConditionTypeStopLimit:
+		if p.Price <= 0 {
+			return errors.New("price must be greater than 0 for STOP_LIMIT orders")
+		}
+		if p.TriggerPrice <= 0 {
+			return errors.New("trigger price must be greater than 0 for STOP_LIMIT orders")
+		}
+	case consts.ConditionTypeTrail:
+		if p.Offset <= 0 {
+			return errors.New("offset must be greater than 0 for TRAIL orders")
+		}
+	}
+	return nil
+}
+
+func validateCancelParentOrder(dto CancelParentOrderDTO) error {
+	if err := dto.ProductCode.validate(); err != nil {
+		return err
+	}
+	if dto.ParentOrderID == "" && dto.ParentOrderAcceptanceID == "" {
+		return errors.New("parent order id or parent order acceptance id is required")
+	}
+	if dto.ParentOrderID != "" && dto.ParentOrderAcceptanceID != "" {
+		return errors.New("specify either parent order id or parent order acceptance id, not both")
+	}
+	return nil
+}
+
+type OrderMethod string
+
+func (o OrderMethod) validate() error {
+	switch o {
+	case consts.OrderMethodSimple, consts.OrderMethodIFD, consts.OrderMethodOCO, consts.OrderMethodIFDOCO:
+		return nil
+	default:
+		return errors.New("invalid order method")
+	}
+}
+
+func (o OrderMethod) parameterCount() int {
+	switch o {
+	case consts.OrderMethodSimple:
+		return 1
+	case consts.OrderMethodIFD, consts.OrderMethodOCO:
+		return 2
+	case consts.OrderMethodIFDOCO:
+		return 3
+	default:
+		return 0
+	}
+}
+
+type ConditionType string
+
+func (c ConditionType) validate() error {
+	switch c {
+	case consts.ConditionTypeLimit, consts.ConditionTypeMarket, consts.ConditionTypeStop,
+		consts.ConditionTypeStopLimit, consts.ConditionTypeTrail:
+		return nil
+	default:
+		return errors.New("invalid condition type")
+	}
+}
+
+type Side string
+
+func (s Side) validate() error {
+	switch s {
+	case consts.SideBuy, consts.SideSell:
+		return nil
+	default:
+		return errors.New("invalid side")
+	}
+}
+
+// 親注文の状態は子注文と同じ値をとる
+type ParentOrderState string
+
+func (p ParentOrderState) validate() error {
+	if err := ChildOrderState(p).validate(); err != nil {
+		return errors.New("invalid parent order state")
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_SendParentOrder(t *testing.T) {
+	ifdoco := ParentOrderDTO{
+		OrderMethod:    consts.OrderMethodIFDOCO,
+		MinuteToExpire: 10000,
+		TimeInForce:    consts.TimeInForceGTC,
+		Parameters: []ParentOrderParameterDTO{
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeLimit, Side: consts.SideBuy, Size: 0.01, Price: 5000000},
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeLimit, Side: consts.SideSell, Size: 0.01, Price: 5100000},
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeStopLimit, Side: consts.SideSell, Size: 0.01, Price: 4890000, TriggerPrice: 4900000},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		dto         ParentOrderDTO
+		want        api.SendParentOrderResponse
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "IFDOCO",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				SendParentOrderFunc: func(req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error) {
+					if req.OrderMethod != consts.OrderMethodIFDOCO || len(req.Parameters) != 3 {
+						return api.SendParentOrderResponse{}, errors.New("unexpected request")
+					}
+					if req.Parameters[2].TriggerPrice != 4900000 {
+						return api.SendParentOrderResponse{}, errors.New("trigger price is not passed through")
+					}
+					return api.SendParentOrderResponse{ParentOrderAcceptanceID: "JRF20150925-060559-396699"}, nil
+				},
+			},
+			dto:     ifdoco,
+			want:    api.SendParentOrderResponse{ParentOrderAcceptanceID: "JRF20150925-060559-396699"},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "order gate refuses",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetBoardStateFunc: func(productCode string) (api.BoardStateFromBitFlyer, error) {
+					return api.BoardStateFromBitFlyer{Health: consts.HealthNormal, State: consts.BoardStateClosed}, nil
+				},
+			},
+			dto:     ifdoco,
+			want:    api.SendParentOrderResponse{},
+			want1:   http.StatusServiceUnavailable,
+			wantErr: true,
+		},
+		{
+			name:        "invalid parameter",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			dto: ParentOrderDTO{
+				OrderMethod:    consts.OrderMethodSimple,
+				MinuteToExpire: 10000,
+				TimeInForce:    consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeTrail, Side: consts.SideSell, Size: 0.01},
+				},
+			},
+			want:    api.SendParentOrderResponse{},
+			want1:   http.StatusBadRequest,
+			wantErr: true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				SendParentOrderFunc: func(req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error) {
+					return api.SendParentOrderResponse{}, errors.New("api error")
+				},
+			},
+			dto:     ifdoco,
+			want:    api.SendParentOrderResponse{},
+			want1:   http.StatusInternalServerError,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.SendParentOrder(context.Background(), tt.dto)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.SendParentOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.SendParentOrder() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.SendParentOrder() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_SendParentOrder_risk(t *testing.T) {
+	cfg := TestConfig
+	cfg.Risk = config.Risk{MaxPosition: map[string]float64{consts.ProductCodeBTCJPY: 0.1}}
+	sent := 0
+	b := &BitFlyerUsecase{
+		Config: cfg,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetBalanceFunc: func() ([]api.BalanceFromBitFlyer, error) {
+				return []api.BalanceFromBitFlyer{{CurrencyCode: "BTC", Amount: 0.05}}, nil
+			},
+			SendParentOrderFunc: func(req api.SendParentOrderRequest, isDry bool) (api.SendParentOrderResponse, error) {
+				sent++
+				return api.SendParentOrderResponse{}, nil
+			},
+		},
+	}
+	// どちらの買い注文も単独なら上限内だが、両方約定すると上限を超える
+	dto := ParentOrderDTO{
+		OrderMethod:    consts.OrderMethodOCO,
+		MinuteToExpire: 10000,
+		TimeInForce:    consts.TimeInForceGTC,
+		Parameters: []ParentOrderParameterDTO{
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeLimit, Side: consts.SideBuy, Size: 0.03, Price: 4900000},
+			{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeStop, Side: consts.SideBuy, Size: 0.03, TriggerPrice: 5100000},
+		},
+	}
+
+	_, got1, err := b.SendParentOrder(context.Background(), dto)
+	var rejection *RiskRejection
+	if !errors.As(err, &rejection) || rejection.Rule != RiskRuleMaxPosition || got1 != http.StatusUnprocessableEntity {
+		t.Fatalf("BitFlyerUsecase.SendParentOrder() = %v, %v, want %v rejection", got1, err, RiskRuleMaxPosition)
+	}
+	if sent != 0 {
+		t.Errorf("SendParentOrder called %d times, want 0", sent)
+	}
+
+	// ドライランは取引所に送らないためチェックしない
+	dto.IsDry = true
+	if _, got1, err := b.SendParentOrder(context.Background(), dto); err != nil || got1 != http.StatusOK {
+		t.Errorf("BitFlyerUsecase.SendParentOrder() dry run = %v, %v, want 200", got1, err)
+	}
+}
+
+func TestBitFlyerUsecase_GetParentOrders(t *testing.T) {
+	orders := []api.ParentOrderFromBitFlyer{
+		{ID: 4242, ParentOrderID: "JCP20150825-046876-036161", ProductCode: consts.ProductCodeBTCJPY, ParentOrderType: consts.OrderMethodIFDOCO},
+	}
+
+	tests := []struct {
+		name             string
+		productCode      string
+		parentOrderState string
+		want             []api.ParentOrderFromBitFlyer
+		want1            int
+		wantErr          bool
+	}{
+		{name: "success", productCode: consts.ProductCodeBTCJPY, parentOrderState: consts.ChildOrderStateActive, want: orders, want1: http.StatusOK, wantErr: false},
+		{name: "invalid state", productCode: consts.ProductCodeBTCJPY, parentOrderState: "INVALID", want: nil, want1: http.StatusBadRequest, wantErr: true},
+		{name: "invalid product code", productCode: "invalid", want: nil, want1: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config: TestConfig,
+				BitFlyerAPI: &MockBitFlyerAPI{
+					GetParentOrdersFunc: func(req api.GetParentOrdersRequest) ([]api.ParentOrderFromBitFlyer, error) {
+						return orders, nil
+					},
+				},
+			}
+			got, got1, err := b.GetParentOrders(context.Background(), tt.productCode, tt.parentOrderState, api.Pagination{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetParentOrders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetParentOrders() = %v, want %v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetParentOrders() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_CancelParentOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		dto     CancelParentOrderDTO
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "success",
+			dto:     CancelParentOrderDTO{ProductCode: consts.ProductCodeBTCJPY, ParentOrderAcceptanceID: "JRF20150925-060559-396699", IsDry: true},
+			want:    http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:    "no id",
+			dto:     CancelParentOrderDTO{ProductCode: consts.ProductCodeBTCJPY},
+			want:    http.StatusBadRequest,
+			wantErr: true,
+		},
+		{
+			name: "both ids",
+			dto: CancelParentOrderDTO{
+				ProductCode:             consts.ProductCodeBTCJPY,
+				ParentOrderID:           "JCP20150825-046876-036161",
+				ParentOrderAcceptanceID: "JRF20150925-060559-396699",
+			},
+			want:    http.StatusBadRequest,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: &MockBitFlyerAPI{}}
+			got, err := b.CancelParentOrder(context.Background(), tt.dto)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.CancelParentOrder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("BitFlyerUsecase.CancelParentOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateParentOrder(t *testing.T) {
+	limit := func(side Side, price float64) ParentOrderParameterDTO {
+		return ParentOrderParameterDTO{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeLimit, Side: side, Size: 0.01, Price: price}
+	}
+
+	tests := []struct {
+		name    string
+		dto     ParentOrderDTO
+		wantErr bool
+	}{
+		{
+			name: "IFD",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodIFD, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{limit(consts.SideBuy, 5000000), limit(consts.SideSell, 5100000)},
+			},
+			wantErr: false,
+		},
+		{
+			name: "OCO with STOP and TRAIL",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodOCO, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeStop, Side: consts.SideSell, Size: 0.01, TriggerPrice: 4900000},
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeTrail, Side: consts.SideSell, Size: 0.01, Offset: 50000},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid order method",
+			dto: ParentOrderDTO{
+				OrderMethod: "INVALID", MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{limit(consts.SideBuy, 5000000)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "wrong parameter count",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodIFDOCO, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{limit(consts.SideBuy, 5000000), limit(consts.SideSell, 5100000)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "STOP without trigger price",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodSimple, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeStop, Side: consts.SideSell, Size: 0.01},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "STOP_LIMIT without price",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodSimple, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeStopLimit, Side: consts.SideSell, Size: 0.01, TriggerPrice: 4900000},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid side",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodSimple, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{limit("HOLD", 5000000)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero size",
+			dto: ParentOrderDTO{
+				OrderMethod: consts.OrderMethodSimple, MinuteToExpire: 10000, TimeInForce: consts.TimeInForceGTC,
+				Parameters: []ParentOrderParameterDTO{
+					{ProductCode: consts.ProductCodeBTCJPY, ConditionType: consts.ConditionTypeMarket, Side: consts.SideBuy},
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateParentOrder(tt.dto); (err != nil) != tt.wantErr {
+				t.Errorf("validateParentOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
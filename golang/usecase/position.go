@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+type PositionSummary struct {
+	ProductCode string `json:"product_code"`
+	// 買い建玉を正、売り建玉を負とした合計サイズ
+	NetSize float64 `json:"net_size"`
+	// 建玉がない場合は空文字
+	Side string `json:"side"`
+	// NetSizeの方向の建玉のサイズ加重平均価格
+	AveragePrice        float64                    `json:"average_price"`
+	Ltp                 float64                    `json:"ltp"`
+	UnrealizedPnL       float64                    `json:"unrealized_pnl"`
+	SwapPointAccumulate float64                    `json:"swap_point_accumulate"`
+	Commission          float64                    `json:"commission"`
+	RequireCollateral   float64                    `json:"require_collateral"`
+	Positions           []api.PositionFromBitFlyer `json:"positions"`
+}
+
+func (b *BitFlyerUsecase) GetPositions(ctx context.Context, productCode string) (PositionSummary, int, error) {
+	pc, err := NewProductCode(productCode)
+	if err != nil {
+		return PositionSummary{}, http.StatusBadRequest, err
+	}
+
+	positions, err := b.BitFlyerAPI.GetPositions(ctx, string(pc))
+	if err != nil {
+		return PositionSummary{}, statusFromError(err), err
+	}
+
+	summary := summarizePositions(string(pc), positions)
+	if len(positions) == 0 {
+		return summary, http.StatusOK, nil
+	}
+
+	ticker, err := b.BitFlyerAPI.GetTicker(ctx, string(pc))
+	if err != nil {
+		return PositionSummary{}, statusFromError(err), err
+	}
+
+	summary.Ltp = ticker.Ltp
+	summary.UnrealizedPnL = (ticker.Ltp - summary.AveragePrice) * summary.NetSize
+
+	return summary, http.StatusOK, nil
+}
+
+func summarizePositions(productCode string, positions []api.PositionFromBitFlyer) PositionSummary {
+	summary := PositionSummary{
+		ProductCode: productCode,
+		Positions:   positions,
+	}
+	if summary.Positions == nil {
+		summary.Positions = []api.PositionFromBitFlyer{}
+	}
+
+	var buySize, buyCost, sellSize, sellCost float64
+	for _, p := range positions {
+		switch p.Side {
+		case consts.SideBuy:
+			buySize += p.Size
+			buyCost += p.Price * p.Size
+		case consts.SideSell:
+			sellSize += p.Size
+			sellCost += p.Price * p.Size
+		}
+		summary.SwapPointAccumulate += p.SwapPointAccumulate
+		summary.Commission += p.Commission
+		summary.RequireCollateral += p.RequireCollateral
+	}
+
+	summary.NetSize = buySize - sellSize
+	switch {
+	case summary.NetSize > 0:
+		summary.Side = consts.SideBuy
+		summary.AveragePrice = buyCost / buySize
+	case summary.NetSize < 0:
+		summary.Side = consts.SideSell
+		summary.AveragePrice = sellCost / sellSize
+	}
+
+	return summary
+}
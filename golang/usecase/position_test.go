@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_GetPositions(t *testing.T) {
+	positions := []api.PositionFromBitFlyer{
+		{ProductCode: consts.ProductCodeFXBTCJPY, Side: consts.SideBuy, Price: 5000000, Size: 0.1, Commission: 0, SwapPointAccumulate: -10, RequireCollateral: 125000},
+		{ProductCode: consts.ProductCodeFXBTCJPY, Side: consts.SideBuy, Price: 5200000, Size: 0.3, Commission: 0, SwapPointAccumulate: -25, RequireCollateral: 390000},
+	}
+
+	tests := []struct {
+		name        string
+		bitFlyerAPI api.IBitFlyerAPI
+		productCode string
+		want        PositionSummary
+		want1       int
+		wantErr     bool
+	}{
+		{
+			name: "success",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetPositionsFunc: func(productCode string) ([]api.PositionFromBitFlyer, error) {
+					return positions, nil
+				},
+				GetTickerFunc: func(productCode string) (api.TickerFromBitFlyer, error) {
+					return api.TickerFromBitFlyer{ProductCode: productCode, Ltp: 5250000}, nil
+				},
+			},
+			productCode: consts.ProductCodeFXBTCJPY,
+			want: PositionSummary{
+				ProductCode:         consts.ProductCodeFXBTCJPY,
+				NetSize:             0.4,
+				Side:                consts.SideBuy,
+				AveragePrice:        5150000,
+				Ltp:                 5250000,
+				UnrealizedPnL:       40000,
+				SwapPointAccumulate: -35,
+				Commission:          0,
+				RequireCollateral:   515000,
+				Positions:           positions,
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name: "no positions does not fetch ticker",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetTickerFunc: func(productCode string) (api.TickerFromBitFlyer, error) {
+					return api.TickerFromBitFlyer{}, errors.New("ticker must not be fetched")
+				},
+			},
+			productCode: consts.ProductCodeFXBTCJPY,
+			want: PositionSummary{
+				ProductCode: consts.ProductCodeFXBTCJPY,
+				Positions:   []api.PositionFromBitFlyer{},
+			},
+			want1:   http.StatusOK,
+			wantErr: false,
+		},
+		{
+			name:        "invalid product code",
+			bitFlyerAPI: &MockBitFlyerAPI{},
+			productCode: "invalid",
+			want:        PositionSummary{},
+			want1:       http.StatusBadRequest,
+			wantErr:     true,
+		},
+		{
+			name: "api error",
+			bitFlyerAPI: &MockBitFlyerAPI{
+				GetPositionsFunc: func(productCode string) ([]api.PositionFromBitFlyer, error) {
+					return nil, errors.New("api error")
+				},
+			},
+			productCode: consts.ProductCodeFXBTCJPY,
+			want:        PositionSummary{},
+			want1:       http.StatusInternalServerError,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{Config: TestConfig, BitFlyerAPI: tt.bitFlyerAPI}
+			got, got1, err := b.GetPositions(context.Background(), tt.productCode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("BitFlyerUsecase.GetPositions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BitFlyerUsecase.GetPositions() = %+v, want %+v", got, tt.want)
+			}
+			if got1 != tt.want1 {
+				t.Errorf("BitFlyerUsecase.GetPositions() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+
+func Test_summarizePositions(t *testing.T) {
+	tests := []struct {
+		name          string
+		positions     []api.PositionFromBitFlyer
+		wantNetSize   float64
+		wantSide      string
+		wantAvgPrice  float64
+		wantSwapPoint float64
+	}{
+		{
+			name: "sell positions",
+			positions: []api.PositionFromBitFlyer{
+				{Side: consts.SideSell, Price: 5000000, Size: 0.5, SwapPointAccumulate: -5},
+			},
+			wantNetSize:   -0.5,
+			wantSide:      consts.SideSell,
+			wantAvgPrice:  5000000,
+			wantSwapPoint: -5,
+		},
+		{
+			name: "flat",
+			positions: []api.PositionFromBitFlyer{
+				{Side: consts.SideBuy, Price: 5000000, Size: 0.5},
+				{Side: consts.SideSell, Price: 5100000, Size: 0.5},
+			},
+			wantNetSize:  0,
+			wantSide:     "",
+			wantAvgPrice: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarizePositions(consts.ProductCodeFXBTCJPY, tt.positions)
+			if got.NetSize != tt.wantNetSize || got.Side != tt.wantSide || got.AveragePrice != tt.wantAvgPrice || got.SwapPointAccumulate != tt.wantSwapPoint {
+				t.Errorf("summarizePositions() = %+v", got)
+			}
+		})
+	}
+}
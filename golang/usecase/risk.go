@@ -0,0 +1,517 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/consts"
+)
+
+var ErrRiskRejected = errors.New("order rejected by risk check")
+
+type RiskRule string
+
+const (
+	RiskRuleMaxOrderNotional RiskRule = "max_order_notional"
+	RiskRuleMaxPosition      RiskRule = "max_position"
+	RiskRuleMaxOpenOrders    RiskRule = "max_open_orders"
+	RiskRuleMaxDailyLoss     RiskRule = "max_daily_loss"
+	RiskRulePriceCollar      RiskRule = "price_collar"
+)
+
+// 日次損失の集計はJSTの0時で区切る
+var jst = time.FixedZone("JST", 9*60*60)
+
+// 0時をまたいで持ち越した建玉の平均取得単価を見積もるために遡る期間
+const dailyLossLookback = 7 * 24 * time.Hour
+
+// 日次損失の計算には商品ごとに約定履歴を何ページも取得するため、この間は前回の結果を使う。
+// 直前の約定が損失に反映されるのはこの時間だけ遅れる
+const dailyPnLCacheTTL = 30 * time.Second
+
+// RiskRejection はどのチェックにどの値で引っかかったかを返すエラー
+type RiskRejection struct {
+	Rule        RiskRule `json:"rule"`
+	ProductCode string   `json:"product_code"`
+	Limit       float64  `json:"limit"`
+	Actual      float64  `json:"actual"`
+	Message     string   `json:"message"`
+}
+
+func (r *RiskRejection) Error() string {
+	return fmt.Sprintf("%v: %s", ErrRiskRejected, r.Message)
+}
+
+func (r *RiskRejection) Is(target error) bool {
+	return target == ErrRiskRejected
+}
+
+type riskOrder struct {
+	ProductCode    ProductCode
+	Side           string
+	ChildOrderType ChildOrderType
+	Price          float64
+	Size           float64
+}
+
+// 実際に取引所へ送る注文だけを設定の上限と照らし合わせる。必要な情報だけをAPIから取得する。
+// 特殊注文のように複数の注文を渡した場合は、すべてが約定したときの建玉で上限を確かめる
+func (b *BitFlyerUsecase) checkRisk(ctx context.Context, orders ...riskOrder) (int, error) {
+	limits := b.Config.Risk
+
+	if len(limits.MaxOrderNotional) > 0 || limits.PriceCollarPercent > 0 {
+		for _, order := range orders {
+			maxNotional, rejection := quoteLimit(limits.MaxOrderNotional, RiskRuleMaxOrderNotional, order.ProductCode)
+			if rejection != nil {
+				return http.StatusUnprocessableEntity, rejection
+			}
+			ticker, err := b.BitFlyerAPI.GetTicker(ctx, string(order.ProductCode))
+			if err != nil {
+				return statusFromError(err), err
+			}
+			if rejection := checkPriceCollar(order, ticker, limits.PriceCollarPercent); rejection != nil {
+				return http.StatusUnprocessableEntity, rejection
+			}
+			if rejection := checkOrderNotional(order, ticker, maxNotional); rejection != nil {
+				return http.StatusUnprocessableEntity, rejection
+			}
+		}
+	}
+
+	for _, exposure := range combinedExposures(orders) {
+		maxPosition := limits.MaxPosition[string(exposure.ProductCode)]
+		if maxPosition <= 0 {
+			continue
+		}
+		position, err := b.currentPosition(ctx, exposure.ProductCode)
+		if err != nil {
+			return statusFromError(err), err
+		}
+		if rejection := checkPosition(exposure, position, maxPosition); rejection != nil {
+			return http.StatusUnprocessableEntity, rejection
+		}
+	}
+
+	if limits.MaxOpenOrders > 0 {
+		for _, productCode := range uniqueProductCodes(orders) {
+			if statusCode, err := b.checkOpenOrders(ctx, productCode, limits.MaxOpenOrders); err != nil {
+				return statusCode, err
+			}
+		}
+	}
+
+	if len(limits.MaxDailyLoss) > 0 {
+		checked := map[string]bool{}
+		for _, productCode := range uniqueProductCodes(orders) {
+			if checked[quoteCurrency(string(productCode))] {
+				continue
+			}
+			checked[quoteCurrency(string(productCode))] = true
+
+			maxLoss, rejection := quoteLimit(limits.MaxDailyLoss, RiskRuleMaxDailyLoss, productCode)
+			if rejection != nil {
+				return http.StatusUnprocessableEntity, rejection
+			}
+			if maxLoss <= 0 {
+				continue
+			}
+			loss, err := b.dailyLoss(ctx, productCode, time.Now())
+			if err != nil {
+				return statusFromError(err), err
+			}
+			if loss >= maxLoss {
+				return http.StatusUnprocessableEntity, &RiskRejection{
+					Rule:        RiskRuleMaxDailyLoss,
+					ProductCode: string(productCode),
+					Limit:       maxLoss,
+					Actual:      loss,
+					Message:     fmt.Sprintf("daily realized loss %v %s reached the limit %v", loss, quoteCurrency(string(productCode)), maxLoss),
+				}
+			}
+		}
+	}
+
+	return http.StatusOK, nil
+}
+
+// 金額の上限は決済通貨ごとに設定する。上限を設定していながら決済通貨が含まれていない場合は、
+// 別の通貨建ての上限と比べてしまわないよう注文を拒否する
+func quoteLimit(limits map[string]float64, rule RiskRule, productCode ProductCode) (float64, *RiskRejection) {
+	if len(limits) == 0 {
+		return 0, nil
+	}
+	quote := quoteCurrency(string(productCode))
+	if limit, ok := limits[quote]; ok {
+		return limit, nil
+	}
+	return 0, &RiskRejection{
+		Rule:        rule,
+		ProductCode: string(productCode),
+		Message:     fmt.Sprintf("no %s limit is configured for the quote currency %s", rule, quote),
+	}
+}
+
+func (b *BitFlyerUsecase) checkOpenOrders(ctx context.Context, productCode ProductCode, maxOpenOrders int) (int, error) {
+	count := min(maxOpenOrders, consts.MaxPaginationCount)
+	orders, err := b.BitFlyerAPI.GetChildOrders(ctx, api.GetChildOrdersRequest{
+		ProductCode:     string(productCode),
+		ChildOrderState: consts.ChildOrderStateActive,
+		Pagination:      api.Pagination{Count: count},
+	})
+	if err != nil {
+		return statusFromError(err), err
+	}
+	// 特殊注文は子注文を出す前から板に影響するため、有効な親注文も数える
+	parentOrders, err := b.BitFlyerAPI.GetParentOrders(ctx, api.GetParentOrdersRequest{
+		ProductCode:      string(productCode),
+		ParentOrderState: consts.ChildOrderStateActive,
+		Pagination:       api.Pagination{Count: count},
+	})
+	if err != nil {
+		return statusFromError(err), err
+	}
+	if open := len(orders) + len(parentOrders); open >= maxOpenOrders {
+		return http.StatusUnprocessableEntity, &RiskRejection{
+			Rule:        RiskRuleMaxOpenOrders,
+			ProductCode: string(productCode),
+			Limit:       float64(maxOpenOrders),
+			Actual:      float64(open),
+			Message:     fmt.Sprintf("%d open orders already exist (limit %d)", open, maxOpenOrders),
+		}
+	}
+	return http.StatusOK, nil
+}
+
+// 商品と売買の向きごとに数量を合計する。OCOのように片方しか約定しない注文も両方約定するとみなす
+func combinedExposures(orders []riskOrder) []riskOrder {
+	exposures := []riskOrder{}
+	index := map[riskOrder]int{}
+	for _, order := range orders {
+		key := riskOrder{ProductCode: order.ProductCode, Side: order.Side}
+		i, ok := index[key]
+		if !ok {
+			i = len(exposures)
+			index[key] = i
+			exposures = append(exposures, key)
+		}
+		exposures[i].Size += order.Size
+	}
+	return exposures
+}
+
+func uniqueProductCodes(orders []riskOrder) []ProductCode {
+	productCodes := []ProductCode{}
+	seen := map[ProductCode]bool{}
+	for _, order := range orders {
+		if seen[order.ProductCode] {
+			continue
+		}
+		seen[order.ProductCode] = true
+		productCodes = append(productCodes, order.ProductCode)
+	}
+	return productCodes
+}
+
+// 指値が最終取引価格からpercent%より離れている場合は拒否する
+func checkPriceCollar(order riskOrder, ticker api.TickerFromBitFlyer, percent float64) *RiskRejection {
+	if percent <= 0 || order.ChildOrderType != consts.ChildOrderTypeLimit || ticker.Ltp <= 0 {
+		return nil
+	}
+
+	deviation := math.Abs(order.Price-ticker.Ltp) / ticker.Ltp * 100
+	if deviation <= percent {
+		return nil
+	}
+	return &RiskRejection{
+		Rule:        RiskRulePriceCollar,
+		ProductCode: string(order.ProductCode),
+		Limit:       percent,
+		Actual:      math.Round(deviation*100) / 100,
+		Message:     fmt.Sprintf("limit price %v is %.2f%% away from the last traded price %v", order.Price, deviation, ticker.Ltp),
+	}
+}
+
+// 成行注文は買いなら最良売り気配、売りなら最良買い気配で約定するとみなして金額を見積もる
+func checkOrderNotional(order riskOrder, ticker api.TickerFromBitFlyer, maxNotional float64) *RiskRejection {
+	if maxNotional <= 0 {
+		return nil
+	}
+
+	price := order.Price
+	if order.ChildOrderType == consts.ChildOrderTypeMarket {
+		price = ticker.BestBid
+		if order.Side == consts.SideBuy {
+			price = ticker.BestAsk
+		}
+		if price <= 0 {
+			price = ticker.Ltp
+		}
+	}
+
+	notional := price * order.Size
+	if notional <= maxNotional {
+		return nil
+	}
+	return &RiskRejection{
+		Rule:        RiskRuleMaxOrderNotional,
+		ProductCode: string(order.ProductCode),
+		Limit:       maxNotional,
+		Actual:      notional,
+		Message:     fmt.Sprintf("order notional %v %s exceeds the limit %v", notional, quoteCurrency(string(order.ProductCode)), maxNotional),
+	}
+}
+
+// 約定後の建玉の絶対値が上限を超える場合は拒否する。建玉を減らす方向の注文は常に通す
+func checkPosition(order riskOrder, position, maxPosition float64) *RiskRejection {
+	after := position + order.Size
+	if order.Side == consts.SideSell {
+		after = position - order.Size
+	}
+
+	if math.Abs(after) <= maxPosition || math.Abs(after) <= math.Abs(position) {
+		return nil
+	}
+	return &RiskRejection{
+		Rule:        RiskRuleMaxPosition,
+		ProductCode: string(order.ProductCode),
+		Limit:       maxPosition,
+		Actual:      math.Abs(after),
+		Message:     fmt.Sprintf("position after the order %v exceeds the limit %v", after, maxPosition),
+	}
+}
+
+// FXは建玉の合計、現物は基軸通貨の残高を現在の建玉とみなす
+func (b *BitFlyerUsecase) currentPosition(ctx context.Context, productCode ProductCode) (float64, error) {
+	if marketType, _ := defaultMarketRegistry.MarketType(string(productCode)); marketType == consts.MarketTypeFX {
+		positions, err := b.BitFlyerAPI.GetPositions(ctx, string(productCode))
+		if err != nil {
+			return 0, err
+		}
+		return summarizePositions(string(productCode), positions).NetSize, nil
+	}
+
+	balances, err := b.BitFlyerAPI.GetBalance(ctx)
+	if err != nil {
+		return 0, err
+	}
+	currency, _, _ := strings.Cut(string(productCode), "_")
+	for _, balance := range balances {
+		if balance.CurrencyCode == currency {
+			return balance.Amount, nil
+		}
+	}
+	return 0, nil
+}
+
+// 日次損失は同じ決済通貨の商品すべての実現損益を合計して求める
+func (b *BitFlyerUsecase) dailyLoss(ctx context.Context, productCode ProductCode, now time.Time) (float64, error) {
+	quote := quoteCurrency(string(productCode))
+	since := startOfDay(now)
+	if realized, ok := b.dailyPnL.get(quote, since, now); ok {
+		return -realized, nil
+	}
+
+	var realized float64
+	for _, m := range defaultMarketRegistry.Markets().Markets {
+		if quoteCurrency(m.ProductCode) != quote {
+			continue
+		}
+		pnl, err := b.realizedPnLSince(ctx, ProductCode(m.ProductCode), since)
+		if err != nil {
+			return 0, err
+		}
+		realized += pnl
+	}
+	b.dailyPnL.set(quote, since, realized, now)
+	return -realized, nil
+}
+
+// dailyPnLCache は決済通貨ごとの当日の実現損益を覚えておく。nilの場合は何も覚えない
+type dailyPnLCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]dailyPnLEntry
+}
+
+type dailyPnLEntry struct {
+	day       time.Time
+	realized  float64
+	fetchedAt time.Time
+}
+
+func newDailyPnLCache(ttl time.Duration) *dailyPnLCache {
+	return &dailyPnLCache{ttl: ttl, entries: map[string]dailyPnLEntry{}}
+}
+
+// 日付が変わった場合やttlを過ぎた場合は計算し直す
+func (c *dailyPnLCache) get(quote string, day, now time.Time) (float64, bool) {
+	if c == nil {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[quote]
+	if !ok || !e.day.Equal(day) || now.Sub(e.fetchedAt) >= c.ttl {
+		return 0, false
+	}
+	return e.realized, true
+}
+
+func (c *dailyPnLCache) set(quote string, day time.Time, realized float64, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[quote] = dailyPnLEntry{day: day, realized: realized, fetchedAt: now}
+}
+
+// since以降の実現損益を求める。sinceの時点で持っていた建玉は現在の建玉から期間中の約定を差し引いて求め、
+// その平均取得単価はdailyLossLookbackまで遡った約定から見積もる
+func (b *BitFlyerUsecase) realizedPnLSince(ctx context.Context, productCode ProductCode, since time.Time) (float64, error) {
+	executions, err := b.executionsSince(ctx, productCode, 0, since)
+	if err != nil {
+		return 0, err
+	}
+	// 約定がなければ損益もないため、建玉の取得を省く
+	if len(executions) == 0 {
+		return 0, nil
+	}
+
+	position, err := b.currentPosition(ctx, productCode)
+	if err != nil {
+		return 0, err
+	}
+	opening := position - netSize(executions)
+	if math.Abs(opening) < 1e-12 {
+		return realizedPnL(executions, 0, 0), nil
+	}
+
+	earlier, err := b.executionsSince(ctx, productCode, executions[len(executions)-1].ID, since.Add(-dailyLossLookback))
+	if err != nil {
+		return 0, err
+	}
+	return realizedPnL(executions, opening, openingAverage(earlier, opening, executions[len(executions)-1].Price)), nil
+}
+
+// 遡る期間にも平均取得単価の手がかりとなる約定がない場合は、期間中の最初の約定価格を使う
+func openingAverage(earlier []api.MyExecutionFromBitFlyer, opening, fallback float64) float64 {
+	if len(earlier) == 0 {
+		return fallback
+	}
+	// 遡った期間の最初の建玉は、その期間で最も古い約定価格で建てたとみなす
+	t := pnlTracker{position: opening - netSize(earlier), average: earlier[len(earlier)-1].Price}
+	for i := len(earlier) - 1; i >= 0; i-- {
+		t.apply(earlier[i])
+	}
+	if t.average <= 0 {
+		return fallback
+	}
+	return t.average
+}
+
+// 買いを正、売りを負とした約定の合計サイズ
+func netSize(executions []api.MyExecutionFromBitFlyer) float64 {
+	var size float64
+	for _, e := range executions {
+		if e.Side == consts.SideSell {
+			size -= e.Size
+		} else {
+			size += e.Size
+		}
+	}
+	return size
+}
+
+// BTC_JPYやFX_BTC_JPYならJPY
+func quoteCurrency(productCode string) string {
+	return productCode[strings.LastIndex(productCode, "_")+1:]
+}
+
+// beforeより古くsince以降の自分の約定を新しい順に取得する。beforeが0なら最新から取得する。
+// 取得できるページ数を超えた分は含まれない
+func (b *BitFlyerUsecase) executionsSince(ctx context.Context, productCode ProductCode, before int64, since time.Time) ([]api.MyExecutionFromBitFlyer, error) {
+	it := api.NewMyExecutionIterator(ctx, b.BitFlyerAPI, api.GetMyExecutionsRequest{
+		ProductCode: string(productCode),
+		Pagination:  api.Pagination{Count: consts.MaxPaginationCount, Before: before},
+	})
+
+	executions := []api.MyExecutionFromBitFlyer{}
+	for i := 0; i < consts.MaxPaginationPages && it.HasNext(); i++ {
+		page, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range page {
+			execDate, err := time.Parse(bitFlyerEventDateLayout, e.ExecDate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse exec date %q: %w", e.ExecDate, err)
+			}
+			if execDate.Before(since) {
+				return executions, nil
+			}
+			executions = append(executions, e)
+		}
+	}
+	return executions, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(jst)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, jst)
+}
+
+// pnlTracker は移動平均法で建玉(買いが正)と平均取得単価、実現損益を追う
+type pnlTracker struct {
+	position float64
+	average  float64
+	realized float64
+}
+
+// 手数料は約定価格で換算して差し引く
+func (t *pnlTracker) apply(e api.MyExecutionFromBitFlyer) {
+	size := e.Size
+	if e.Side == consts.SideSell {
+		size = -size
+	}
+
+	switch {
+	case t.position == 0 || (t.position > 0) == (size > 0):
+		t.average = (t.average*math.Abs(t.position) + e.Price*e.Size) / (math.Abs(t.position) + e.Size)
+		t.position += size
+	default:
+		closed := math.Min(e.Size, math.Abs(t.position))
+		if t.position > 0 {
+			t.realized += (e.Price - t.average) * closed
+		} else {
+			t.realized += (t.average - e.Price) * closed
+		}
+		t.position += size
+		if math.Abs(t.position) < 1e-12 {
+			t.position = 0
+		}
+		// 反対方向に建玉が残った場合はその約定価格が新しい平均取得単価になる
+		if e.Size > closed {
+			t.average = e.Price
+		}
+	}
+	t.realized -= e.Commission * e.Price
+}
+
+// 新しい順の約定から、期間の最初にopeningの建玉を平均取得単価averageで持っていたとして実現損益を計算する
+func realizedPnL(executions []api.MyExecutionFromBitFlyer, opening, average float64) float64 {
+	t := pnlTracker{position: opening, average: average}
+	for i := len(executions) - 1; i >= 0; i-- {
+		t.apply(executions[i])
+	}
+	return t.realized
+}
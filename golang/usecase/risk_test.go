@@ -0,0 +1,440 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"bitcoin-app-golang/api"
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+func TestBitFlyerUsecase_checkRisk(t *testing.T) {
+	ticker := api.TickerFromBitFlyer{Ltp: 5000000, BestBid: 4999000, BestAsk: 5001000}
+	limits := config.Risk{
+		MaxOrderNotional:   map[string]float64{"JPY": 1000000},
+		MaxPosition:        map[string]float64{consts.ProductCodeBTCJPY: 0.1},
+		MaxOpenOrders:      2,
+		MaxDailyLoss:       map[string]float64{"JPY": 10000},
+		PriceCollarPercent: 5,
+	}
+	limitBuy := riskOrder{
+		ProductCode:    consts.ProductCodeBTCJPY,
+		Side:           consts.SideBuy,
+		ChildOrderType: consts.ChildOrderTypeLimit,
+		Price:          5000000,
+		Size:           0.01,
+	}
+	today := time.Now().UTC().Format(bitFlyerEventDateLayout)
+
+	tests := []struct {
+		name         string
+		orders       []riskOrder
+		balance      float64
+		openOrders   int
+		parentOrders int
+		executions   []api.MyExecutionFromBitFlyer
+		wantStatus   int
+		wantRule     RiskRule
+	}{
+		{
+			name:       "within all limits",
+			orders:     []riskOrder{limitBuy},
+			balance:    0.05,
+			openOrders: 1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "price collar",
+			orders: []riskOrder{{
+				ProductCode:    consts.ProductCodeBTCJPY,
+				Side:           consts.SideBuy,
+				ChildOrderType: consts.ChildOrderTypeLimit,
+				Price:          5500000,
+				Size:           0.01,
+			}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRulePriceCollar,
+		},
+		{
+			name: "fat-fingered MARKET size",
+			orders: []riskOrder{{
+				ProductCode:    consts.ProductCodeBTCJPY,
+				Side:           consts.SideBuy,
+				ChildOrderType: consts.ChildOrderTypeMarket,
+				Size:           1,
+			}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxOrderNotional,
+		},
+		{
+			name:       "position limit",
+			orders:     []riskOrder{limitBuy},
+			balance:    0.095,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxPosition,
+		},
+		{
+			name:       "open orders limit",
+			orders:     []riskOrder{limitBuy},
+			openOrders: 2,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxOpenOrders,
+		},
+		{
+			name:         "open parent orders count towards the limit",
+			orders:       []riskOrder{limitBuy},
+			openOrders:   1,
+			parentOrders: 1,
+			wantStatus:   http.StatusUnprocessableEntity,
+			wantRule:     RiskRuleMaxOpenOrders,
+		},
+		{
+			name:   "daily loss limit",
+			orders: []riskOrder{limitBuy},
+			executions: []api.MyExecutionFromBitFlyer{
+				{ID: 2, Side: consts.SideSell, Price: 4000000, Size: 0.01, ExecDate: today},
+				{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: today},
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxDailyLoss,
+		},
+		{
+			name: "quote currency without a notional limit",
+			orders: []riskOrder{{
+				ProductCode:    consts.ProductCodeETHBTC,
+				Side:           consts.SideBuy,
+				ChildOrderType: consts.ChildOrderTypeMarket,
+				Size:           0.01,
+			}},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxOrderNotional,
+		},
+		{
+			name: "legs within the position limit on their own",
+			orders: []riskOrder{
+				limitBuy,
+				{ProductCode: consts.ProductCodeBTCJPY, Side: consts.SideSell, ChildOrderType: consts.ChildOrderTypeLimit, Price: 5100000, Size: 0.01},
+			},
+			balance:    0.08,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "combined legs exceed the position limit",
+			orders: []riskOrder{
+				limitBuy,
+				{ProductCode: consts.ProductCodeBTCJPY, Side: consts.SideBuy, ChildOrderType: consts.ChildOrderTypeLimit, Price: 4900000, Size: 0.01},
+			},
+			balance:    0.085,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxPosition,
+		},
+		{
+			name: "any leg over the notional limit",
+			orders: []riskOrder{
+				limitBuy,
+				{ProductCode: consts.ProductCodeBTCJPY, Side: consts.SideSell, ChildOrderType: consts.ConditionTypeStop, Price: 4900000, Size: 0.5},
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantRule:   RiskRuleMaxOrderNotional,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := TestConfig
+			cfg.Risk = limits
+			b := &BitFlyerUsecase{
+				Config: cfg,
+				BitFlyerAPI: &MockBitFlyerAPI{
+					GetTickerFunc: func(string) (api.TickerFromBitFlyer, error) {
+						return ticker, nil
+					},
+					GetBalanceFunc: func() ([]api.BalanceFromBitFlyer, error) {
+						return []api.BalanceFromBitFlyer{{CurrencyCode: "BTC", Amount: tt.balance}}, nil
+					},
+					GetChildOrdersFunc: func(api.GetChildOrdersRequest) ([]api.ChildOrderFromBitFlyer, error) {
+						return make([]api.ChildOrderFromBitFlyer, tt.openOrders), nil
+					},
+					GetParentOrdersFunc: func(api.GetParentOrdersRequest) ([]api.ParentOrderFromBitFlyer, error) {
+						return make([]api.ParentOrderFromBitFlyer, tt.parentOrders), nil
+					},
+					GetMyExecutionsFunc: func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+						if req.Before != 0 {
+							return []api.MyExecutionFromBitFlyer{}, nil
+						}
+						return tt.executions, nil
+					},
+				},
+			}
+
+			got, err := b.checkRisk(context.Background(), tt.orders...)
+			if got != tt.wantStatus {
+				t.Errorf("BitFlyerUsecase.checkRisk() status = %v, want %v", got, tt.wantStatus)
+			}
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Errorf("BitFlyerUsecase.checkRisk() error = %v, want nil", err)
+				}
+				return
+			}
+			var rejection *RiskRejection
+			if !errors.As(err, &rejection) || !errors.Is(err, ErrRiskRejected) {
+				t.Fatalf("BitFlyerUsecase.checkRisk() error = %v, want RiskRejection", err)
+			}
+			if rejection.Rule != tt.wantRule {
+				t.Errorf("RiskRejection.Rule = %v, want %v", rejection.Rule, tt.wantRule)
+			}
+		})
+	}
+}
+
+func Test_quoteLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		limits        map[string]float64
+		productCode   ProductCode
+		want          float64
+		wantRejection bool
+	}{
+		{name: "not configured", limits: nil, productCode: consts.ProductCodeETHBTC, want: 0},
+		{name: "spot", limits: map[string]float64{"JPY": 50000, "BTC": 0.005}, productCode: consts.ProductCodeETHBTC, want: 0.005},
+		{name: "FX", limits: map[string]float64{"JPY": 50000}, productCode: consts.ProductCodeFXBTCJPY, want: 50000},
+		{name: "unknown quote currency", limits: map[string]float64{"JPY": 50000}, productCode: consts.ProductCodeBCHBTC, wantRejection: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rejection := quoteLimit(tt.limits, RiskRuleMaxDailyLoss, tt.productCode)
+			if (rejection != nil) != tt.wantRejection {
+				t.Errorf("quoteLimit() rejection = %v, wantRejection %v", rejection, tt.wantRejection)
+			}
+			if got != tt.want {
+				t.Errorf("quoteLimit() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_checkPosition(t *testing.T) {
+	order := func(side string, size float64) riskOrder {
+		return riskOrder{ProductCode: consts.ProductCodeFXBTCJPY, Side: side, Size: size}
+	}
+	tests := []struct {
+		name     string
+		order    riskOrder
+		position float64
+		wantErr  bool
+	}{
+		{name: "within limit", order: order(consts.SideBuy, 0.05), position: 0.05, wantErr: false},
+		{name: "exceeds limit", order: order(consts.SideBuy, 0.06), position: 0.05, wantErr: true},
+		{name: "short exceeds limit", order: order(consts.SideSell, 0.2), position: 0, wantErr: true},
+		{name: "reducing an over-limit position", order: order(consts.SideSell, 0.05), position: 0.3, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkPosition(tt.order, tt.position, 0.1); (got != nil) != tt.wantErr {
+				t.Errorf("checkPosition() = %v, wantErr %v", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_realizedPnL(t *testing.T) {
+	tests := []struct {
+		name       string
+		executions []api.MyExecutionFromBitFlyer
+		opening    float64
+		average    float64
+		want       float64
+	}{
+		{
+			name: "long then close with profit",
+			executions: []api.MyExecutionFromBitFlyer{
+				{Side: consts.SideSell, Price: 5100000, Size: 0.02},
+				{Side: consts.SideBuy, Price: 5000000, Size: 0.01},
+				{Side: consts.SideBuy, Price: 4900000, Size: 0.01},
+			},
+			want: 3000,
+		},
+		{
+			name: "short then flip to long",
+			executions: []api.MyExecutionFromBitFlyer{
+				{Side: consts.SideSell, Price: 5000000, Size: 0.01},
+				{Side: consts.SideBuy, Price: 5100000, Size: 0.02},
+				{Side: consts.SideSell, Price: 5200000, Size: 0.01},
+			},
+			want: 0,
+		},
+		{
+			name: "commission is deducted",
+			executions: []api.MyExecutionFromBitFlyer{
+				{Side: consts.SideBuy, Price: 5000000, Size: 0.01, Commission: 0.0000015},
+			},
+			want: -7.5,
+		},
+		{
+			name: "close a position carried over",
+			executions: []api.MyExecutionFromBitFlyer{
+				{Side: consts.SideSell, Price: 4000000, Size: 0.01},
+			},
+			opening: 0.01,
+			average: 5000000,
+			want:    -10000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := realizedPnL(tt.executions, tt.opening, tt.average); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("realizedPnL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_dailyLoss(t *testing.T) {
+	now := time.Now()
+	today := now.UTC().Format(bitFlyerEventDateLayout)
+	yesterday := startOfDay(now).Add(-time.Hour).UTC().Format(bitFlyerEventDateLayout)
+
+	tests := []struct {
+		name        string
+		productCode ProductCode
+		balance     float64
+		executions  map[string][]api.MyExecutionFromBitFlyer
+		want        float64
+	}{
+		{
+			name:        "no executions today",
+			productCode: consts.ProductCodeBTCJPY,
+			executions: map[string][]api.MyExecutionFromBitFlyer{
+				consts.ProductCodeBTCJPY: {{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: yesterday}},
+			},
+			want: 0,
+		},
+		{
+			// 前日に買った分を当日に売った損失も含める
+			name:        "position carried over midnight",
+			productCode: consts.ProductCodeBTCJPY,
+			executions: map[string][]api.MyExecutionFromBitFlyer{
+				consts.ProductCodeBTCJPY: {
+					{ID: 2, Side: consts.SideSell, Price: 4000000, Size: 0.01, ExecDate: today},
+					{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: yesterday},
+				},
+			},
+			want: 10000,
+		},
+		{
+			name:        "carried position still open",
+			productCode: consts.ProductCodeBTCJPY,
+			balance:     0.01,
+			executions: map[string][]api.MyExecutionFromBitFlyer{
+				consts.ProductCodeBTCJPY: {
+					{ID: 3, Side: consts.SideSell, Price: 4000000, Size: 0.01, ExecDate: today},
+					{ID: 2, Side: consts.SideBuy, Price: 4600000, Size: 0.01, ExecDate: yesterday},
+					{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: yesterday},
+				},
+			},
+			want: 8000,
+		},
+		{
+			// 同じ円建ての現物とFXの損失を合計し、BTC建ての商品は含めない
+			name:        "losses across products",
+			productCode: consts.ProductCodeFXBTCJPY,
+			executions: map[string][]api.MyExecutionFromBitFlyer{
+				consts.ProductCodeBTCJPY: {
+					{ID: 2, Side: consts.SideSell, Price: 4400000, Size: 0.01, ExecDate: today},
+					{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: today},
+				},
+				consts.ProductCodeFXBTCJPY: {
+					{ID: 2, Side: consts.SideBuy, Price: 5600000, Size: 0.01, ExecDate: today},
+					{ID: 1, Side: consts.SideSell, Price: 5000000, Size: 0.01, ExecDate: today},
+				},
+				consts.ProductCodeETHBTC: {
+					{ID: 2, Side: consts.SideSell, Price: 0.01, Size: 1, ExecDate: today},
+					{ID: 1, Side: consts.SideBuy, Price: 0.05, Size: 1, ExecDate: today},
+				},
+			},
+			want: 12000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &BitFlyerUsecase{
+				Config: TestConfig,
+				BitFlyerAPI: &MockBitFlyerAPI{
+					GetBalanceFunc: func() ([]api.BalanceFromBitFlyer, error) {
+						return []api.BalanceFromBitFlyer{{CurrencyCode: "BTC", Amount: tt.balance}}, nil
+					},
+					GetMyExecutionsFunc: func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+						page := []api.MyExecutionFromBitFlyer{}
+						for _, e := range tt.executions[req.ProductCode] {
+							if req.Before == 0 || e.ID < req.Before {
+								page = append(page, e)
+							}
+						}
+						return page, nil
+					},
+				},
+			}
+
+			got, err := b.dailyLoss(context.Background(), tt.productCode, now)
+			if err != nil {
+				t.Fatalf("BitFlyerUsecase.dailyLoss() error = %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("BitFlyerUsecase.dailyLoss() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBitFlyerUsecase_dailyLoss_cache(t *testing.T) {
+	now := time.Now()
+	today := now.UTC().Format(bitFlyerEventDateLayout)
+
+	calls := 0
+	b := &BitFlyerUsecase{
+		Config: TestConfig,
+		BitFlyerAPI: &MockBitFlyerAPI{
+			GetMyExecutionsFunc: func(req api.GetMyExecutionsRequest) ([]api.MyExecutionFromBitFlyer, error) {
+				calls++
+				if req.ProductCode != consts.ProductCodeBTCJPY || req.Before != 0 {
+					return []api.MyExecutionFromBitFlyer{}, nil
+				}
+				return []api.MyExecutionFromBitFlyer{
+					{ID: 2, Side: consts.SideSell, Price: 4000000, Size: 0.01, ExecDate: today},
+					{ID: 1, Side: consts.SideBuy, Price: 5000000, Size: 0.01, ExecDate: today},
+				}, nil
+			},
+		},
+		dailyPnL: newDailyPnLCache(time.Minute),
+	}
+
+	for _, tt := range []struct {
+		name      string
+		now       time.Time
+		wantFetch bool
+	}{
+		{name: "first order", now: now, wantFetch: true},
+		{name: "within ttl", now: now.Add(30 * time.Second), wantFetch: false},
+		{name: "ttl expired", now: now.Add(time.Minute), wantFetch: true},
+	} {
+		before := calls
+		got, err := b.dailyLoss(context.Background(), consts.ProductCodeBTCJPY, tt.now)
+		if err != nil {
+			t.Fatalf("%s: BitFlyerUsecase.dailyLoss() error = %v", tt.name, err)
+		}
+		// ttlを過ぎた時点では日付が変わっている場合があるため、損失はそれより前の呼び出しで確かめる
+		if !tt.wantFetch || tt.now.Equal(now) {
+			if math.Abs(got-10000) > 1e-6 {
+				t.Errorf("%s: BitFlyerUsecase.dailyLoss() = %v, want 10000", tt.name, got)
+			}
+		}
+		if fetched := calls > before; fetched != tt.wantFetch {
+			t.Errorf("%s: fetched executions = %v, want %v", tt.name, fetched, tt.wantFetch)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+var (
+	ErrSizeBelowMinimum = errors.New("size is below the minimum order size")
+	ErrSizeNotOnStep    = errors.New("size is not a multiple of the size step")
+	ErrPriceNotOnTick   = errors.New("price is not a multiple of the price tick")
+)
+
+// 丸め誤差で刻みの倍数を弾かないための許容誤差。刻みに対する割合
+const tradingRuleEpsilon = 1e-6
+
+// TradingRule は商品ごとの最小発注数量、数量の刻み、価格の刻み。0の項目はチェックしない
+type TradingRule struct {
+	MinSize   float64 `json:"min_size"`
+	SizeStep  float64 `json:"size_step"`
+	PriceTick float64 `json:"price_tick"`
+}
+
+// bitFlyerの取引ルールに合わせたデフォルト値。configのtradingRulesで上書きできる
+var defaultTradingRules = map[string]TradingRule{
+	consts.ProductCodeBTCJPY:   {MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+	consts.ProductCodeFXBTCJPY: {MinSize: 0.01, SizeStep: 0.00000001, PriceTick: 1},
+	consts.ProductCodeETHJPY:   {MinSize: 0.01, SizeStep: 0.00000001, PriceTick: 1},
+	consts.ProductCodeXRPJPY:   {MinSize: 0.1, SizeStep: 0.000001, PriceTick: 0.01},
+	consts.ProductCodeXLMJPY:   {MinSize: 0.1, SizeStep: 0.000001, PriceTick: 0.001},
+	consts.ProductCodeMONAJPY:  {MinSize: 0.1, SizeStep: 0.000001, PriceTick: 0.001},
+	consts.ProductCodeETHBTC:   {MinSize: 0.01, SizeStep: 0.00000001, PriceTick: 0.00001},
+	consts.ProductCodeBCHBTC:   {MinSize: 0.01, SizeStep: 0.00000001, PriceTick: 0.00001},
+}
+
+// デフォルト値にconfigで指定した項目を上書きしたルールを返す。
+// どちらにもない商品はゼロ値を返し、数量と価格の正負だけを検証する
+func tradingRuleFor(cfg config.Config, productCode ProductCode) TradingRule {
+	rule := defaultTradingRules[string(productCode)]
+	override, ok := cfg.TradingRules[string(productCode)]
+	if !ok {
+		return rule
+	}
+
+	if override.MinSize > 0 {
+		rule.MinSize = override.MinSize
+	}
+	if override.SizeStep > 0 {
+		rule.SizeStep = override.SizeStep
+	}
+	if override.PriceTick > 0 {
+		rule.PriceTick = override.PriceTick
+	}
+	return rule
+}
+
+// 発注内容をルールに照らして検証し、送信する価格と数量を返す。
+// autoRoundがtrueの場合は刻みに合わない値を不利にならない方向に丸める。
+// 価格は買いなら切り下げ、売りなら切り上げ、数量は常に切り捨てる。
+func (r TradingRule) apply(side string, orderType ChildOrderType, price, size float64, autoRound bool) (float64, float64, error) {
+	if size <= 0 {
+		return 0, 0, errors.New("size must be greater than 0")
+	}
+
+	// 成行注文の価格はbitFlyerで無視されるため送らない
+	if orderType == consts.ChildOrderTypeMarket {
+		price = 0
+	} else if r.PriceTick > 0 && !isMultipleOf(price, r.PriceTick) {
+		if !autoRound {
+			return 0, 0, fmt.Errorf("%w: price %v, tick %v", ErrPriceNotOnTick, price, r.PriceTick)
+		}
+		rounded := roundDownToStep(price, r.PriceTick)
+		if side == consts.SideSell {
+			rounded = roundUpToStep(price, r.PriceTick)
+		}
+		log.Printf("Rounded price from %v to %v (tick %v)", price, rounded, r.PriceTick)
+		price = rounded
+	}
+
+	if r.SizeStep > 0 && !isMultipleOf(size, r.SizeStep) {
+		if !autoRound {
+			return 0, 0, fmt.Errorf("%w: size %v, step %v", ErrSizeNotOnStep, size, r.SizeStep)
+		}
+		rounded := roundDownToStep(size, r.SizeStep)
+		log.Printf("Rounded size from %v to %v (step %v)", size, rounded, r.SizeStep)
+		size = rounded
+	}
+
+	if size < r.MinSize-tradingRuleEpsilon*r.SizeStep {
+		return 0, 0, fmt.Errorf("%w: size %v, minimum %v", ErrSizeBelowMinimum, size, r.MinSize)
+	}
+	if orderType == consts.ChildOrderTypeLimit && price <= 0 {
+		return 0, 0, errors.New("price must be greater than 0 for LIMIT orders")
+	}
+
+	return price, size, nil
+}
+
+func isMultipleOf(v, step float64) bool {
+	n := v / step
+	return math.Abs(n-math.Round(n)) < tradingRuleEpsilon
+}
+
+func roundDownToStep(v, step float64) float64 {
+	return truncateToStepDecimals(math.Floor(v/step+tradingRuleEpsilon)*step, step)
+}
+
+func roundUpToStep(v, step float64) float64 {
+	return truncateToStepDecimals(math.Ceil(v/step-tradingRuleEpsilon)*step, step)
+}
+
+// 0.001*3が0.0030000000000000005になるような誤差を刻みの桁数で落とす
+func truncateToStepDecimals(v, step float64) float64 {
+	decimals := math.Max(0, math.Ceil(-math.Log10(step)-1e-9))
+	pow := math.Pow(10, decimals)
+	return math.Round(v*pow) / pow
+}
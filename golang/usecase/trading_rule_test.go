@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"bitcoin-app-golang/config"
+	"bitcoin-app-golang/consts"
+)
+
+func Test_tradingRuleFor(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		productCode ProductCode
+		want        TradingRule
+	}{
+		{
+			name:        "default",
+			cfg:         config.Config{},
+			productCode: consts.ProductCodeBTCJPY,
+			want:        TradingRule{MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1},
+		},
+		{
+			name: "partially overridden by config",
+			cfg: config.Config{TradingRules: map[string]config.TradingRule{
+				consts.ProductCodeBTCJPY: {MinSize: 0.01},
+			}},
+			productCode: consts.ProductCodeBTCJPY,
+			want:        TradingRule{MinSize: 0.01, SizeStep: 0.00000001, PriceTick: 1},
+		},
+		{
+			name: "only in config",
+			cfg: config.Config{TradingRules: map[string]config.TradingRule{
+				"NEW_JPY": {MinSize: 1, SizeStep: 1, PriceTick: 0.1},
+			}},
+			productCode: "NEW_JPY",
+			want:        TradingRule{MinSize: 1, SizeStep: 1, PriceTick: 0.1},
+		},
+		{
+			name:        "unknown product",
+			cfg:         config.Config{},
+			productCode: "NEW_JPY",
+			want:        TradingRule{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tradingRuleFor(tt.cfg, tt.productCode); got != tt.want {
+				t.Errorf("tradingRuleFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingRule_apply(t *testing.T) {
+	btc := TradingRule{MinSize: 0.001, SizeStep: 0.00000001, PriceTick: 1}
+	xrp := TradingRule{MinSize: 0.1, SizeStep: 0.000001, PriceTick: 0.01}
+
+	tests := []struct {
+		name      string
+		rule      TradingRule
+		side      string
+		orderType ChildOrderType
+		price     float64
+		size      float64
+		autoRound bool
+		wantPrice float64
+		wantSize  float64
+		wantErr   error
+	}{
+		{
+			name:      "valid LIMIT order",
+			rule:      btc,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeLimit,
+			price:     5000000,
+			size:      0.003,
+			wantPrice: 5000000,
+			wantSize:  0.003,
+		},
+		{
+			name:      "price is ignored for MARKET orders",
+			rule:      btc,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeMarket,
+			price:     5000000.5,
+			size:      0.001,
+			wantPrice: 0,
+			wantSize:  0.001,
+		},
+		{
+			name:      "size below minimum",
+			rule:      btc,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeMarket,
+			size:      0.0009,
+			wantErr:   ErrSizeBelowMinimum,
+		},
+		{
+			name:      "price off tick",
+			rule:      btc,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeLimit,
+			price:     5000000.5,
+			size:      0.001,
+			wantErr:   ErrPriceNotOnTick,
+		},
+		{
+			name:      "size off step",
+			rule:      btc,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeLimit,
+			price:     5000000,
+			size:      0.001000005,
+			wantErr:   ErrSizeNotOnStep,
+		},
+		{
+			name:      "auto round buy price down",
+			rule:      xrp,
+			side:      consts.SideBuy,
+			orderType: consts.ChildOrderTypeLimit,
+			price:     80.129,
+			size:      10.1234567,
+			autoRound: true,
+			wantPrice: 80.12,
+			wantSize:  10.123456,
+		},
+		{
+			name:      "auto round sell price up",
+			rule:      xrp,
+			side:      consts.SideSell,
+			orderType: consts.ChildOrderTypeLimit,
+			price:     80.121,
+			size:      10,
+			autoRound: true,
+			wantPrice: 80.13,
+			wantSize:  10,
+		},
+		{
+			name:      "auto round below minimum",
+			rule:      btc,
+			side:      consts.SideSell,
+			orderType: consts.ChildOrderTypeMarket,
+			size:      0.000999999999,
+			autoRound: true,
+			wantErr:   ErrSizeBelowMinimum,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPrice, gotSize, err := tt.rule.apply(tt.side, tt.orderType, tt.price, tt.size, tt.autoRound)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TradingRule.apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotPrice != tt.wantPrice {
+				t.Errorf("TradingRule.apply() price = %v, want %v", gotPrice, tt.wantPrice)
+			}
+			if gotSize != tt.wantSize {
+				t.Errorf("TradingRule.apply() size = %v, want %v", gotSize, tt.wantSize)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+# Generated by Django 5.2 on 2026-10-18 12:00
+
+from django.db import migrations, models
+
+
+class Migration(migrations.Migration):
+
+    dependencies = [
+        ('api', '0001_initial'),
+    ]
+
+    operations = [
+        migrations.CreateModel(
+            name='FXStatus',
+            fields=[
+                ('id', models.AutoField(primary_key=True, serialize=False)),
+                ('product_code', models.CharField(max_length=50)),
+                ('funding_rate', models.FloatField()),
+                ('next_funding_rate_settledate', models.CharField(max_length=50)),
+                ('fx_ltp', models.FloatField()),
+                ('spot_ltp', models.FloatField()),
+                ('sfd_ratio', models.FloatField()),
+                ('sfd_fee_rate', models.FloatField()),
+                ('sfd_fee_side', models.CharField(blank=True, max_length=10)),
+                ('created_at', models.DateTimeField(auto_now_add=True)),
+            ],
+            options={
+                'db_table': 'fx_statuses',
+            },
+        ),
+    ]
@@ -0,0 +1,20 @@
+from django.db import models
+
+
+class FXStatus(models.Model):
+    """
+    FX_BTC_JPYのファンディングレートとSFDの乖離率を格納するモデル
+    """
+    id = models.AutoField(primary_key=True)
+    product_code = models.CharField(max_length=50)
+    funding_rate = models.FloatField()
+    next_funding_rate_settledate = models.CharField(max_length=50)
+    fx_ltp = models.FloatField()
+    spot_ltp = models.FloatField()
+    sfd_ratio = models.FloatField()
+    sfd_fee_rate = models.FloatField()
+    sfd_fee_side = models.CharField(max_length=10, blank=True)
+    created_at = models.DateTimeField(auto_now_add=True)
+
+    class Meta:
+        db_table = 'fx_statuses'
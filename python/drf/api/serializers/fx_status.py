@@ -0,0 +1,8 @@
+from rest_framework import serializers
+from ..models.fx_status import FXStatus
+
+
+class FXStatusSerializer(serializers.ModelSerializer):
+    class Meta:
+        model = FXStatus
+        fields = '__all__'
@@ -4,6 +4,7 @@ from . import views
 
 router = routers.SimpleRouter()
 router.register('tickers', views.TickerViewSet)
+router.register('fx-statuses', views.FXStatusViewSet)
 
 urlpatterns = [
     path('', include(router.urls)),
@@ -1,8 +1,15 @@
 from rest_framework import viewsets
+from .models.fx_status import FXStatus
 from .models.ticker import Ticker
+from .serializers.fx_status import FXStatusSerializer
 from .serializers.ticker import TickerSerializer
 
 
 class TickerViewSet(viewsets.ModelViewSet):
     queryset = Ticker.objects.all()
     serializer_class = TickerSerializer
+
+
+class FXStatusViewSet(viewsets.ModelViewSet):
+    queryset = FXStatus.objects.all()
+    serializer_class = FXStatusSerializer